@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+)
+
+type AlarmType int
+
+const (
+	ALARM_HI AlarmType = iota
+	ALARM_HIHI
+	ALARM_LO
+	ALARM_LOLO
+	ALARM_STATE
+	ALARM_ROC
+)
+
+var alarmTypeNames = []string{"HI", "HIHI", "LO", "LOLO", "STATE", "ROC"}
+
+func (t AlarmType) String() string {
+	if int(t) < 0 || int(t) >= len(alarmTypeNames) {
+		return "UNKNOWN"
+	}
+	return alarmTypeNames[t]
+}
+
+func AlarmTypeNames() []string {
+	return alarmTypeNames
+}
+
+type AlarmEventType int
+
+const (
+	ALARM_EVENT_ACTIVE AlarmEventType = iota
+	ALARM_EVENT_ACK
+	ALARM_EVENT_CLEAR
+)
+
+func (e AlarmEventType) String() string {
+	switch e {
+	case ALARM_EVENT_ACTIVE:
+		return "ACTIVE"
+	case ALARM_EVENT_ACK:
+		return "ACK"
+	case ALARM_EVENT_CLEAR:
+		return "CLEAR"
+	}
+	return "UNKNOWN"
+}
+
+type AlarmEvent struct {
+	Time   time.Time
+	Client string
+	Alarm  AlarmConfig
+	Event  AlarmEventType
+	Value  float64
+	Active bool
+	Acked  bool
+}
+
+type AlarmState struct {
+	Client string
+	Config AlarmConfig
+
+	Active     bool
+	Acked      bool
+	Value      float64
+	ActiveTime time.Time
+	ClearTime  time.Time
+
+	pending   bool
+	pendingAt time.Time
+	lastValue float64
+	lastTime  time.Time
+}
+
+func (s *AlarmState) Key() string {
+	return AlarmKey(s.Client, s.Config.Name)
+}
+
+func (s *AlarmState) StateName() string {
+	if s.Active && s.Acked {
+		return "Active Acked"
+	}
+	if s.Active {
+		return "Active"
+	}
+	if !s.Acked {
+		return "Cleared Unacked"
+	}
+	return "Normal"
+}
+
+func (s *AlarmState) condition(value float64, now time.Time) bool {
+	cfg := s.Config
+	switch cfg.Type {
+	case ALARM_HI, ALARM_HIHI:
+		if s.Active {
+			return value > cfg.Limit-cfg.Deadband
+		}
+		return value > cfg.Limit
+	case ALARM_LO, ALARM_LOLO:
+		if s.Active {
+			return value < cfg.Limit+cfg.Deadband
+		}
+		return value < cfg.Limit
+	case ALARM_STATE:
+		return value == cfg.Limit
+	case ALARM_ROC:
+		if s.lastTime.IsZero() {
+			return false
+		}
+		seconds := now.Sub(s.lastTime).Seconds()
+		if seconds <= 0 {
+			return s.Active
+		}
+		rate := math.Abs(value-s.lastValue) / seconds
+		if s.Active {
+			return rate > cfg.Limit-cfg.Deadband
+		}
+		return rate > cfg.Limit
+	}
+	return false
+}
+
+func (s *AlarmState) update(value float64, now time.Time) (AlarmEventType, bool) {
+	cond := s.condition(value, now)
+	s.lastValue, s.lastTime = value, now
+	s.Value = value
+
+	if cond == s.Active {
+		s.pending = false
+		return 0, false
+	}
+
+	delay := s.Config.DelayOff
+	if cond {
+		delay = s.Config.DelayOn
+	}
+
+	if !s.pending {
+		s.pending = true
+		s.pendingAt = now
+	}
+	if now.Sub(s.pendingAt) < time.Duration(delay)*time.Millisecond {
+		return 0, false
+	}
+
+	s.pending = false
+	s.Active = cond
+	if cond {
+		s.Acked = false
+		s.ActiveTime = now
+		return ALARM_EVENT_ACTIVE, true
+	}
+	s.ClearTime = now
+	return ALARM_EVENT_CLEAR, true
+}
+
+func (s *AlarmState) event(eventType AlarmEventType, now time.Time) AlarmEvent {
+	return AlarmEvent{
+		Time:   now,
+		Client: s.Client,
+		Alarm:  s.Config,
+		Event:  eventType,
+		Value:  s.Value,
+		Active: s.Active,
+		Acked:  s.Acked,
+	}
+}
+
+func AlarmKey(client, name string) string {
+	return fmt.Sprintf("%s.%s", client, name)
+}
+
+type AlarmSink interface {
+	Name() string
+	Publish(event AlarmEvent) error
+}
+
+type AlarmQueueSink struct {
+	name  string
+	ctx   context.Context
+	queue *SinkQueue
+}
+
+func NewAlarmQueueSink(ctx context.Context, name string, queue *SinkQueue) *AlarmQueueSink {
+	return &AlarmQueueSink{name: name, ctx: ctx, queue: queue}
+}
+
+func (s *AlarmQueueSink) Name() string {
+	return s.name
+}
+
+func (s *AlarmQueueSink) Publish(event AlarmEvent) error {
+	if !s.queue.Push(s.ctx, event) {
+		return fmt.Errorf("alarm sink %s queue is full", s.name)
+	}
+	return nil
+}
+
+type AlarmFileSink struct {
+	sync.Mutex
+	filename string
+}
+
+func NewAlarmFileSink(dir string) *AlarmFileSink {
+	return &AlarmFileSink{filename: filepath.Join(dir, "alarm.log")}
+}
+
+func (s *AlarmFileSink) Name() string {
+	return "logfile"
+}
+
+func (s *AlarmFileSink) Publish(event AlarmEvent) error {
+	s.Lock()
+	defer s.Unlock()
+
+	file, err := os.OpenFile(s.filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s %s %s %s %s %s value=%0.5f limit=%0.5f severity=%d %s\r\n",
+		event.Time.Format("2006-01-02 15:04:05.000"), event.Event.String(), event.Client,
+		event.Alarm.Name, event.Alarm.Type.String(), event.Alarm.Node.ToString(),
+		event.Value, event.Alarm.Limit, event.Alarm.Severity, event.Alarm.Message)
+	return err
+}
+
+type AlarmEngine struct {
+	sync.RWMutex
+
+	states map[string]*AlarmState
+	nodes  map[string][]*AlarmState
+	sinks  []AlarmSink
+}
+
+func NewAlarmEngine(clients []ClientConfig) *AlarmEngine {
+	engine := &AlarmEngine{
+		states: make(map[string]*AlarmState),
+		nodes:  make(map[string][]*AlarmState),
+		sinks:  make([]AlarmSink, 0),
+	}
+
+	for _, client := range clients {
+		if !client.Enable {
+			continue
+		}
+		for _, alarm := range client.Alarms {
+			if !alarm.Enable {
+				continue
+			}
+			state := &AlarmState{Client: client.Name, Config: alarm, Acked: true}
+			engine.states[state.Key()] = state
+
+			nodeKey := AlarmKey(client.Name, alarm.Node.ToString())
+			engine.nodes[nodeKey] = append(engine.nodes[nodeKey], state)
+		}
+	}
+
+	logs.Info("alarm engine init with %d alarms", len(engine.states))
+	return engine
+}
+
+// Reset replaces the alarms of the client, the disabled client has no alarms
+func (e *AlarmEngine) Reset(client ClientConfig) {
+	e.Lock()
+	defer e.Unlock()
+
+	for key, state := range e.states {
+		if state.Client == client.Name {
+			delete(e.states, key)
+		}
+	}
+	for key, states := range e.nodes {
+		if len(states) > 0 && states[0].Client == client.Name {
+			delete(e.nodes, key)
+		}
+	}
+	if !client.Enable {
+		return
+	}
+
+	for _, alarm := range client.Alarms {
+		if !alarm.Enable {
+			continue
+		}
+		state := &AlarmState{Client: client.Name, Config: alarm, Acked: true}
+		e.states[state.Key()] = state
+
+		nodeKey := AlarmKey(client.Name, alarm.Node.ToString())
+		e.nodes[nodeKey] = append(e.nodes[nodeKey], state)
+	}
+	logs.Info("alarm engine reset client %s alarms", client.Name)
+}
+
+func (e *AlarmEngine) Count() int {
+	e.RLock()
+	defer e.RUnlock()
+
+	return len(e.states)
+}
+
+func (e *AlarmEngine) AddSink(sink AlarmSink) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.sinks = append(e.sinks, sink)
+	logs.Info("alarm engine add sink %s", sink.Name())
+}
+
+func (e *AlarmEngine) publish(sinks []AlarmSink, events []AlarmEvent) {
+	for _, event := range events {
+		for _, sink := range sinks {
+			err := sink.Publish(event)
+			if err != nil {
+				logs.Warning("alarm %s publish to sink %s failed, %s", event.Alarm.Name, sink.Name(), err.Error())
+			}
+		}
+	}
+}
+
+func (e *AlarmEngine) Evaluate(client string, nodes []NodeInfo, values []*NodeValue) {
+	now := time.Now()
+	events := make([]AlarmEvent, 0)
+
+	e.Lock()
+	for i, node := range nodes {
+		if i >= len(values) {
+			break
+		}
+		if values[i] == nil {
+			continue
+		}
+		states, ok := e.nodes[AlarmKey(client, node.ToString())]
+		if !ok {
+			continue
+		}
+		value, ok := values[i].ToFloat()
+		if !ok {
+			continue
+		}
+		for _, state := range states {
+			eventType, changed := state.update(value, now)
+			if changed {
+				events = append(events, state.event(eventType, now))
+			}
+		}
+	}
+	sinks := e.sinks
+	e.Unlock()
+
+	e.publish(sinks, events)
+}
+
+func (e *AlarmEngine) Acknowledge(keys ...string) {
+	now := time.Now()
+	events := make([]AlarmEvent, 0)
+
+	e.Lock()
+	for _, key := range keys {
+		state, ok := e.states[key]
+		if !ok || state.Acked {
+			continue
+		}
+		state.Acked = true
+		events = append(events, state.event(ALARM_EVENT_ACK, now))
+	}
+	sinks := e.sinks
+	e.Unlock()
+
+	e.publish(sinks, events)
+}
+
+func (e *AlarmEngine) AcknowledgeAll() {
+	keys := make([]string, 0)
+	for _, state := range e.ActiveList() {
+		keys = append(keys, state.Key())
+	}
+	e.Acknowledge(keys...)
+}
+
+func (e *AlarmEngine) ActiveList() []AlarmState {
+	e.RLock()
+	defer e.RUnlock()
+
+	output := make([]AlarmState, 0)
+	for _, state := range e.states {
+		if state.Active || !state.Acked {
+			output = append(output, *state)
+		}
+	}
+	sort.Slice(output, func(i, j int) bool {
+		return output[i].ActiveTime.After(output[j].ActiveTime)
+	})
+	return output
+}
+
+func (e *AlarmEngine) Alarms(client string) []AlarmConfig {
+	e.RLock()
+	defer e.RUnlock()
+
+	output := make([]AlarmConfig, 0)
+	for _, state := range e.states {
+		if state.Client == client {
+			output = append(output, state.Config)
+		}
+	}
+	sort.Slice(output, func(i, j int) bool {
+		return output[i].Name < output[j].Name
+	})
+	return output
+}
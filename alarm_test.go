@@ -0,0 +1,162 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func alarmTestState(alarm AlarmConfig) *AlarmState {
+	return &AlarmState{Client: "plc", Config: alarm, Acked: true}
+}
+
+func TestAlarmDeadband(t *testing.T) {
+	state := alarmTestState(AlarmConfig{Name: "hi", Type: ALARM_HI, Limit: 100, Deadband: 5})
+	now := time.Now()
+
+	steps := []struct {
+		value  float64
+		event  AlarmEventType
+		change bool
+		active bool
+	}{
+		{99, 0, false, false},
+		{101, ALARM_EVENT_ACTIVE, true, true},
+		{97, 0, false, true}, // inside the deadband the alarm keeps active
+		{95, ALARM_EVENT_CLEAR, true, false},
+		{99, 0, false, false},
+	}
+	for i, step := range steps {
+		event, change := state.update(step.value, now.Add(time.Duration(i)*time.Second))
+		if event != step.event || change != step.change || state.Active != step.active {
+			t.Fatalf("step %d value %v: event %v change %v active %v, want %v %v %v",
+				i, step.value, event, change, state.Active, step.event, step.change, step.active)
+		}
+	}
+}
+
+func TestAlarmLowDeadband(t *testing.T) {
+	state := alarmTestState(AlarmConfig{Name: "lo", Type: ALARM_LO, Limit: 10, Deadband: 2})
+	now := time.Now()
+
+	if _, change := state.update(9, now); !change || !state.Active {
+		t.Fatalf("value 9 under the limit 10 is not active")
+	}
+	if _, change := state.update(11, now.Add(time.Second)); change || !state.Active {
+		t.Fatalf("value 11 inside the deadband clears the alarm")
+	}
+	if _, change := state.update(12, now.Add(2*time.Second)); !change || state.Active {
+		t.Fatalf("value 12 at the deadband does not clear the alarm")
+	}
+}
+
+func TestAlarmDelay(t *testing.T) {
+	state := alarmTestState(AlarmConfig{Name: "hi", Type: ALARM_HI, Limit: 100, DelayOn: 1000, DelayOff: 500})
+	now := time.Now()
+
+	if _, change := state.update(150, now); change {
+		t.Fatalf("alarm is active before the on delay")
+	}
+	if _, change := state.update(150, now.Add(999*time.Millisecond)); change {
+		t.Fatalf("alarm is active before the on delay")
+	}
+	event, change := state.update(150, now.Add(time.Second))
+	if !change || event != ALARM_EVENT_ACTIVE || state.Acked {
+		t.Fatalf("alarm is not active after the on delay")
+	}
+	if !state.ActiveTime.Equal(now.Add(time.Second)) {
+		t.Fatalf("active time %v is not the time of the change", state.ActiveTime)
+	}
+
+	// the condition gone within the delay restarts the pending time
+	now = now.Add(10 * time.Second)
+	state.update(50, now)
+	state.update(150, now.Add(400*time.Millisecond))
+	if _, change := state.update(50, now.Add(600*time.Millisecond)); change {
+		t.Fatalf("alarm is cleared by the restarted off delay")
+	}
+	event, change = state.update(50, now.Add(1100*time.Millisecond))
+	if !change || event != ALARM_EVENT_CLEAR || state.Active {
+		t.Fatalf("alarm is not cleared after the off delay")
+	}
+	if state.StateName() != "Cleared Unacked" {
+		t.Fatalf("state name %s, want Cleared Unacked", state.StateName())
+	}
+}
+
+func TestAlarmRateOfChange(t *testing.T) {
+	state := alarmTestState(AlarmConfig{Name: "roc", Type: ALARM_ROC, Limit: 10, Deadband: 2})
+	now := time.Now()
+
+	if _, change := state.update(1000, now); change {
+		t.Fatalf("the first value has no rate")
+	}
+	if _, change := state.update(1005, now.Add(time.Second)); change {
+		t.Fatalf("rate 5/s over the limit 10/s")
+	}
+	if _, change := state.update(1025, now.Add(2*time.Second)); !change || !state.Active {
+		t.Fatalf("rate 20/s is not active")
+	}
+	if _, change := state.update(1025, now.Add(2*time.Second)); change || !state.Active {
+		t.Fatalf("the same timestamp changes the alarm")
+	}
+	if _, change := state.update(1034, now.Add(3*time.Second)); change || !state.Active {
+		t.Fatalf("rate 9/s inside the deadband clears the alarm")
+	}
+	if _, change := state.update(1036, now.Add(4*time.Second)); !change || state.Active {
+		t.Fatalf("rate 2/s does not clear the alarm")
+	}
+}
+
+func TestAlarmState(t *testing.T) {
+	state := alarmTestState(AlarmConfig{Name: "run", Type: ALARM_STATE, Limit: 1})
+	now := time.Now()
+
+	if _, change := state.update(0, now); change {
+		t.Fatalf("state 0 is active")
+	}
+	if event, _ := state.update(1, now); event != ALARM_EVENT_ACTIVE {
+		t.Fatalf("state 1 is not active")
+	}
+}
+
+func TestAlarmEngineEvaluate(t *testing.T) {
+	node := NodeInfo{NsIndex: 2, NodeID: "temp", IdType: NODEID_STRING}
+	other := NodeInfo{NsIndex: 2, NodeID: "speed", IdType: NODEID_STRING}
+	clients := []ClientConfig{
+		{Enable: true, Name: "plc", Alarms: []AlarmConfig{
+			{Enable: true, Name: "hi", Node: node, Type: ALARM_HI, Limit: 100},
+			{Enable: false, Name: "hihi", Node: node, Type: ALARM_HIHI, Limit: 120},
+		}},
+		{Enable: false, Name: "off", Alarms: []AlarmConfig{
+			{Enable: true, Name: "hi", Node: node, Type: ALARM_HI, Limit: 100},
+		}},
+	}
+	engine := NewAlarmEngine(clients)
+	if engine.Count() != 1 {
+		t.Fatalf("engine has %d alarms, want 1", engine.Count())
+	}
+
+	// the nil, the missing and the not numeric values are skipped
+	engine.Evaluate("plc", []NodeInfo{other, node}, []*NodeValue{{Type: UA_DOUBLE, Value: 500.0}, nil})
+	engine.Evaluate("plc", []NodeInfo{node}, []*NodeValue{})
+	engine.Evaluate("plc", []NodeInfo{node}, []*NodeValue{{Type: UA_STRING, Value: "n/a"}})
+	if len(engine.ActiveList()) != 0 {
+		t.Fatalf("alarm is active without a value")
+	}
+
+	engine.Evaluate("plc", []NodeInfo{node}, []*NodeValue{{Type: UA_DOUBLE, Value: 150.0}})
+	active := engine.ActiveList()
+	if len(active) != 1 || active[0].Key() != AlarmKey("plc", "hi") || active[0].Value != 150 {
+		t.Fatalf("active list %+v, want plc.hi at 150", active)
+	}
+
+	engine.AcknowledgeAll()
+	if active = engine.ActiveList(); len(active) != 1 || active[0].StateName() != "Active Acked" {
+		t.Fatalf("active list %+v after the acknowledge", active)
+	}
+
+	engine.Reset(ClientConfig{Enable: false, Name: "plc"})
+	if engine.Count() != 0 {
+		t.Fatalf("disabled client keeps %d alarms", engine.Count())
+	}
+}
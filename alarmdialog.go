@@ -0,0 +1,578 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+type ActiveAlarmItem struct {
+	state AlarmState
+
+	checked bool
+}
+
+type ActiveAlarmTable struct {
+	sync.RWMutex
+
+	walk.TableModelBase
+	walk.SorterBase
+	sortColumn int
+	sortOrder  walk.SortOrder
+
+	items []*ActiveAlarmItem
+}
+
+func (n *ActiveAlarmTable) RowCount() int {
+	return len(n.items)
+}
+
+func (n *ActiveAlarmTable) Value(row, col int) interface{} {
+	item := n.items[row]
+	switch col {
+	case 0:
+		return item.state.ActiveTime.Format("2006-01-02 15:04:05")
+	case 1:
+		return item.state.Client
+	case 2:
+		return item.state.Config.Name
+	case 3:
+		return item.state.Config.Type.String()
+	case 4:
+		return item.state.StateName()
+	case 5:
+		return fmt.Sprintf("%0.3f", item.state.Value)
+	case 6:
+		return item.state.Config.Message
+	}
+	panic("unexpected col")
+}
+
+func (n *ActiveAlarmTable) Checked(row int) bool {
+	return n.items[row].checked
+}
+
+func (n *ActiveAlarmTable) SetChecked(row int, checked bool) error {
+	n.items[row].checked = checked
+	return nil
+}
+
+func (m *ActiveAlarmTable) Sort(col int, order walk.SortOrder) error {
+	m.sortColumn, m.sortOrder = col, order
+	sort.SliceStable(m.items, func(i, j int) bool {
+		a, b := m.items[i], m.items[j]
+		c := func(ls bool) bool {
+			if m.sortOrder == walk.SortAscending {
+				return ls
+			}
+			return !ls
+		}
+		switch m.sortColumn {
+		case 0:
+			return c(a.state.ActiveTime.Before(b.state.ActiveTime))
+		case 1:
+			return c(a.state.Client < b.state.Client)
+		case 2:
+			return c(a.state.Config.Name < b.state.Config.Name)
+		case 3:
+			return c(a.state.Config.Type < b.state.Config.Type)
+		case 4:
+			return c(a.state.StateName() < b.state.StateName())
+		case 5:
+			return c(a.state.Value < b.state.Value)
+		case 6:
+			return c(a.state.Config.Message < b.state.Config.Message)
+		}
+		panic("unreachable")
+	})
+	return m.SorterBase.Sort(col, order)
+}
+
+var activeAlarmTable *ActiveAlarmTable
+
+func init() {
+	activeAlarmTable = new(ActiveAlarmTable)
+	activeAlarmTable.items = make([]*ActiveAlarmItem, 0)
+}
+
+func ActiveAlarmTableUpdate(engine *AlarmEngine) {
+	activeAlarmTable.Lock()
+	defer activeAlarmTable.Unlock()
+
+	checked := make(map[string]bool)
+	for _, item := range activeAlarmTable.items {
+		if item.checked {
+			checked[item.state.Key()] = true
+		}
+	}
+
+	items := make([]*ActiveAlarmItem, 0)
+	if engine != nil {
+		for _, state := range engine.ActiveList() {
+			items = append(items, &ActiveAlarmItem{state: state, checked: checked[state.Key()]})
+		}
+	}
+
+	activeAlarmTable.items = items
+	activeAlarmTable.PublishRowsReset()
+	activeAlarmTable.Sort(activeAlarmTable.sortColumn, activeAlarmTable.sortOrder)
+}
+
+func ActiveAlarmTableSelect() []string {
+	activeAlarmTable.Lock()
+	defer activeAlarmTable.Unlock()
+
+	keys := make([]string, 0)
+	for _, item := range activeAlarmTable.items {
+		if item.checked {
+			keys = append(keys, item.state.Key())
+		}
+	}
+	return keys
+}
+
+type AlarmItem struct {
+	Index int
+
+	alarm   AlarmConfig
+	checked bool
+}
+
+type AlarmTable struct {
+	walk.TableModelBase
+	walk.SorterBase
+	sortColumn int
+	sortOrder  walk.SortOrder
+
+	items []*AlarmItem
+}
+
+func (n *AlarmTable) RowCount() int {
+	return len(n.items)
+}
+
+func (n *AlarmTable) Value(row, col int) interface{} {
+	item := n.items[row]
+	switch col {
+	case 0:
+		return item.Index
+	case 1:
+		return item.alarm.Name
+	case 2:
+		return item.alarm.Node.ToString()
+	case 3:
+		return item.alarm.Type.String()
+	case 4:
+		return fmt.Sprintf("%0.3f", item.alarm.Limit)
+	case 5:
+		return fmt.Sprintf("%0.3f", item.alarm.Deadband)
+	case 6:
+		return fmt.Sprintf("%d/%d ms", item.alarm.DelayOn, item.alarm.DelayOff)
+	case 7:
+		return item.alarm.Severity
+	case 8:
+		return SwitchName(item.alarm.Enable)
+	}
+	panic("unexpected col")
+}
+
+func (n *AlarmTable) Checked(row int) bool {
+	return n.items[row].checked
+}
+
+func (n *AlarmTable) SetChecked(row int, checked bool) error {
+	n.items[row].checked = checked
+	return nil
+}
+
+func (m *AlarmTable) Sort(col int, order walk.SortOrder) error {
+	m.sortColumn, m.sortOrder = col, order
+	sort.SliceStable(m.items, func(i, j int) bool {
+		a, b := m.items[i], m.items[j]
+		c := func(ls bool) bool {
+			if m.sortOrder == walk.SortAscending {
+				return ls
+			}
+			return !ls
+		}
+		switch m.sortColumn {
+		case 0:
+			return c(a.Index < b.Index)
+		case 1:
+			return c(a.alarm.Name < b.alarm.Name)
+		case 2:
+			return c(a.alarm.Node.ToString() < b.alarm.Node.ToString())
+		case 3:
+			return c(a.alarm.Type < b.alarm.Type)
+		case 4:
+			return c(a.alarm.Limit < b.alarm.Limit)
+		case 5:
+			return c(a.alarm.Deadband < b.alarm.Deadband)
+		case 6:
+			return c(a.alarm.DelayOn < b.alarm.DelayOn)
+		case 7:
+			return c(a.alarm.Severity < b.alarm.Severity)
+		case 8:
+			return c(a.alarm.Enable)
+		}
+		panic("unreachable")
+	})
+	return m.SorterBase.Sort(col, order)
+}
+
+func (m *AlarmTable) Init(config ClientConfig) {
+	items := make([]*AlarmItem, 0)
+	for index, alarm := range config.Alarms {
+		items = append(items, &AlarmItem{Index: index, alarm: alarm})
+	}
+	m.items = items
+	m.PublishRowsReset()
+	m.Sort(m.sortColumn, m.sortOrder)
+}
+
+func (m *AlarmTable) Delete(config *ClientConfig) {
+	for _, item := range m.items {
+		if item.checked {
+			config.AlarmDelete(item.alarm.Name)
+		}
+	}
+	m.Init(*config)
+}
+
+func AlarmAddDialog(from walk.Form, config *ClientConfig, node NodeInfo) bool {
+	var dlg *walk.Dialog
+	var nameLine, messageLine *walk.LineEdit
+	var nodeBox, typeBox *walk.ComboBox
+	var limit, deadband, delayOn, delayOff, severity *walk.NumberEdit
+	var enableCB *walk.CheckBox
+	var acceptPB, cancelPB *walk.PushButton
+
+	nodes := make([]string, 0)
+	current := 0
+	for i, item := range config.NodeList {
+		if item.Compare(node) {
+			current = i
+		}
+		nodes = append(nodes, item.ToString())
+	}
+
+	result, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Adding an Alarm Definition",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 500, Height: 300},
+		Size:          Size{Width: 500, Height: 300},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Composite{
+				Layout: Grid{Columns: 4},
+				Children: []Widget{
+					Label{
+						Text: "Alarm Name:",
+					},
+					LineEdit{
+						AssignTo: &nameLine,
+					},
+					Label{
+						Text: "Alarm Type:",
+					},
+					ComboBox{
+						AssignTo:     &typeBox,
+						CurrentIndex: 0,
+						Model:        AlarmTypeNames(),
+					},
+					Label{
+						Text: "Node Tag:",
+					},
+					ComboBox{
+						AssignTo:     &nodeBox,
+						CurrentIndex: current,
+						Model:        nodes,
+						ColumnSpan:   3,
+					},
+					Label{
+						Text: "Limit:",
+					},
+					NumberEdit{
+						AssignTo:    &limit,
+						Decimals:    3,
+						ToolTipText: "HI/HIHI/LO/LOLO: limit value, STATE: alarm value, ROC: units per second",
+						MinValue:    -1e15,
+						MaxValue:    1e15,
+					},
+					Label{
+						Text: "Deadband:",
+					},
+					NumberEdit{
+						AssignTo: &deadband,
+						Decimals: 3,
+						MinValue: 0,
+						MaxValue: 1e15,
+					},
+					Label{
+						Text: "Delay On:",
+					},
+					NumberEdit{
+						AssignTo:    &delayOn,
+						ToolTipText: "0~3600000 ms",
+						MinValue:    0,
+						MaxValue:    3600000,
+					},
+					Label{
+						Text: "Delay Off:",
+					},
+					NumberEdit{
+						AssignTo:    &delayOff,
+						ToolTipText: "0~3600000 ms",
+						MinValue:    0,
+						MaxValue:    3600000,
+					},
+					Label{
+						Text: "Severity:",
+					},
+					NumberEdit{
+						AssignTo:    &severity,
+						Value:       float64(500),
+						ToolTipText: "1~1000",
+						MinValue:    1,
+						MaxValue:    1000,
+					},
+					HSpacer{},
+					CheckBox{
+						AssignTo: &enableCB,
+						Text:     "Enable",
+						Checked:  true,
+					},
+					Label{
+						Text: "Message:",
+					},
+					LineEdit{
+						AssignTo:   &messageLine,
+						ColumnSpan: 3,
+					},
+				},
+			},
+			VSpacer{},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							if nameLine.Text() == "" {
+								ErrorBoxAction(dlg, "The alarm name cannot be empty!")
+								return
+							}
+							index := nodeBox.CurrentIndex()
+							if index < 0 || index >= len(config.NodeList) {
+								ErrorBoxAction(dlg, "Please select a node!")
+								return
+							}
+
+							err := config.AlarmAdd(AlarmConfig{
+								Enable:   enableCB.Checked(),
+								Name:     nameLine.Text(),
+								Node:     config.NodeList[index],
+								Type:     AlarmType(typeBox.CurrentIndex()),
+								Limit:    limit.Value(),
+								Deadband: deadband.Value(),
+								DelayOn:  int(delayOn.Value()),
+								DelayOff: int(delayOff.Value()),
+								Severity: int(severity.Value()),
+								Message:  messageLine.Text(),
+							})
+							if err != nil {
+								ErrorBoxAction(dlg, "Add alarm failed: "+err.Error())
+								return
+							}
+							dlg.Accept()
+							logs.Info("alarm add dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("alarm add dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("AlarmAddDialog: %s", err.Error())
+	}
+
+	return result == walk.DlgCmdOK
+}
+
+func AlarmEditDialog(from walk.Form, config *ClientConfig, node NodeInfo) {
+	var dlg *walk.Dialog
+	var alarmTableView *walk.TableView
+	var alarmTable AlarmTable
+
+	alarmTable.Init(*config)
+
+	_, err := Dialog{
+		AssignTo: &dlg,
+		Title:    "Alarm Configuration: " + config.Name,
+		Icon:     walk.IconInformation(),
+		MinSize:  Size{Width: 900, Height: 400},
+		Size:     Size{Width: 900, Height: 400},
+		Font:     DefaultFont(),
+		Layout:   VBox{},
+		Children: []Widget{
+			Label{
+				Text: "Alarm List:",
+			},
+			TableView{
+				AssignTo:         &alarmTableView,
+				AlternatingRowBG: true,
+				ColumnsOrderable: true,
+				CheckBoxes:       true,
+				Columns: []TableViewColumn{
+					{Title: "#", Width: 40},
+					{Title: "Alarm Name", Width: 120},
+					{Title: "Node Tag", Width: 200},
+					{Title: "Type", Width: 60},
+					{Title: "Limit", Width: 80},
+					{Title: "Deadband", Width: 80},
+					{Title: "Delay On/Off", Width: 100},
+					{Title: "Severity", Width: 60},
+					{Title: "Enable", Width: 60},
+				},
+				StyleCell: func(style *walk.CellStyle) {
+					if style.Row()%2 == 0 {
+						style.BackgroundColor = walk.RGB(248, 248, 255)
+					} else {
+						style.BackgroundColor = walk.RGB(220, 220, 220)
+					}
+				},
+				Model: &alarmTable,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					PushButton{
+						Text: "Add",
+						OnClicked: func() {
+							if len(config.NodeList) == 0 {
+								ErrorBoxAction(dlg, "No nodes configured for this client")
+								return
+							}
+							if AlarmAddDialog(dlg, config, node) {
+								alarmTable.Init(*config)
+							}
+						},
+					},
+					PushButton{
+						Text: "Delete",
+						OnClicked: func() {
+							alarmTable.Delete(config)
+						},
+					},
+					HSpacer{},
+					PushButton{
+						Text: "Close",
+						OnClicked: func() {
+							dlg.Accept()
+							logs.Info("alarm edit dialog close")
+						},
+					},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("AlarmEditDialog: %s", err.Error())
+	}
+}
+
+func AlarmSinkDialog(from walk.Form, config *Config) {
+	var dlg *walk.Dialog
+	var databaseCB, serverCB, logfileCB *walk.CheckBox
+	var acceptPB, cancelPB *walk.PushButton
+
+	sinkConfig := config.Alarm
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Alarm Publish Settings",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 300, Height: 150},
+		Size:          Size{Width: 300, Height: 150},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			CheckBox{
+				AssignTo: &databaseCB,
+				Text:     "Write alarm events to MYSQL alarm table",
+				Checked:  sinkConfig.Database,
+				OnCheckedChanged: func() {
+					sinkConfig.Database = databaseCB.Checked()
+				},
+			},
+			CheckBox{
+				AssignTo: &serverCB,
+				Text:     "Publish alarm state on OPCUA server",
+				Checked:  sinkConfig.Server,
+				OnCheckedChanged: func() {
+					sinkConfig.Server = serverCB.Checked()
+				},
+			},
+			CheckBox{
+				AssignTo: &logfileCB,
+				Text:     "Write alarm events to alarm log file",
+				Checked:  sinkConfig.Logfile,
+				OnCheckedChanged: func() {
+					sinkConfig.Logfile = logfileCB.Checked()
+				},
+			},
+			VSpacer{},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							config.UpdateAlarm(sinkConfig)
+							dlg.Accept()
+							logs.Info("alarm sink dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("alarm sink dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("AlarmSinkDialog: %s", err.Error())
+	}
+}
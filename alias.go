@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the storage column name is truncated above the length, see ColumnName
+const ALIAS_MAX_LENGTH = 59
+
+// the columns created by the datastore tables
+var aliasReserved = []string{"id", "timestamp", BACKFILL_COLUMN}
+
+// AliasCheck checks the alias is a legal storage column and proxy browse name, the empty alias is valid
+func AliasCheck(alias string) error {
+	if alias == "" {
+		return nil
+	}
+	if len(alias) > ALIAS_MAX_LENGTH {
+		return fmt.Errorf("alias %s is longer than %d characters of the storage column", alias, ALIAS_MAX_LENGTH)
+	}
+	for i, c := range alias {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return fmt.Errorf("alias %s has the illegal character '%c', use letters, digits and '_' and begin with a letter", alias, c)
+		}
+	}
+	for _, name := range aliasReserved {
+		if strings.EqualFold(alias, name) {
+			return fmt.Errorf("alias %s is reserved by the storage table", alias)
+		}
+	}
+	return nil
+}
+
+// AliasConflict returns the node of the list with the same name as the node,
+// the storage columns are case insensitive
+func AliasConflict(nodes []NodeInfo, node NodeInfo) (NodeInfo, bool) {
+	name := ColumnName(node.Name())
+	for _, item := range nodes {
+		if item.Compare(node) {
+			continue
+		}
+		if strings.EqualFold(ColumnName(item.Name()), name) {
+			return item, true
+		}
+	}
+	return NodeInfo{}, false
+}
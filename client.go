@@ -150,8 +150,8 @@ func ClientAddDialog(from walk.Form, config *Config) {
 	var dlg *walk.Dialog
 	var nameLine, addressLine *walk.LineEdit
 	var enableCB, storeCB *walk.CheckBox
-	var acceptPB, cancelPB, testPB *walk.PushButton
-	var number *walk.NumberEdit
+	var acceptPB, cancelPB, testPB, discoveryPB *walk.PushButton
+	var number, backfill *walk.NumberEdit
 
 	_, err := Dialog{
 		AssignTo:      &dlg,
@@ -189,6 +189,16 @@ func ClientAddDialog(from walk.Form, config *Config) {
 						MaxValue:    10000,
 						MinValue:    100,
 					},
+					Label{
+						Text: "Backfill Limit (hours):",
+					},
+					NumberEdit{
+						AssignTo:    &backfill,
+						Value:       float64(0),
+						ToolTipText: fmt.Sprintf("0~%d hours, 0 disables backfill from the source history", BACKFILL_MAX_HOURS),
+						MaxValue:    BACKFILL_MAX_HOURS,
+						MinValue:    0,
+					},
 					HSpacer{},
 					Composite{
 						Layout: HBox{},
@@ -203,6 +213,20 @@ func ClientAddDialog(from walk.Form, config *Config) {
 								AssignTo: &storeCB,
 								Checked:  true,
 							},
+							PushButton{
+								AssignTo: &discoveryPB,
+								Text:     "Discovery",
+								OnClicked: func() {
+									result, ok := DiscoveryDialog(dlg)
+									if !ok {
+										return
+									}
+									addressLine.SetText(result.Endpoint.Url)
+									if nameLine.Text() == "" && result.Name != "" {
+										nameLine.SetText(EscapeString(result.Name))
+									}
+								},
+							},
 							PushButton{
 								AssignTo: &testPB,
 								Text:     "Connectivity Test",
@@ -244,7 +268,8 @@ func ClientAddDialog(from walk.Form, config *Config) {
 								Endpoint: addressLine.Text(),
 								Timeout:  int(number.Value()),
 								Enable:   enableCB.Checked(),
-								Store:    storeCB.Checked()})
+								Store:    storeCB.Checked(),
+								Backfill: int(backfill.Value())})
 
 							if err != nil {
 								ErrorBoxAction(dlg, "Add client failed: "+err.Error())
@@ -28,22 +28,53 @@ type ApplicationConfig struct {
 }
 
 type DataStoreConfig struct {
-	Enable   bool   `json:"enable"`
-	Address  string `json:"address"`
-	Port     int    `json:"port"`
-	UserName string `json:"username"`
-	PassWord string `json:"password"`
-	DataBase string `json:"database"`
-	Expired  int    `json:"expired"`
+	Enable   bool        `json:"enable"`
+	Address  string      `json:"address"`
+	Port     int         `json:"port"`
+	UserName string      `json:"username"`
+	PassWord string      `json:"password"`
+	DataBase string      `json:"database"`
+	Expired  int         `json:"expired"`
+	Queue    QueueConfig `json:"queue"`
 }
 
 type ClientConfig struct {
-	Enable   bool       `json:"enable"`
-	Timeout  int        `json:"timeout"`
-	Name     string     `json:"name"`
-	Endpoint string     `json:"endpoint"`
-	Store    bool       `json:"store"`
-	NodeList []NodeInfo `json:"nodes"`
+	Enable   bool          `json:"enable"`
+	Timeout  int           `json:"timeout"`
+	Name     string        `json:"name"`
+	Endpoint string        `json:"endpoint"`
+	Store    bool          `json:"store"`
+	Backfill int           `json:"backfill"`
+	Pipeline int           `json:"pipeline"`
+	NodeList []NodeInfo    `json:"nodes"`
+	Alarms   []AlarmConfig `json:"alarms"`
+	Events   EventConfig   `json:"events"`
+}
+
+type EventConfig struct {
+	Enable   bool     `json:"enable"`
+	Interval int      `json:"interval"`
+	Select   []string `json:"select"`
+	Where    string   `json:"where"`
+}
+
+type AlarmConfig struct {
+	Enable   bool      `json:"enable"`
+	Name     string    `json:"name"`
+	Node     NodeInfo  `json:"node"`
+	Type     AlarmType `json:"type"`
+	Limit    float64   `json:"limit"`
+	Deadband float64   `json:"deadband"`
+	DelayOn  int       `json:"delayOn"`
+	DelayOff int       `json:"delayOff"`
+	Severity int       `json:"severity"`
+	Message  string    `json:"message"`
+}
+
+type AlarmSinkConfig struct {
+	Database bool `json:"database"`
+	Server   bool `json:"server"`
+	Logfile  bool `json:"logfile"`
 }
 
 type ServerNodeInfo struct {
@@ -52,14 +83,70 @@ type ServerNodeInfo struct {
 	ClientNode     NodeInfo `json:"clientNode"`
 	ServerName     string   `json:"serverName"`
 	ServerNode     NodeInfo `json:"serverNode"`
+	MetaOverride   bool     `json:"metaOverride"`
+	Meta           NodeMeta `json:"meta"`
 }
 
 type ServerConfig struct {
-	Enable   bool             `json:"enable"`
-	Name     string           `json:"name"`
-	Endpoint string           `json:"endpoint"`
-	Port     int              `json:"port"`
-	NodeList []ServerNodeInfo `json:"nodes"`
+	Enable    bool               `json:"enable"`
+	Name      string             `json:"name"`
+	Endpoint  string             `json:"endpoint"`
+	Port      int                `json:"port"`
+	NodeList  []ServerNodeInfo   `json:"nodes"`
+	Hierarchy HierarchyConfig    `json:"hierarchy"`
+	Security  SecurityConfig     `json:"security"`
+	Roles     []RoleConfig       `json:"roles"`
+	History   bool               `json:"history"`
+	Methods   []ServerMethodInfo `json:"methods"`
+	Queue     QueueConfig        `json:"queue"`
+}
+
+type ServerMethodInfo struct {
+	ClientName string   `json:"clientName"`
+	ObjectNode NodeInfo `json:"objectNode"`
+	MethodNode NodeInfo `json:"methodNode"`
+	ServerName string   `json:"serverName"`
+}
+
+type RoleRule struct {
+	Target string `json:"target"`
+	AccessPermission
+}
+
+type RoleConfig struct {
+	Name    string           `json:"name"`
+	Default AccessPermission `json:"default"`
+	Rules   []RoleRule       `json:"rules"`
+}
+
+type ServerUser struct {
+	UserName string `json:"username"`
+	PassWord string `json:"password"`
+	Role     string `json:"role"`
+}
+
+type SecurityConfig struct {
+	Policies         []string     `json:"policies"`
+	Sign             bool         `json:"sign"`
+	SignAndEncrypt   bool         `json:"signAndEncrypt"`
+	Certificate      string       `json:"certificate"`
+	PrivateKey       string       `json:"privateKey"`
+	TrustList        []string     `json:"trustList"`
+	DisableAnonymous bool         `json:"disableAnonymous"`
+	AnonymousRole    string       `json:"anonymousRole"`
+	DefaultRole      string       `json:"defaultRole"`
+	Users            []ServerUser `json:"users"`
+}
+
+type NameMapping struct {
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
+}
+
+type HierarchyConfig struct {
+	Enable    bool          `json:"enable"`
+	Collision CollisionMode `json:"collision"`
+	Mapping   []NameMapping `json:"mapping"`
 }
 
 type Config struct {
@@ -68,6 +155,7 @@ type Config struct {
 	Clients   []ClientConfig  `json:"clients"`
 	Server    ServerConfig    `json:"server"`
 	Datastore DataStoreConfig `json:"datastore"`
+	Alarm     AlarmSinkConfig `json:"alarm"`
 }
 
 var defaultApplicationConfig = ApplicationConfig{
@@ -95,6 +183,7 @@ var defaultConfig = Config{
 		Address: "localhost", Port: 3306,
 		UserName: "root", PassWord: "root",
 		DataBase: "opcua", Expired: 30},
+	Alarm: AlarmSinkConfig{Database: true, Server: true, Logfile: true},
 }
 
 func (c *Config) statusUpdate() {
@@ -132,6 +221,11 @@ func (c *Config) UpdateServer(server ServerConfig) {
 	c.Server = server
 }
 
+func (c *Config) UpdateAlarm(alarm AlarmSinkConfig) {
+	defer c.statusUpdate()
+	c.Alarm = alarm
+}
+
 func (c *Config) UpdateClient(clients []ClientConfig) {
 	defer c.statusUpdate()
 	c.Clients = clients
@@ -154,6 +248,9 @@ func (c *Config) Update(client ClientConfig) error {
 
 	for i, item := range c.Clients {
 		if item.Name == client.Name {
+			for _, node := range client.NodeList {
+				c.Server.Rename(client.Name, item.Settings(node), node)
+			}
 			c.Clients[i] = client
 			return nil
 		}
@@ -172,10 +269,46 @@ func (c *Config) Delete(name string) {
 	}
 }
 
+func (c Config) String() string {
+	value, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return string(value)
+}
+
+// Clone copies the config with its lists, the file path is kept
+func (c Config) Clone() Config {
+	var clone Config
+	value, err := json.Marshal(c)
+	if err == nil {
+		err = json.Unmarshal(value, &clone)
+	}
+	if err != nil {
+		logs.Error("clone config data fail, %s", err.Error())
+		return c
+	}
+	clone.Filepath = c.Filepath
+	return clone
+}
+
 func (c *Config) Save() error {
+	return c.SaveComment("")
+}
+
+// SaveComment saves the config and keeps the snapshot of the version with the comment
+func (c *Config) SaveComment(comment string) error {
 	defer c.statusUpdate()
 
-	value, err := json.Marshal(*c)
+	// the live config keeps the plain secrets, the copy of the sealed ones is saved
+	sealed := c.Clone()
+	err := sealed.SecretSeal()
+	if err != nil {
+		logs.Error("seal config secrets fail, %s", err.Error())
+		return err
+	}
+
+	value, err := json.Marshal(sealed)
 	if err != nil {
 		logs.Error("covert config data to json fail, %s", err.Error())
 		return err
@@ -185,8 +318,12 @@ func (c *Config) Save() error {
 		logs.Error("write config data to file fail, %s", err.Error())
 		return err
 	}
-	logs.Info("config save %s", string(value))
+	logs.Info("config save %s", c.Redacted().String())
 
+	err = ConfigHistoryAdd(sealed, comment)
+	if err != nil {
+		logs.Warning("config history snapshot fail, %s", err.Error())
+	}
 	return nil
 }
 
@@ -220,7 +357,7 @@ func (c *Config) ClientNames(filters ...string) []string {
 }
 
 func (c *ServerConfig) Add(name string, endpoint string, node NodeInfo) bool {
-	serverName := fmt.Sprintf("%s.%s", name, node.NodeID)
+	serverName := fmt.Sprintf("%s.%s", name, node.Name())
 	for _, node := range c.NodeList {
 		if node.ServerName == serverName {
 			return false
@@ -236,6 +373,50 @@ func (c *ServerConfig) Add(name string, endpoint string, node NodeInfo) bool {
 	return true
 }
 
+// Import replaces the mapping with the same server name and appends the others
+func (c *ServerConfig) Import(nodes []ServerNodeInfo) {
+	for _, node := range nodes {
+		exist := false
+		for i, item := range c.NodeList {
+			if item.ServerName == node.ServerName {
+				c.NodeList[i] = node
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			c.NodeList = append(c.NodeList, node)
+		}
+	}
+}
+
+// Rename follows the alias change of the client node, the server names given by the user are kept
+func (c *ServerConfig) Rename(name string, old NodeInfo, node NodeInfo) {
+	oldName := fmt.Sprintf("%s.%s", name, old.Name())
+	serverName := fmt.Sprintf("%s.%s", name, node.Name())
+	for _, item := range c.NodeList {
+		if item.ServerName == serverName {
+			serverName = oldName
+			break
+		}
+	}
+
+	for i, item := range c.NodeList {
+		if item.ClientName != name || !item.ClientNode.Compare(node) {
+			continue
+		}
+		c.NodeList[i].ClientNode = node
+		if oldName == serverName || item.ServerName != oldName {
+			continue
+		}
+		c.NodeList[i].ServerName = serverName
+		if item.ServerNode.NodeID == oldName {
+			c.NodeList[i].ServerNode.NodeID = serverName
+		}
+		logs.Info("server node %s rename to %s", oldName, serverName)
+	}
+}
+
 func (c *ServerConfig) Update(serverName string, node NodeInfo) bool {
 	for i, item := range c.NodeList {
 		if item.ServerName == serverName {
@@ -246,6 +427,17 @@ func (c *ServerConfig) Update(serverName string, node NodeInfo) bool {
 	return false
 }
 
+func (c *ServerConfig) UpdateMeta(serverName string, override bool, meta NodeMeta) bool {
+	for i, item := range c.NodeList {
+		if item.ServerName == serverName {
+			c.NodeList[i].MetaOverride = override
+			c.NodeList[i].Meta = meta
+			return true
+		}
+	}
+	return false
+}
+
 func (c *ServerConfig) Delete(serverName string) bool {
 	for i, node := range c.NodeList {
 		if node.ServerName == serverName {
@@ -261,10 +453,40 @@ func (c *ServerConfig) Clean() {
 	logs.Info("server config node list clean")
 }
 
+// Settings returns the node with the settings of the same node in the node list
+func (c ClientConfig) Settings(node NodeInfo) NodeInfo {
+	for _, item := range c.NodeList {
+		if item.Compare(node) {
+			return item
+		}
+	}
+	return node
+}
+
 func (c *ClientConfig) Reset(nodes []NodeInfo) {
 	c.NodeList = nodes
 }
 
+func (c *ClientConfig) AlarmAdd(alarm AlarmConfig) error {
+	for _, item := range c.Alarms {
+		if item.Name == alarm.Name {
+			return fmt.Errorf("alarm name %s already exist", alarm.Name)
+		}
+	}
+	c.Alarms = append(c.Alarms, alarm)
+	return nil
+}
+
+func (c *ClientConfig) AlarmDelete(name string) bool {
+	for i, item := range c.Alarms {
+		if item.Name == name {
+			c.Alarms = append(c.Alarms[:i], c.Alarms[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
 func ConfigCreate(filepath string) (*Config, error) {
 	config := defaultConfig
 	config.Filepath = filepath
@@ -283,8 +505,6 @@ func ConfigLoad(filepath string) (*Config, error) {
 		logs.Error("read config file from app data dir fail, %s", err.Error())
 		return nil, err
 	}
-	logs.Info("config load %s value %s", filepath, string(value))
-
 	var config Config
 	err = json.Unmarshal(value, &config)
 	if err != nil {
@@ -292,6 +512,8 @@ func ConfigLoad(filepath string) (*Config, error) {
 		return nil, err
 	}
 	config.Filepath = filepath
+	logs.Info("config load %s value %s", filepath, config.Redacted().String())
+	ConfigValidate(config, false).Log()
 	config.statusUpdate()
 	return &config, nil
 }
@@ -0,0 +1,695 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+// the oldest snapshots above the count are removed
+const CONFIG_HISTORY_MAX = 200
+
+// the kinds of the config changes
+const (
+	CHANGE_ADDED   = "added"
+	CHANGE_REMOVED = "removed"
+	CHANGE_CHANGED = "changed"
+)
+
+// ConfigVersion is the snapshot of the config file at one save, the secrets are sealed
+type ConfigVersion struct {
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	User      string    `json:"user"`
+	Comment   string    `json:"comment"`
+	Config    Config    `json:"config"`
+}
+
+func (v ConfigVersion) String() string {
+	text := fmt.Sprintf("version %d, %s, %s", v.Version, v.Timestamp.Format(time.DateTime), v.User)
+	if v.Comment != "" {
+		text += ", " + v.Comment
+	}
+	return text
+}
+
+// configHistoryDir returns the snapshot directory beside the config file
+func configHistoryDir(path string) string {
+	return path + ".history"
+}
+
+func configHistoryFile(path string, version int) string {
+	return filepath.Join(configHistoryDir(path), fmt.Sprintf("%06d.json", version))
+}
+
+func configHistoryUser() string {
+	user := os.Getenv("USERNAME")
+	if host, err := os.Hostname(); err == nil {
+		user += "@" + host
+	}
+	return user
+}
+
+// ConfigHistoryList returns the snapshots of the config file by the version
+func ConfigHistoryList(path string) ([]ConfigVersion, error) {
+	versions := make([]ConfigVersion, 0)
+
+	files, err := os.ReadDir(configHistoryDir(path))
+	if os.IsNotExist(err) {
+		return versions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".json")
+		version, err := strconv.Atoi(name)
+		if file.IsDir() || err != nil {
+			continue
+		}
+		item, err := ConfigHistoryGet(path, version)
+		if err != nil {
+			logs.Warning("config history %s skipped, %s", file.Name(), err.Error())
+			continue
+		}
+		versions = append(versions, item)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version < versions[j].Version
+	})
+	return versions, nil
+}
+
+func ConfigHistoryGet(path string, version int) (ConfigVersion, error) {
+	var item ConfigVersion
+
+	body, err := os.ReadFile(configHistoryFile(path, version))
+	if err != nil {
+		return item, fmt.Errorf("config version %d not found, %s", version, err.Error())
+	}
+	err = json.Unmarshal(body, &item)
+	if err != nil {
+		return item, fmt.Errorf("config version %d is broken, %s", version, err.Error())
+	}
+	item.Config.Filepath = path
+	return item, nil
+}
+
+// ConfigHistoryAdd keeps the snapshot of the saved config, the save without changes is not recorded
+func ConfigHistoryAdd(config Config, comment string) error {
+	versions, err := ConfigHistoryList(config.Filepath)
+	if err != nil {
+		return err
+	}
+
+	version := 1
+	if len(versions) > 0 {
+		last := versions[len(versions)-1]
+		if reloadEqual(last.Config, config) && comment == "" {
+			return nil
+		}
+		version = last.Version + 1
+	}
+
+	err = os.MkdirAll(configHistoryDir(config.Filepath), 0755)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(ConfigVersion{
+		Version:   version,
+		Timestamp: time.Now(),
+		User:      configHistoryUser(),
+		Comment:   comment,
+		Config:    config,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(configHistoryFile(config.Filepath, version), body, 0600)
+	if err != nil {
+		return err
+	}
+	logs.Info("config %s history version %d saved", config.Filepath, version)
+
+	for i := 0; i < len(versions)+1-CONFIG_HISTORY_MAX; i++ {
+		os.Remove(configHistoryFile(config.Filepath, versions[i].Version))
+	}
+	return nil
+}
+
+// ConfigRollback saves the config of the version as the new version of the config file
+func ConfigRollback(path string, version int) (*Config, error) {
+	item, err := ConfigHistoryGet(path, version)
+	if err != nil {
+		return nil, err
+	}
+	config := item.Config
+	err = config.SaveComment(fmt.Sprintf("rollback to version %d", version))
+	if err != nil {
+		return nil, err
+	}
+	logs.Info("config %s rollback to version %d", path, version)
+	return &config, nil
+}
+
+// ConfigChange is one difference of two configs, the section is clients, nodes, server or datastore
+type ConfigChange struct {
+	Section string
+	Path    string
+	Kind    string
+	Detail  string
+}
+
+func (c ConfigChange) String() string {
+	if c.Detail == "" {
+		return fmt.Sprintf("[%s] %s", c.Kind, c.Path)
+	}
+	return fmt.Sprintf("[%s] %s: %s", c.Kind, c.Path, c.Detail)
+}
+
+func diffValue(body json.RawMessage) string {
+	text := string(body)
+	if len(text) > 60 {
+		text = text[:57] + "..."
+	}
+	return text
+}
+
+// diffFields returns the changed json fields of the two values, the secret values are not shown
+func diffFields(a, b interface{}, skip ...string) []string {
+	var x, y map[string]json.RawMessage
+	body, _ := json.Marshal(a)
+	json.Unmarshal(body, &x)
+	body, _ = json.Marshal(b)
+	json.Unmarshal(body, &y)
+
+	keys := make([]string, 0)
+	for key := range x {
+		keys = append(keys, key)
+	}
+	for key := range y {
+		if _, ok := x[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, 0)
+	for _, key := range keys {
+		if diffSkip(skip, key) || string(x[key]) == string(y[key]) {
+			continue
+		}
+		if strings.EqualFold(key, "password") {
+			fields = append(fields, key+" changed")
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%s %s -> %s", key, diffValue(x[key]), diffValue(y[key])))
+	}
+	return fields
+}
+
+func diffSkip(skip []string, key string) bool {
+	for _, item := range skip {
+		if item == key {
+			return true
+		}
+	}
+	return false
+}
+
+type diffItem struct {
+	path  string
+	value interface{}
+}
+
+// diffList compares the items of the two lists by the key in the order of the lists
+func diffList(section string, old, cur []diffItem, detail func(item diffItem) string) []ConfigChange {
+	changes := make([]ConfigChange, 0)
+
+	items := make(map[string]diffItem)
+	for _, item := range cur {
+		items[item.path] = item
+	}
+	olds := make(map[string]bool)
+	for _, item := range old {
+		olds[item.path] = true
+		next, ok := items[item.path]
+		if !ok {
+			changes = append(changes, ConfigChange{Section: section, Path: item.path, Kind: CHANGE_REMOVED})
+			continue
+		}
+		if fields := diffFields(item.value, next.value, "nodes"); len(fields) > 0 {
+			changes = append(changes, ConfigChange{Section: section, Path: item.path, Kind: CHANGE_CHANGED,
+				Detail: strings.Join(fields, "; ")})
+		}
+	}
+	for _, item := range cur {
+		if !olds[item.path] {
+			changes = append(changes, ConfigChange{Section: section, Path: item.path, Kind: CHANGE_ADDED,
+				Detail: detail(item)})
+		}
+	}
+	return changes
+}
+
+func diffNone(item diffItem) string {
+	return ""
+}
+
+func diffClients(config Config) []diffItem {
+	items := make([]diffItem, 0)
+	for _, client := range config.Clients {
+		items = append(items, diffItem{path: fmt.Sprintf("clients[%s]", client.Name), value: client})
+	}
+	return items
+}
+
+func diffNodes(config Config) []diffItem {
+	items := make([]diffItem, 0)
+	for _, client := range config.Clients {
+		for _, node := range client.NodeList {
+			items = append(items, diffItem{path: fmt.Sprintf("clients[%s].nodes[%s]", client.Name, node.Key()), value: node})
+		}
+	}
+	return items
+}
+
+func diffServerNodes(config Config) []diffItem {
+	items := make([]diffItem, 0)
+	for _, node := range config.Server.NodeList {
+		items = append(items, diffItem{
+			path:  fmt.Sprintf("server.nodes[%s]", historyKey(node.ClientName, node.ClientNode.Key())),
+			value: node,
+		})
+	}
+	for _, method := range config.Server.Methods {
+		items = append(items, diffItem{
+			path:  fmt.Sprintf("server.methods[%s]", historyKey(method.ClientName, method.MethodNode.Key())),
+			value: method,
+		})
+	}
+	return items
+}
+
+// ConfigDiff returns the changes from the old config to the config by clients, nodes, server mapping and datastore
+func ConfigDiff(old, config Config) []ConfigChange {
+	changes := make([]ConfigChange, 0)
+
+	changes = append(changes, diffList("clients", diffClients(old), diffClients(config), func(item diffItem) string {
+		client := item.value.(ClientConfig)
+		return fmt.Sprintf("endpoint %s, %d nodes", client.Endpoint, len(client.NodeList))
+	})...)
+	changes = append(changes, diffList("nodes", diffNodes(old), diffNodes(config), diffNone)...)
+
+	if fields := diffFields(old.Server, config.Server, "nodes", "methods"); len(fields) > 0 {
+		changes = append(changes, ConfigChange{Section: "server", Path: "server", Kind: CHANGE_CHANGED,
+			Detail: strings.Join(fields, "; ")})
+	}
+	changes = append(changes, diffList("server", diffServerNodes(old), diffServerNodes(config), func(item diffItem) string {
+		switch value := item.value.(type) {
+		case ServerNodeInfo:
+			return "server name " + value.ServerName
+		case ServerMethodInfo:
+			return "server name " + value.ServerName
+		}
+		return ""
+	})...)
+
+	if fields := diffFields(old.Datastore, config.Datastore); len(fields) > 0 {
+		changes = append(changes, ConfigChange{Section: "datastore", Path: "datastore", Kind: CHANGE_CHANGED,
+			Detail: strings.Join(fields, "; ")})
+	}
+	return changes
+}
+
+// configHistoryPair returns the configs of the two versions, the version 0 is the config file
+func configHistoryPair(path string, from, to int) (Config, Config, error) {
+	configs := make([]Config, 2)
+	for i, version := range []int{from, to} {
+		if version == 0 {
+			config, err := configFileRead(path)
+			if err != nil {
+				return Config{}, Config{}, err
+			}
+			configs[i] = *config
+			continue
+		}
+		item, err := ConfigHistoryGet(path, version)
+		if err != nil {
+			return Config{}, Config{}, err
+		}
+		configs[i] = item.Config
+	}
+	return configs[0], configs[1], nil
+}
+
+// configFileRead reads the config file without the side effects of ConfigLoad
+func configFileRead(path string) (*Config, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	err = json.Unmarshal(body, &config)
+	if err != nil {
+		return nil, fmt.Errorf("config %s is not valid json, %s", path, err.Error())
+	}
+	config.Filepath = path
+	return &config, nil
+}
+
+// HistoryCommand is the headless config history command:
+// history <config.json>, history -diff <from> [-to <to>] <config.json>, history -rollback <version> <config.json>,
+// the version 0 is the current config file
+func HistoryCommand(args []string) int {
+	consoleAttach()
+
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	from := flags.Int("diff", -1, "show the changes from the version")
+	to := flags.Int("to", 0, "the version compared by -diff, 0 is the config file")
+	rollback := flags.Int("rollback", 0, "save the version as the config file")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: history [-diff <version> [-to <version>] | -rollback <version>] <config.json>")
+		return 2
+	}
+	path := flags.Arg(0)
+
+	switch {
+	case *rollback > 0:
+		_, err := ConfigRollback(path, *rollback)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		fmt.Printf("%s: rollback to version %d\n", path, *rollback)
+
+	case *from >= 0:
+		old, config, err := configHistoryPair(path, *from, *to)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		changes := ConfigDiff(old, config)
+		for _, change := range changes {
+			fmt.Println(change.String())
+		}
+		fmt.Printf("%s: %d changes\n", path, len(changes))
+
+	default:
+		versions, err := ConfigHistoryList(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		for _, version := range versions {
+			fmt.Println(version.String())
+		}
+	}
+	return 0
+}
+
+type ConfigVersionItem struct {
+	version ConfigVersion
+	checked bool
+}
+
+type ConfigVersionTable struct {
+	walk.TableModelBase
+
+	items []*ConfigVersionItem
+}
+
+func (n *ConfigVersionTable) RowCount() int {
+	return len(n.items)
+}
+
+func (n *ConfigVersionTable) Value(row, col int) interface{} {
+	item := n.items[row]
+	switch col {
+	case 0:
+		return item.version.Version
+	case 1:
+		return item.version.Timestamp.Format(time.DateTime)
+	case 2:
+		return item.version.User
+	case 3:
+		return item.version.Comment
+	}
+	panic("unexpected col")
+}
+
+func (n *ConfigVersionTable) Checked(row int) bool {
+	return n.items[row].checked
+}
+
+func (n *ConfigVersionTable) SetChecked(row int, checked bool) error {
+	n.items[row].checked = checked
+	return nil
+}
+
+// Init lists the latest version first
+func (n *ConfigVersionTable) Init(path string) error {
+	versions, err := ConfigHistoryList(path)
+	if err != nil {
+		return err
+	}
+	items := make([]*ConfigVersionItem, 0)
+	for i := len(versions) - 1; i >= 0; i-- {
+		items = append(items, &ConfigVersionItem{version: versions[i]})
+	}
+	n.items = items
+	n.PublishRowsReset()
+	return nil
+}
+
+// CheckedVersions returns the checked versions, the older version first
+func (n *ConfigVersionTable) CheckedVersions() []ConfigVersion {
+	versions := make([]ConfigVersion, 0)
+	for i := len(n.items) - 1; i >= 0; i-- {
+		if n.items[i].checked {
+			versions = append(versions, n.items[i].version)
+		}
+	}
+	return versions
+}
+
+func ConfigDiffDialog(from walk.Form, title string, changes []ConfigChange) {
+	var dlg *walk.Dialog
+	var acceptPB *walk.PushButton
+
+	lines := make([]string, 0)
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "no changes")
+	}
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         title,
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 700, Height: 400},
+		Size:          Size{Width: 700, Height: 400},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &acceptPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Label{
+				Text: fmt.Sprintf("%d changes", len(changes)),
+			},
+			ListBox{
+				Model: lines,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "OK",
+						OnClicked: func() {
+							dlg.Accept()
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("ConfigDiffDialog: %s", err.Error())
+	}
+}
+
+// ConfigHistoryDialog lists the versions of the config file, it returns the config of the rollback
+func ConfigHistoryDialog(from walk.Form, config *Config) *Config {
+	var dlg *walk.Dialog
+	var acceptPB *walk.PushButton
+	var rollback *Config
+
+	table := new(ConfigVersionTable)
+	if err := table.Init(config.Filepath); err != nil {
+		ErrorBoxAction(from, err.Error())
+		return nil
+	}
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Configuration History",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 700, Height: 400},
+		Size:          Size{Width: 700, Height: 400},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &acceptPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Label{
+				Text: "Check one version to compare with the current configuration, or two versions to compare them",
+			},
+			TableView{
+				AlternatingRowBG: true,
+				CheckBoxes:       true,
+				Columns: []TableViewColumn{
+					{Title: "Version", Width: 60},
+					{Title: "Time", Width: 140},
+					{Title: "User", Width: 140},
+					{Title: "Comment", Width: 300},
+				},
+				Model: table,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					PushButton{
+						Text: "Diff",
+						OnClicked: func() {
+							versions := table.CheckedVersions()
+							switch len(versions) {
+							case 1:
+								ConfigDiffDialog(dlg, fmt.Sprintf("Changes from version %d to the current configuration", versions[0].Version),
+									ConfigDiff(versions[0].Config, *config))
+							case 2:
+								ConfigDiffDialog(dlg, fmt.Sprintf("Changes from version %d to version %d", versions[0].Version, versions[1].Version),
+									ConfigDiff(versions[0].Config, versions[1].Config))
+							default:
+								ErrorBoxAction(dlg, "Please check one or two versions")
+							}
+						},
+					},
+					PushButton{
+						Text: "Rollback",
+						OnClicked: func() {
+							versions := table.CheckedVersions()
+							if len(versions) != 1 {
+								ErrorBoxAction(dlg, "Please check the version to rollback")
+								return
+							}
+							version := versions[0].Version
+							ConfirmBoxAction(dlg, fmt.Sprintf("Rollback the configuration to version %d? the unsaved changes are lost", version), func() {
+								cfg, err := ConfigRollback(config.Filepath, version)
+								if err != nil {
+									ErrorBoxAction(dlg, err.Error())
+									return
+								}
+								rollback = cfg
+								dlg.Accept()
+							})
+						},
+					},
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Close",
+						OnClicked: func() {
+							dlg.Cancel()
+						},
+					},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("ConfigHistoryDialog: %s", err.Error())
+	}
+	return rollback
+}
+
+// ConfigCommentDialog asks the optional comment of the saved version
+func ConfigCommentDialog(from walk.Form) (string, bool) {
+	var dlg *walk.Dialog
+	var commentLine *walk.LineEdit
+	var acceptPB, cancelPB *walk.PushButton
+	var comment string
+
+	result, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Save Configuration",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 400, Height: 120},
+		Size:          Size{Width: 400, Height: 120},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Composite{
+				Layout: Grid{Columns: 2},
+				Children: []Widget{
+					Label{
+						Text: "Comment:",
+					},
+					LineEdit{
+						AssignTo:    &commentLine,
+						ToolTipText: "The comment of the version in the configuration history",
+					},
+				},
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Save",
+						OnClicked: func() {
+							comment = strings.TrimSpace(commentLine.Text())
+							dlg.Accept()
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("ConfigCommentDialog: %s", err.Error())
+		return "", false
+	}
+	return comment, result == walk.DlgCmdOK
+}
@@ -4,25 +4,38 @@ import (
 	"bytes"
 	"database/sql"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/astaxie/beego/logs"
 	_ "github.com/go-sql-driver/mysql"
 )
 
+const ALARM_TABLE = "alarm_history"
+
+const METHOD_AUDIT_TABLE = "method_audit"
+
+const (
+	BACKFILL_COLUMN     = "backfilled"
+	BACKFILL_MAX_HOURS  = 720
+	BACKFILL_MAX_VALUES = 100000
+)
+
 type ColumnInfo struct {
 	Name    string
 	Comment string
 }
 
 type DataSave struct {
+	sync.RWMutex
 	expired   int
 	database  string
 	db        *sql.DB
 	tableInfo map[string][]ColumnInfo
 }
 
-func ExecuteUpdate(db *sql.DB, sql string) error {
-	_, err := db.Exec(sql)
+func ExecuteUpdate(db *sql.DB, sql string, args ...interface{}) error {
+	_, err := db.Exec(sql, args...)
 	if err != nil {
 		logs.Error("ExecuteUpdate SQL[%s] failed, %s", sql, err.Error())
 		return err
@@ -137,6 +150,30 @@ func TableAlter(db *sql.DB, database, tableName string, newColumns []ColumnInfo)
 	return ExecuteUpdate(db, sql.String())
 }
 
+func ColumnRename(db *sql.DB, database, tableName string, oldName string, column ColumnInfo) error {
+	sql := fmt.Sprintf("ALTER TABLE %s.%s CHANGE `%s` `%s` TEXT COMMENT '%s'", database, tableName, oldName, column.Name, column.Comment)
+	return ExecuteUpdate(db, sql)
+}
+
+// ColumnRenames matches the missing columns to the unused old columns by the comment,
+// the node columns keep the node key as the comment when the alias renames the column
+func ColumnRenames(newColumns, oldColumns []ColumnInfo) map[string]ColumnInfo {
+	renames := make(map[string]ColumnInfo)
+	for _, newColumn := range ColumnCompare(newColumns, oldColumns) {
+		for _, oldColumn := range ColumnCompare(oldColumns, newColumns) {
+			if oldColumn.Comment == "" || oldColumn.Comment != newColumn.Comment {
+				continue
+			}
+			if _, ok := renames[oldColumn.Name]; ok {
+				continue
+			}
+			renames[oldColumn.Name] = newColumn
+			break
+		}
+	}
+	return renames
+}
+
 func TableWrite(db *sql.DB, database, tableName string, columns []ColumnInfo, values []string) error {
 	var sql bytes.Buffer
 
@@ -151,11 +188,14 @@ func TableWrite(db *sql.DB, database, tableName string, columns []ColumnInfo, va
 
 	sql.WriteString(") VALUES (")
 
+	// the values are bound as the parameters, the text of the node is never a part of the sql
+	args := make([]interface{}, 0, len(values))
 	for i, value := range values {
 		if value == "" {
 			sql.WriteString("NULL")
 		} else {
-			sql.WriteString(fmt.Sprintf("'%s'", value))
+			sql.WriteString("?")
+			args = append(args, value)
 		}
 		if i+1 != len(values) {
 			sql.WriteString(", ")
@@ -164,11 +204,16 @@ func TableWrite(db *sql.DB, database, tableName string, columns []ColumnInfo, va
 
 	sql.WriteString(")")
 
-	return ExecuteUpdate(db, sql.String())
+	return ExecuteUpdate(db, sql.String(), args...)
 }
 
 func NewDataSave(cfg DataStoreConfig) (*DataSave, error) {
-	url := fmt.Sprintf("%s:%s@tcp(%s:%d)/", cfg.UserName, cfg.PassWord, cfg.Address, cfg.Port)
+	password, err := SecretResolve(cfg.PassWord)
+	if err != nil {
+		logs.Error("CreateDataSave: %s", err.Error())
+		return nil, err
+	}
+	url := fmt.Sprintf("%s:%s@tcp(%s:%d)/", cfg.UserName, password, cfg.Address, cfg.Port)
 	db, err := sql.Open("mysql", url)
 	if err != nil {
 		logs.Error("CreateDataSave: %s", err.Error())
@@ -201,8 +246,16 @@ func (d *DataSave) Close() {
 	d.db = nil
 }
 
-func (d *DataSave) TableWrite(tableName string, values []string) error {
+func (d *DataSave) columns(tableName string) ([]ColumnInfo, bool) {
+	d.RLock()
+	defer d.RUnlock()
+
 	columns, ok := d.tableInfo[tableName]
+	return columns, ok
+}
+
+func (d *DataSave) TableWrite(tableName string, values []string) error {
+	columns, ok := d.columns(tableName)
 	if !ok {
 		return fmt.Errorf("DataSave.TableWrite: %s not init", tableName)
 	}
@@ -214,6 +267,47 @@ func (d *DataSave) TableWrite(tableName string, values []string) error {
 	return TableWrite(d.db, d.database, tableName, columns, values)
 }
 
+// TableWriteAt writes the row with the given timestamp instead of the current time
+func (d *DataSave) TableWriteAt(tableName string, timestamp time.Time, values []string) error {
+	columns, ok := d.columns(tableName)
+	if !ok {
+		return fmt.Errorf("DataSave.TableWriteAt: %s not init", tableName)
+	}
+
+	if len(columns) != len(values) {
+		return fmt.Errorf("DataSave.TableWriteAt: %s columns[%d] != values[%d]", tableName, len(columns), len(values))
+	}
+
+	columns = append([]ColumnInfo{{Name: "timestamp"}}, columns...)
+	values = append([]string{timestamp.Format(HISTORY_TIME_FORMAT)}, values...)
+
+	return TableWrite(d.db, d.database, tableName, columns, values)
+}
+
+// TableLast returns the timestamp of the last stored row, zero time if the table is empty
+func (d *DataSave) TableLast(tableName string) (time.Time, error) {
+	rows, err := ExecuteQuery(d.db, fmt.Sprintf("SELECT MAX(timestamp) FROM %s.%s", d.database, tableName))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return time.Time{}, nil
+	}
+
+	var timestamp sql.NullString
+	err = rows.Scan(&timestamp)
+	if err != nil {
+		logs.Error("TableLast Row Scan failed, %s", err.Error())
+		return time.Time{}, err
+	}
+	if !timestamp.Valid {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(HISTORY_TIME_FORMAT, timestamp.String, time.Local)
+}
+
 func (d *DataSave) TableInit(tableName string, columns []ColumnInfo) error {
 	if !TableCheck(d.db, d.database, tableName) {
 		err := TableCreate(d.db, d.database, tableName, columns)
@@ -225,6 +319,14 @@ func (d *DataSave) TableInit(tableName string, columns []ColumnInfo) error {
 		if err != nil {
 			return err
 		}
+		for oldName, column := range ColumnRenames(columns, oldColumns) {
+			err = ColumnRename(d.db, d.database, tableName, oldName, column)
+			if err != nil {
+				return err
+			}
+			logs.Info("DataSave.TableInit: %s rename column %s to %s", tableName, oldName, column.Name)
+			oldColumns = append(oldColumns, column)
+		}
 		newColumns := ColumnCompare(columns, oldColumns)
 		if len(newColumns) > 0 {
 			err = TableAlter(d.db, d.database, tableName, newColumns)
@@ -234,7 +336,9 @@ func (d *DataSave) TableInit(tableName string, columns []ColumnInfo) error {
 		}
 	}
 	logs.Info("DataSave.TableInit: %s Columns %d success", tableName, len(columns))
+	d.Lock()
 	d.tableInfo[tableName] = columns
+	d.Unlock()
 
 	return nil
 }
@@ -263,9 +367,11 @@ func (d *DataSave) TableExpired(enable bool) error {
 		buffer.WriteString(fmt.Sprintf("CREATE EVENT %s_data_expired_event ON SCHEDULE EVERY 1 HOUR ", d.database))
 		buffer.WriteString("STARTS CURRENT_TIMESTAMP ON COMPLETION PRESERVE DO BEGIN ")
 
+		d.RLock()
 		for table, _ := range d.tableInfo {
 			buffer.WriteString(fmt.Sprintf("DELETE FROM %s.%s WHERE timestamp < DATE_SUB(NOW(), INTERVAL %d DAY);", d.database, table, d.expired))
 		}
+		d.RUnlock()
 		buffer.WriteString("END;")
 
 		err = ExecuteUpdate(d.db, buffer.String())
@@ -278,3 +384,137 @@ func (d *DataSave) TableExpired(enable bool) error {
 
 	return nil
 }
+
+func AlarmColumns() []ColumnInfo {
+	return []ColumnInfo{
+		{Name: "event_time", Comment: "alarm event time"},
+		{Name: "event", Comment: "alarm event type"},
+		{Name: "client", Comment: "client name"},
+		{Name: "node", Comment: "node tag"},
+		{Name: "name", Comment: "alarm name"},
+		{Name: "type", Comment: "alarm type"},
+		{Name: "value", Comment: "node value"},
+		{Name: "limit_value", Comment: "alarm limit"},
+		{Name: "severity", Comment: "alarm severity"},
+		{Name: "message", Comment: "alarm message"},
+	}
+}
+
+func (d *DataSave) AlarmWrite(event AlarmEvent) error {
+	values := []string{
+		event.Time.Format("2006-01-02 15:04:05.000"),
+		event.Event.String(),
+		event.Client,
+		event.Alarm.Node.ToString(),
+		event.Alarm.Name,
+		event.Alarm.Type.String(),
+		fmt.Sprintf("%0.5f", event.Value),
+		fmt.Sprintf("%0.5f", event.Alarm.Limit),
+		fmt.Sprintf("%d", event.Alarm.Severity),
+		event.Alarm.Message,
+	}
+	return d.TableWrite(ALARM_TABLE, values)
+}
+
+const HISTORY_TIME_FORMAT = "2006-01-02 15:04:05"
+
+type HistoryRow struct {
+	Time  time.Time
+	Value string
+}
+
+func historyRows(db *sql.DB, sql string) ([]HistoryRow, error) {
+	rows, err := ExecuteQuery(db, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	output := make([]HistoryRow, 0)
+	for rows.Next() {
+		var timestamp, value string
+		err = rows.Scan(&timestamp, &value)
+		if err != nil {
+			logs.Error("HistoryRows Row Scan failed, %s", err.Error())
+			return nil, err
+		}
+		t, err := time.ParseInLocation(HISTORY_TIME_FORMAT, timestamp, time.Local)
+		if err != nil {
+			logs.Error("HistoryRows parse timestamp %s failed, %s", timestamp, err.Error())
+			return nil, err
+		}
+		output = append(output, HistoryRow{Time: t, Value: value})
+	}
+	return output, nil
+}
+
+// HistoryRaw reads the stored values between start and end, the rows are in descending order when reverse is true
+func (d *DataSave) HistoryRaw(tableName, column string, start, end time.Time, reverse bool, offset, limit int) ([]HistoryRow, error) {
+	var sql bytes.Buffer
+
+	sql.WriteString(fmt.Sprintf("SELECT timestamp, `%s` FROM %s.%s WHERE `%s` IS NOT NULL", column, d.database, tableName, column))
+	if !start.IsZero() {
+		sql.WriteString(fmt.Sprintf(" AND timestamp >= '%s'", start.Format(HISTORY_TIME_FORMAT)))
+	}
+	if !end.IsZero() {
+		sql.WriteString(fmt.Sprintf(" AND timestamp <= '%s'", end.Format(HISTORY_TIME_FORMAT)))
+	}
+	if reverse {
+		sql.WriteString(" ORDER BY timestamp DESC, id DESC")
+	} else {
+		sql.WriteString(" ORDER BY timestamp ASC, id ASC")
+	}
+	if limit > 0 {
+		sql.WriteString(fmt.Sprintf(" LIMIT %d, %d", offset, limit))
+	}
+
+	return historyRows(d.db, sql.String())
+}
+
+// HistoryBound reads the last stored value before the time, or the first value after it
+func (d *DataSave) HistoryBound(tableName, column string, t time.Time, before bool) (*HistoryRow, error) {
+	var query string
+	if before {
+		query = fmt.Sprintf("SELECT timestamp, `%s` FROM %s.%s WHERE `%s` IS NOT NULL AND timestamp < '%s' ORDER BY timestamp DESC, id DESC LIMIT 1",
+			column, d.database, tableName, column, t.Format(HISTORY_TIME_FORMAT))
+	} else {
+		query = fmt.Sprintf("SELECT timestamp, `%s` FROM %s.%s WHERE `%s` IS NOT NULL AND timestamp > '%s' ORDER BY timestamp ASC, id ASC LIMIT 1",
+			column, d.database, tableName, column, t.Format(HISTORY_TIME_FORMAT))
+	}
+
+	rows, err := historyRows(d.db, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+func MethodAuditColumns() []ColumnInfo {
+	return []ColumnInfo{
+		{Name: "call_time", Comment: "method call time"},
+		{Name: "user", Comment: "session user name"},
+		{Name: "client", Comment: "client name"},
+		{Name: "method", Comment: "server method name"},
+		{Name: "inputs", Comment: "input arguments"},
+		{Name: "outputs", Comment: "output arguments"},
+		{Name: "status", Comment: "call status code"},
+		{Name: "duration", Comment: "call duration in ms"},
+	}
+}
+
+func (d *DataSave) MethodAuditWrite(audit MethodAudit) error {
+	values := []string{
+		audit.Time.Format("2006-01-02 15:04:05.000"),
+		audit.User,
+		audit.Client,
+		audit.Method,
+		audit.Inputs,
+		audit.Outputs,
+		fmt.Sprintf("0x%08x", audit.Status),
+		fmt.Sprintf("%d", audit.Duration.Milliseconds()),
+	}
+	return d.TableWrite(METHOD_AUDIT_TABLE, values)
+}
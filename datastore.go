@@ -73,8 +73,10 @@ func DataStoreDialog(from walk.Form, config *Config) {
 						Text: "Password:",
 					},
 					LineEdit{
-						Text:     sqlConfig.PassWord,
-						AssignTo: &password,
+						Text:         sqlConfig.PassWord,
+						AssignTo:     &password,
+						PasswordMode: true,
+						ToolTipText:  "The password is encrypted by the save, ${NAME} reads the environment variable and ${file:path} reads the file",
 						OnEditingFinished: func() {
 							sqlConfig.PassWord = password.Text()
 						},
@@ -112,7 +114,12 @@ func DataStoreDialog(from walk.Form, config *Config) {
 							sqlConfig.Enable = enableCB.Checked()
 						},
 					},
-					HSpacer{},
+					PushButton{
+						Text: "Queue",
+						OnClicked: func() {
+							QueueEditDialog(dlg, "Datastore Queue", &sqlConfig.Queue)
+						},
+					},
 					PushButton{
 						AssignTo: &testPB,
 						Text:     "Connectivity Test",
@@ -162,7 +169,11 @@ func DataStoreDialog(from walk.Form, config *Config) {
 }
 
 func DataStoreTest(config DataStoreConfig) string {
-	url := fmt.Sprintf("%s:%s@tcp(%s:%d)/", config.UserName, config.PassWord, config.Address, config.Port)
+	password, err := SecretResolve(config.PassWord)
+	if err != nil {
+		return "The test failed for a reason:" + err.Error()
+	}
+	url := fmt.Sprintf("%s:%s@tcp(%s:%d)/", config.UserName, password, config.Address, config.Port)
 	db, err := sql.Open("mysql", url)
 	if err != nil {
 		return "The test failed for a reason:" + err.Error()
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+const DISCOVERY_DEFAULT_URL = "opc.tcp://localhost:4840"
+
+type DiscoveryResult struct {
+	Name     string
+	Endpoint EndpointInfo
+}
+
+func DiscoveryDialog(from walk.Form) (DiscoveryResult, bool) {
+	var dlg *walk.Dialog
+	var urlLine *walk.LineEdit
+	var serverBox, endpointBox *walk.ListBox
+	var findPB, networkPB, endpointsPB, acceptPB, cancelPB *walk.PushButton
+
+	var result DiscoveryResult
+	servers := make([]ServerInfo, 0)
+	endpoints := make([]EndpointInfo, 0)
+
+	serverModel := func() []string {
+		items := make([]string, 0)
+		for _, server := range servers {
+			items = append(items, server.String())
+		}
+		return items
+	}
+
+	endpointModel := func() []string {
+		items := make([]string, 0)
+		for _, endpoint := range endpoints {
+			items = append(items, endpoint.String())
+		}
+		return items
+	}
+
+	listServers := func(find func(string) ([]ServerInfo, error)) {
+		url := strings.TrimSpace(urlLine.Text())
+		if url == "" {
+			ErrorBoxAction(dlg, "The discovery address cannot be empty!")
+			return
+		}
+		list, err := find(url)
+		if err != nil {
+			ErrorBoxAction(dlg, "Find servers failed: "+err.Error())
+			return
+		}
+		servers = list
+		endpoints = endpoints[:0]
+		serverBox.SetModel(serverModel())
+		endpointBox.SetModel(endpointModel())
+	}
+
+	listEndpoints := func(url string) {
+		list, err := GetEndpoints(url)
+		if err != nil {
+			ErrorBoxAction(dlg, "Get endpoints failed: "+err.Error())
+			return
+		}
+		endpoints = list
+		endpointBox.SetModel(endpointModel())
+	}
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "OPC UA Discovery",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 750, Height: 500},
+		Size:          Size{Width: 750, Height: 500},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					Label{
+						Text: "Discovery Address:",
+					},
+					LineEdit{
+						AssignTo: &urlLine,
+						Text:     DISCOVERY_DEFAULT_URL,
+					},
+					PushButton{
+						AssignTo: &findPB,
+						Text:     "Find Servers",
+						OnClicked: func() {
+							listServers(FindServers)
+						},
+					},
+					PushButton{
+						AssignTo: &networkPB,
+						Text:     "Find On Network",
+						Visible:  DiscoveryMulticastSupported(),
+						OnClicked: func() {
+							listServers(FindServersOnNetwork)
+						},
+					},
+					PushButton{
+						AssignTo: &endpointsPB,
+						Text:     "Get Endpoints",
+						OnClicked: func() {
+							url := strings.TrimSpace(urlLine.Text())
+							if url == "" {
+								ErrorBoxAction(dlg, "The discovery address cannot be empty!")
+								return
+							}
+							listEndpoints(url)
+						},
+					},
+				},
+			},
+			Label{
+				Text: "Servers (name [type] discovery urls):",
+			},
+			ListBox{
+				AssignTo: &serverBox,
+				Model:    serverModel(),
+				OnCurrentIndexChanged: func() {
+					index := serverBox.CurrentIndex()
+					if index < 0 || index >= len(servers) {
+						return
+					}
+					urls := servers[index].DiscoveryUrls
+					if len(urls) == 0 {
+						ErrorBoxAction(dlg, "The server has no discovery url")
+						return
+					}
+					listEndpoints(urls[0])
+				},
+			},
+			Label{
+				Text: "Endpoints (url | security mode | security policy | user tokens):",
+			},
+			ListBox{
+				AssignTo: &endpointBox,
+				Model:    endpointModel(),
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							index := endpointBox.CurrentIndex()
+							if index < 0 || index >= len(endpoints) {
+								ErrorBoxAction(dlg, "Please select an endpoint!")
+								return
+							}
+							endpoint := endpoints[index]
+							if endpoint.Mode != "None" && !EncryptionSupported() {
+								ErrorBoxAction(dlg, "The endpoint requires "+endpoint.Mode+", the library is built without encryption")
+								return
+							}
+
+							result.Endpoint = endpoint
+							if server := serverBox.CurrentIndex(); server >= 0 && server < len(servers) {
+								result.Name = servers[server].Name
+							}
+
+							dlg.Accept()
+							logs.Info("discovery dialog accept %s", endpoint.Url)
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("discovery dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("DiscoveryDialog: %s", err.Error())
+		return result, false
+	}
+	return result, result.Endpoint.Url != ""
+}
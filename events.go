@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+const EVENT_RESUBSCRIBE_INTERVAL = time.Minute
+
+var defaultEventSelect = []string{"EventType", "Time", "Message", "Severity", "SourceName"}
+
+var eventOperators = []struct {
+	text     string
+	operator uint32
+}{
+	// the longer operators must be matched first at the same position
+	{">=", EVENT_OPERATOR_GREATERTHANOREQUAL},
+	{"<=", EVENT_OPERATOR_LESSTHANOREQUAL},
+	{"==", EVENT_OPERATOR_EQUALS},
+	{"=", EVENT_OPERATOR_EQUALS},
+	{">", EVENT_OPERATOR_GREATERTHAN},
+	{"<", EVENT_OPERATOR_LESSTHAN},
+	{" like ", EVENT_OPERATOR_LIKE},
+}
+
+type OpcuaClientEvent struct {
+	name   string
+	fields []EventField
+}
+
+func (c EventConfig) Selects() []string {
+	if len(c.Select) == 0 {
+		return defaultEventSelect
+	}
+	return c.Select
+}
+
+func EventsTableName(client string) string {
+	return EscapeString(client) + "_events"
+}
+
+func EventColumns(selects []string) []ColumnInfo {
+	columns := []ColumnInfo{{Name: "receive_time", Comment: "event receive time"}}
+	for _, field := range selects {
+		columns = append(columns, ColumnInfo{Name: ColumnName(field), Comment: EscapeString(field)})
+	}
+	return columns
+}
+
+// splitEventWhere splits the where clause by AND outside of the quoted strings
+func splitEventWhere(text string) []string {
+	terms := make([]string, 0)
+	quoted := false
+	begin := 0
+	lower := strings.ToLower(text)
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\'' {
+			quoted = !quoted
+			continue
+		}
+		if !quoted && strings.HasPrefix(lower[i:], " and ") {
+			terms = append(terms, text[begin:i])
+			begin = i + len(" and ")
+			i = begin - 1
+		}
+	}
+	return append(terms, text[begin:])
+}
+
+// findEventOperator returns the leftmost operator outside of the quoted strings
+func findEventOperator(term string) (int, int) {
+	quoted := false
+	lower := strings.ToLower(term)
+	for i := 0; i < len(term); i++ {
+		if term[i] == '\'' {
+			quoted = !quoted
+			continue
+		}
+		if quoted {
+			continue
+		}
+		for k, op := range eventOperators {
+			if strings.HasPrefix(lower[i:], op.text) {
+				return i, k
+			}
+		}
+	}
+	return -1, -1
+}
+
+func parseEventType(text string) (uint16, uint32, error) {
+	var ns uint64
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "ns=") {
+		parts := strings.SplitN(text[3:], ";", 2)
+		if len(parts) != 2 {
+			return 0, 0, fmt.Errorf("event type %q is invalid", text)
+		}
+		value, err := strconv.ParseUint(parts[0], 10, 16)
+		if err != nil {
+			return 0, 0, fmt.Errorf("event type %q is invalid", text)
+		}
+		ns = value
+		text = parts[1]
+	}
+	text = strings.TrimPrefix(text, "i=")
+	id, err := strconv.ParseUint(text, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("event type %q must be a numeric node id", text)
+	}
+	return uint16(ns), uint32(id), nil
+}
+
+func parseEventLiteral(text string) (NodeValue, error) {
+	text = strings.TrimSpace(text)
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return NodeValue{Type: UA_STRING, Value: text[1 : len(text)-1]}, nil
+	}
+	switch strings.ToLower(text) {
+	case "true":
+		return NodeValue{Type: UA_BOOLEAN, Value: true}, nil
+	case "false":
+		return NodeValue{Type: UA_BOOLEAN, Value: false}, nil
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return NodeValue{}, fmt.Errorf("literal %q is invalid, use number, true/false or 'string'", text)
+	}
+	return NodeValue{Type: UA_DOUBLE, Value: value}, nil
+}
+
+// ParseEventWhere parses the where clause, the terms are "OfType i=2041" or "Field op literal" joined by AND
+func ParseEventWhere(text string) ([]EventWhere, error) {
+	where := make([]EventWhere, 0)
+	if strings.TrimSpace(text) == "" {
+		return where, nil
+	}
+
+	for _, term := range splitEventWhere(text) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, fmt.Errorf("where clause has an empty term")
+		}
+
+		if strings.HasPrefix(strings.ToLower(term), "oftype ") {
+			ns, id, err := parseEventType(term[len("oftype "):])
+			if err != nil {
+				return nil, err
+			}
+			where = append(where, EventWhere{Operator: EVENT_OPERATOR_OFTYPE, TypeNs: ns, TypeID: id})
+			continue
+		}
+
+		index, k := findEventOperator(term)
+		if index <= 0 {
+			return nil, fmt.Errorf("where term %q is invalid", term)
+		}
+		op := eventOperators[k]
+		field := strings.TrimSpace(term[:index])
+		value, err := parseEventLiteral(term[index+len(op.text):])
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, EventWhere{Operator: op.operator, Field: field, Value: value})
+	}
+	return where, nil
+}
+
+// ServerEventFromFields maps the selected standard fields to the republished event
+func ServerEventFromFields(fields []EventField, sourceName string) ServerEvent {
+	event := ServerEvent{Type: UA_BASEEVENTTYPE, Time: time.Now(), Severity: 100, SourceName: sourceName}
+	for _, field := range fields {
+		switch field.Name {
+		case "EventType":
+			ns, id, err := parseEventType(field.Text)
+			if err == nil && ns == 0 {
+				event.Type = id
+			}
+		case "Time":
+			if field.Value != nil && field.Value.Type == UA_DATETIME && !field.Value.Array {
+				event.Time = DatetimeToTime(field.Value.Value.(uint64))
+			}
+		case "Severity":
+			if field.Value != nil {
+				if value, ok := field.Value.ToFloat(); ok {
+					event.Severity = uint16(value)
+				}
+			}
+		case "Message":
+			event.Message = field.Text
+		case "SourceName":
+			if field.Text != "" {
+				event.SourceName = field.Text
+			}
+		}
+	}
+	return event
+}
+
+// clientEvents keeps the event subscription of the client, it returns the next subscribe time
+func (opc *OpcuaServer) clientEvents(ctx context.Context, cli *Client, cfg ClientConfig, next time.Time) time.Time {
+	if next.IsZero() {
+		if err := cli.Iterate(); err != nil {
+			logs.Warning("opcua client %s events iterate failed, %s", cfg.Name, err.Error())
+			return time.Now()
+		}
+		return next
+	}
+
+	if time.Now().Before(next) {
+		return next
+	}
+
+	where, err := ParseEventWhere(cfg.Events.Where)
+	if err != nil {
+		logs.Error("opcua client %s events where clause invalid, %s", cfg.Name, err.Error())
+		return time.Now().Add(EVENT_RESUBSCRIBE_INTERVAL)
+	}
+
+	interval := time.Duration(cfg.Events.Interval) * time.Millisecond
+	err = cli.SubscribeEvents(interval, cfg.Events.Selects(), where, func(fields []EventField) {
+		opc.clientEvent(ctx, cfg, fields)
+	})
+	if err != nil {
+		logs.Warning("opcua client %s subscribe events failed, %s", cfg.Name, err.Error())
+		return time.Now().Add(EVENT_RESUBSCRIBE_INTERVAL)
+	}
+
+	logs.Info("opcua client %s subscribe events success", cfg.Name)
+	return time.Time{}
+}
+
+func (opc *OpcuaServer) clientEvent(ctx context.Context, cfg ClientConfig, fields []EventField) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	if cfg.Store && opc.db != nil {
+		values := []string{time.Now().Format("2006-01-02 15:04:05.000")}
+		for _, field := range fields {
+			values = append(values, field.Text)
+		}
+		if opc.dbPush(ctx, cfg.Name, OpcuaStoreData{table: EventsTableName(cfg.Name), values: values}) {
+			atomic.AddUint64(&opc.stats[STAT_CLIENT].OperOK, 1)
+		} else {
+			atomic.AddUint64(&opc.stats[STAT_CLIENT].OperFail, 1)
+		}
+	}
+
+	if opc.server != nil {
+		opc.serverPush(ctx, cfg.Name, OpcuaClientEvent{name: cfg.Name, fields: fields})
+	}
+}
+
+func (opc *OpcuaServer) serverEventWrite(event OpcuaClientEvent) {
+	opc.RLock()
+	origin, ok := opc.eventCache[event.name]
+	opc.RUnlock()
+	if !ok {
+		return
+	}
+
+	err := opc.server.TriggerEvent(origin, ServerEventFromFields(event.fields, event.name))
+	if err != nil {
+		logs.Error("server trigger event on %s failed, %s", origin.ToString(), err.Error())
+		atomic.AddUint64(&opc.stats[STAT_SERVER].OperFail, 1)
+	} else {
+		atomic.AddUint64(&opc.stats[STAT_SERVER].OperOK, 1)
+	}
+}
+
+func EventEditDialog(from walk.Form, config *EventConfig) {
+	var dlg *walk.Dialog
+	var enableCB *walk.CheckBox
+	var interval *walk.NumberEdit
+	var selectEdit *walk.TextEdit
+	var whereLine *walk.LineEdit
+	var acceptPB, cancelPB *walk.PushButton
+
+	intervalValue := config.Interval
+	if intervalValue == 0 {
+		intervalValue = 1000
+	}
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Event Subscription Settings",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 550, Height: 400},
+		Size:          Size{Width: 550, Height: 400},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					CheckBox{
+						AssignTo: &enableCB,
+						Text:     "Enable",
+						Checked:  config.Enable,
+					},
+					Label{
+						Text: "Publishing Interval:",
+					},
+					NumberEdit{
+						AssignTo:    &interval,
+						Value:       float64(intervalValue),
+						ToolTipText: "100~60000 ms",
+						MaxValue:    60000,
+						MinValue:    100,
+					},
+					HSpacer{},
+				},
+			},
+			Label{
+				Text: "Select Clauses (browse path of BaseEventType fields, one per line):",
+			},
+			TextEdit{
+				AssignTo: &selectEdit,
+				Text:     strings.Join(config.Selects(), "\r\n"),
+				VScroll:  true,
+			},
+			Label{
+				Text: "Where Clause (e.g. OfType i=2041 AND Severity >= 500 AND SourceName = 'Pump'):",
+			},
+			LineEdit{
+				AssignTo: &whereLine,
+				Text:     config.Where,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							selects := make([]string, 0)
+							for _, line := range strings.Split(selectEdit.Text(), "\n") {
+								line = strings.TrimSpace(line)
+								if line != "" {
+									selects = append(selects, line)
+								}
+							}
+							if len(selects) == 0 {
+								ErrorBoxAction(dlg, "The select clauses cannot be empty!")
+								return
+							}
+							if _, err := ParseEventWhere(whereLine.Text()); err != nil {
+								ErrorBoxAction(dlg, "Where clause is invalid, "+err.Error())
+								return
+							}
+
+							config.Enable = enableCB.Checked()
+							config.Interval = int(interval.Value())
+							config.Select = selects
+							config.Where = strings.TrimSpace(whereLine.Text())
+
+							dlg.Accept()
+							logs.Info("event edit dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("event edit dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("EventEditDialog: %s", err.Error())
+	}
+}
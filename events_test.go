@@ -0,0 +1,97 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitEventWhere(t *testing.T) {
+	cases := []struct {
+		text  string
+		terms []string
+	}{
+		{"Severity > 500", []string{"Severity > 500"}},
+		{"Severity > 500 AND Message = 'x'", []string{"Severity > 500", "Message = 'x'"}},
+		{"Severity > 500 and Message = 'x'", []string{"Severity > 500", "Message = 'x'"}},
+		{"Message = 'a and b' AND Severity > 1", []string{"Message = 'a and b'", "Severity > 1"}},
+		{"Message = 'a AND b'", []string{"Message = 'a AND b'"}},
+		{"Brand = 'x'", []string{"Brand = 'x'"}},
+	}
+	for _, c := range cases {
+		terms := splitEventWhere(c.text)
+		if !reflect.DeepEqual(terms, c.terms) {
+			t.Errorf("split %q: %q, want %q", c.text, terms, c.terms)
+		}
+	}
+}
+
+func TestFindEventOperator(t *testing.T) {
+	cases := []struct {
+		term     string
+		index    int
+		operator uint32
+	}{
+		{"Severity >= 500", 9, EVENT_OPERATOR_GREATERTHANOREQUAL},
+		{"Severity <= 500", 9, EVENT_OPERATOR_LESSTHANOREQUAL},
+		{"Severity == 500", 9, EVENT_OPERATOR_EQUALS},
+		{"Severity = 500", 9, EVENT_OPERATOR_EQUALS},
+		{"Severity>500", 8, EVENT_OPERATOR_GREATERTHAN},
+		{"Severity < 500", 9, EVENT_OPERATOR_LESSTHAN},
+		{"Message like 'Tank%'", 7, EVENT_OPERATOR_LIKE},
+		{"Message LIKE 'a = b'", 7, EVENT_OPERATOR_LIKE},
+	}
+	for _, c := range cases {
+		index, k := findEventOperator(c.term)
+		if index != c.index || k < 0 || eventOperators[k].operator != c.operator {
+			t.Errorf("find %q: index %d operator %d, want %d %d", c.term, index, k, c.index, c.operator)
+		}
+	}
+
+	if index, k := findEventOperator("'a >= b'"); index != -1 || k != -1 {
+		t.Errorf("operator in the quoted string is found at %d", index)
+	}
+	if index, _ := findEventOperator("Severity"); index != -1 {
+		t.Errorf("term without operator is found at %d", index)
+	}
+}
+
+func TestParseEventWhere(t *testing.T) {
+	where, err := ParseEventWhere("OfType ns=2;i=1000 AND Message = 'high > 5 and low < 1' AND Severity >= 500")
+	if err != nil {
+		t.Fatalf("parse failed, %s", err.Error())
+	}
+	want := []EventWhere{
+		{Operator: EVENT_OPERATOR_OFTYPE, TypeNs: 2, TypeID: 1000},
+		{Operator: EVENT_OPERATOR_EQUALS, Field: "Message", Value: NodeValue{Type: UA_STRING, Value: "high > 5 and low < 1"}},
+		{Operator: EVENT_OPERATOR_GREATERTHANOREQUAL, Field: "Severity", Value: NodeValue{Type: UA_DOUBLE, Value: 500.0}},
+	}
+	if !reflect.DeepEqual(where, want) {
+		t.Fatalf("parse: %+v, want %+v", where, want)
+	}
+
+	where, err = ParseEventWhere("oftype i=2041 and Retain = true")
+	if err != nil {
+		t.Fatalf("parse failed, %s", err.Error())
+	}
+	if len(where) != 2 || where[0].TypeID != 2041 || where[1].Value.Value != true {
+		t.Fatalf("parse: %+v", where)
+	}
+
+	if where, err = ParseEventWhere("  "); err != nil || len(where) != 0 {
+		t.Fatalf("empty clause: %+v %v", where, err)
+	}
+
+	for _, text := range []string{
+		"Severity > 500 AND ",
+		"= 500",
+		"Severity",
+		"Severity > high",
+		"OfType ns=2",
+		"OfType i=Tank",
+		"Message = 'unquoted and Severity > 1",
+	} {
+		if _, err := ParseEventWhere(text); err == nil {
+			t.Errorf("parse %q has no error", text)
+		}
+	}
+}
@@ -25,6 +25,15 @@ func IconDirGet() string {
 	return dir
 }
 
+func CertDirGet() string {
+	dir := filepath.Join(DEFAULT_HOME, "pki")
+	_, err := os.Stat(dir)
+	if err != nil {
+		os.MkdirAll(dir, 0644)
+	}
+	return dir
+}
+
 func ConfigDirGet() string {
 	dir := filepath.Join(DEFAULT_HOME, "config")
 	_, err := os.Stat(dir)
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+type CollisionMode int
+
+const (
+	COLLISION_SUFFIX CollisionMode = iota
+	COLLISION_SKIP
+	COLLISION_ERROR
+)
+
+var collisionModeNames = []string{"Suffix", "Skip", "Error"}
+
+func (m CollisionMode) String() string {
+	if int(m) < 0 || int(m) >= len(collisionModeNames) {
+		return "UNKNOWN"
+	}
+	return collisionModeNames[m]
+}
+
+func CollisionModeNames() []string {
+	return collisionModeNames
+}
+
+type nameRule struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+type HierarchyBuilder struct {
+	server    *Server
+	root      NodeInfo
+	collision CollisionMode
+	rules     []nameRule
+	client    *Client
+	cache     *BrowseCache
+
+	folders map[string]NodeInfo
+	names   map[string]map[string]string
+}
+
+func NewHierarchyBuilder(server *Server, cli *Client, cfg HierarchyConfig, root NodeInfo) (*HierarchyBuilder, error) {
+	builder := &HierarchyBuilder{
+		server:    server,
+		root:      root,
+		collision: cfg.Collision,
+		rules:     make([]nameRule, 0),
+		client:    cli,
+		cache:     NewBrowseCache(),
+		folders:   make(map[string]NodeInfo),
+		names:     make(map[string]map[string]string),
+	}
+
+	for _, mapping := range cfg.Mapping {
+		pattern, err := regexp.Compile(mapping.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("browse name mapping %s is invalid, %s", mapping.Pattern, err.Error())
+		}
+		builder.rules = append(builder.rules, nameRule{pattern: pattern, replace: mapping.Replace})
+	}
+
+	return builder, nil
+}
+
+func (h *HierarchyBuilder) MapName(name string) string {
+	output := name
+	for _, rule := range h.rules {
+		output = rule.pattern.ReplaceAllString(output, rule.replace)
+	}
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return name
+	}
+	return output
+}
+
+// reserve the browse name below parent for owner, returns empty name if skipped
+func (h *HierarchyBuilder) reserve(parent NodeInfo, name string, owner string, folder bool) (string, error) {
+	names, ok := h.names[parent.NodeID]
+	if !ok {
+		names = make(map[string]string)
+		h.names[parent.NodeID] = names
+	}
+
+	exist, ok := names[name]
+	if !ok || exist == owner {
+		names[name] = owner
+		return name, nil
+	}
+
+	switch h.collision {
+	case COLLISION_SKIP:
+		if folder {
+			return name, nil
+		}
+		logs.Warning("opcua server browse name %s under %s already used by %s, skip %s", name, parent.NodeID, exist, owner)
+		return "", nil
+	case COLLISION_ERROR:
+		return "", fmt.Errorf("browse name %s under %s already used by %s", name, parent.NodeID, exist)
+	}
+
+	for i := 2; ; i++ {
+		suffix := fmt.Sprintf("%s_%d", name, i)
+		if exist, ok := names[suffix]; !ok || exist == owner {
+			names[suffix] = owner
+			logs.Warning("opcua server browse name %s under %s already used, rename %s to %s", name, parent.NodeID, owner, suffix)
+			return suffix, nil
+		}
+	}
+}
+
+func (h *HierarchyBuilder) folder(parent NodeInfo, tree *NodeTree, name string) (NodeInfo, error) {
+	owner := tree.Node.ToString()
+	if folder, ok := h.folders[owner]; ok {
+		return folder, nil
+	}
+
+	browseName, err := h.reserve(parent, h.MapName(name), owner, true)
+	if err != nil {
+		return NodeInfo{}, err
+	}
+
+	folder := NodeInfo{NsIndex: h.root.NsIndex, NodeID: parent.NodeID + "/" + browseName}
+	if h.names[parent.NodeID][browseName] != owner {
+		// merge into the folder that owns the same browse name
+		h.folders[owner] = folder
+		return folder, nil
+	}
+
+	err = h.server.AddFolder(parent, folder, browseName)
+	if err != nil {
+		return NodeInfo{}, err
+	}
+	h.folders[owner] = folder
+
+	logs.Info("opcua server add folder %s success", folder.ToString())
+	return folder, nil
+}
+
+// Place returns the parent node and browse name of the proxy variable,
+// the browse name is empty if the node should be skipped
+func (h *HierarchyBuilder) Place(node ServerNodeInfo) (NodeInfo, string, error) {
+	parent := h.root
+	path, err := h.client.BrowsePath(node.ClientNode, h.cache)
+	if err != nil {
+		logs.Warning("opcua server source node %s browse path failed, use flat layout, %s", node.ClientNode.ToString(), err.Error())
+		return h.reserveLeaf(parent, node.ClientNode.NodeID, node)
+	}
+
+	prefix := ""
+	for _, tree := range path[:len(path)-1] {
+		folder, err := h.folder(parent, tree, TreeSegmentName(prefix, tree))
+		if err != nil {
+			return NodeInfo{}, "", err
+		}
+		parent = folder
+		prefix = tree.Node.NodeID
+	}
+
+	leaf := path[len(path)-1]
+	name := TreeSegmentName(prefix, leaf) + strings.TrimPrefix(node.ClientNode.Key(), leaf.Node.Key())
+	return h.reserveLeaf(parent, name, node)
+}
+
+// TreeSegmentName names the numeric, guid and opaque node ids by the browse name
+func TreeSegmentName(parent string, tree *NodeTree) string {
+	if tree.Node.Type() != NODEID_STRING {
+		return tree.BrowseName
+	}
+	return SegmentName(parent, tree.Node.NodeID)
+}
+
+// reserveLeaf names the leaf by the alias of the client node as given, the other names are mapped
+func (h *HierarchyBuilder) reserveLeaf(parent NodeInfo, name string, node ServerNodeInfo) (NodeInfo, string, error) {
+	name = h.MapName(name)
+	if node.ClientNode.Alias != "" {
+		name = node.ClientNode.Alias
+	}
+	browseName, err := h.reserve(parent, name, node.ServerName, false)
+	if err != nil {
+		return NodeInfo{}, "", err
+	}
+	return parent, browseName, nil
+}
+
+// SegmentName strips the parent node id prefix from string node ids like "Channel1.Device1.Tag1"
+func SegmentName(parent string, nodeID string) string {
+	if parent == "" {
+		return nodeID
+	}
+	for _, sep := range []string{".", "/"} {
+		if strings.HasPrefix(nodeID, parent+sep) && len(nodeID) > len(parent)+1 {
+			return nodeID[len(parent)+1:]
+		}
+	}
+	return nodeID
+}
+
+func MappingToText(mapping []NameMapping) string {
+	lines := make([]string, 0)
+	for _, item := range mapping {
+		lines = append(lines, item.Pattern+" => "+item.Replace)
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+func MappingFromText(text string) ([]NameMapping, error) {
+	mapping := make([]NameMapping, 0)
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "=>", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: missing \"=>\"", i+1)
+		}
+		item := NameMapping{Pattern: strings.TrimSpace(parts[0]), Replace: strings.TrimSpace(parts[1])}
+		if _, err := regexp.Compile(item.Pattern); err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+		}
+		mapping = append(mapping, item)
+	}
+	return mapping, nil
+}
+
+func HierarchyEditDialog(from walk.Form, config *HierarchyConfig) {
+	var dlg *walk.Dialog
+	var enableCB *walk.CheckBox
+	var collisionBox *walk.ComboBox
+	var mappingEdit *walk.TextEdit
+	var acceptPB, cancelPB *walk.PushButton
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Server Hierarchy Settings",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 450, Height: 300},
+		Size:          Size{Width: 450, Height: 300},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			CheckBox{
+				AssignTo: &enableCB,
+				Text:     "Mirror the source browse path as folders on the server",
+				Checked:  config.Enable,
+			},
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					Label{
+						Text: "Name Collision:",
+					},
+					ComboBox{
+						AssignTo:     &collisionBox,
+						CurrentIndex: int(config.Collision),
+						Model:        CollisionModeNames(),
+						ToolTipText:  "Suffix: append _2, _3 ...; Skip: merge folders and skip variables; Error: stop startup",
+					},
+					HSpacer{},
+				},
+			},
+			Label{
+				Text: "Browse Name Mapping (one rule per line, regexp => replace):",
+			},
+			TextEdit{
+				AssignTo: &mappingEdit,
+				Text:     MappingToText(config.Mapping),
+				VScroll:  true,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							mapping, err := MappingFromText(mappingEdit.Text())
+							if err != nil {
+								ErrorBoxAction(dlg, "Browse name mapping is invalid, "+err.Error())
+								return
+							}
+							config.Enable = enableCB.Checked()
+							config.Collision = CollisionMode(collisionBox.CurrentIndex())
+							config.Mapping = mapping
+							dlg.Accept()
+							logs.Info("hierarchy edit dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("hierarchy edit dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("HierarchyEditDialog: %s", err.Error())
+	}
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const (
+	HISTORY_MAX_VALUES     = 1000
+	HISTORY_MAX_INTERVALS  = 1000
+	HISTORY_MAX_SAMPLES    = 100000
+	HISTORY_CURSOR_EXPIRED = 10 * time.Minute
+)
+
+var errHistorySamples = fmt.Errorf("more than %d stored values to aggregate", HISTORY_MAX_SAMPLES)
+
+var historyAggregateNames = map[uint32]string{
+	HISTORY_AGGREGATE_RAW:           "Raw",
+	HISTORY_AGGREGATE_INTERPOLATIVE: "Interpolative",
+	HISTORY_AGGREGATE_AVERAGE:       "Average",
+	HISTORY_AGGREGATE_MINIMUM:       "Minimum",
+	HISTORY_AGGREGATE_MAXIMUM:       "Maximum",
+	HISTORY_AGGREGATE_COUNT:         "Count",
+}
+
+type historyNode struct {
+	table    string
+	column   string
+	template NodeValue
+}
+
+type historyCursor struct {
+	key    string
+	offset int
+	expire time.Time
+}
+
+type historySample struct {
+	time  time.Time
+	value float64
+}
+
+// HistoryStore answers the proxy server history reads from the datastore tables
+type HistoryStore struct {
+	sync.Mutex
+
+	db      *DataSave
+	nodes   map[string]historyNode
+	cursors map[string]historyCursor
+}
+
+func NewHistoryStore(db *DataSave) *HistoryStore {
+	return &HistoryStore{
+		db:      db,
+		nodes:   make(map[string]historyNode),
+		cursors: make(map[string]historyCursor),
+	}
+}
+
+func historyKey(namespace, nodeID string) string {
+	return namespace + "|" + nodeID
+}
+
+func (h *HistoryStore) Add(namespace, nodeID string, table, column string, template NodeValue) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.nodes[historyKey(namespace, nodeID)] = historyNode{table: table, column: column, template: *template.Clone()}
+}
+
+func (h *HistoryStore) Remove(namespace string) {
+	h.Lock()
+	defer h.Unlock()
+
+	for key := range h.nodes {
+		if strings.HasPrefix(key, historyKey(namespace, "")) {
+			delete(h.nodes, key)
+		}
+	}
+}
+
+func (h *HistoryStore) node(namespace, nodeID string) (historyNode, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	node, ok := h.nodes[historyKey(namespace, nodeID)]
+	return node, ok
+}
+
+func (h *HistoryStore) cursorTake(token []byte, key string) (int, bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	id := hex.EncodeToString(token)
+	cursor, ok := h.cursors[id]
+	if !ok {
+		return 0, false
+	}
+	delete(h.cursors, id)
+
+	if cursor.key != key || time.Now().After(cursor.expire) {
+		return 0, false
+	}
+	return cursor.offset, true
+}
+
+func (h *HistoryStore) cursorSave(key string, offset int) []byte {
+	token := make([]byte, 16)
+	_, err := rand.Read(token)
+	if err != nil {
+		logs.Error("history cursor create failed, %s", err.Error())
+		return nil
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	now := time.Now()
+	for id, cursor := range h.cursors {
+		if now.After(cursor.expire) {
+			delete(h.cursors, id)
+		}
+	}
+	h.cursors[hex.EncodeToString(token)] = historyCursor{key: key, offset: offset, expire: now.Add(HISTORY_CURSOR_EXPIRED)}
+	return token
+}
+
+func (n historyNode) parse(row HistoryRow) HistoryValue {
+	value := n.template.Clone()
+
+	items := []string{row.Value}
+	if value.Array && len(value.Dims) > 1 {
+		var dims []uint32
+		var err error
+		items, dims, err = ParseMatrixString(row.Value)
+		if err != nil || MatrixLength(dims) != MatrixLength(value.Dims) {
+			return HistoryValue{Time: row.Time, Status: UA_STATUS_BADDATAENCODINGINVALID}
+		}
+		value.Dims = dims
+	} else if value.Array {
+		// the array is stored as the json array, the rows stored before are the comma lists
+		items = strings.Split(row.Value, ",")
+		if strings.HasPrefix(row.Value, "[") {
+			if list, _, err := ParseMatrixString(row.Value); err == nil {
+				items = list
+			}
+		}
+	}
+
+	err := value.FromString(items)
+	if err != nil {
+		return HistoryValue{Time: row.Time, Status: UA_STATUS_BADDATAENCODINGINVALID}
+	}
+	return HistoryValue{Value: value, Time: row.Time, Status: UA_STATUS_GOOD}
+}
+
+// Read is the HistoryHandler of the proxy server
+func (h *HistoryStore) Read(request HistoryRequest) (*HistoryResponse, uint32) {
+	node, ok := h.node(request.Namespace, request.NodeID)
+	if !ok {
+		return nil, UA_STATUS_BADNODEIDUNKNOWN
+	}
+
+	key := fmt.Sprintf("%s|%d", historyKey(request.Namespace, request.NodeID), request.Aggregate)
+
+	offset := 0
+	if len(request.ContinuationPoint) > 0 {
+		offset, ok = h.cursorTake(request.ContinuationPoint, key)
+		if !ok {
+			return nil, UA_STATUS_BADCONTINUATIONPOINTINVALID
+		}
+	}
+
+	if request.Release {
+		return &HistoryResponse{}, UA_STATUS_GOOD
+	}
+
+	if request.Aggregate == HISTORY_AGGREGATE_RAW {
+		return h.readRaw(node, key, request, offset)
+	}
+	return h.readProcessed(node, key, request, offset)
+}
+
+func (h *HistoryStore) readRaw(node historyNode, key string, request HistoryRequest, offset int) (*HistoryResponse, uint32) {
+	start, end := request.Start, request.End
+	if start.IsZero() && end.IsZero() {
+		return nil, UA_STATUS_BADINVALIDARGUMENT
+	}
+
+	// without start time or with start after end, the values are returned backward
+	reverse := start.IsZero() || (!end.IsZero() && start.After(end))
+	if reverse && !start.IsZero() {
+		start, end = end, start
+	}
+
+	limit := HISTORY_MAX_VALUES
+	if request.MaxValues > 0 && int(request.MaxValues) < limit {
+		limit = int(request.MaxValues)
+	}
+
+	rows, err := h.db.HistoryRaw(node.table, node.column, start, end, reverse, offset, limit+1)
+	if err != nil {
+		logs.Error("history read raw %s failed, %s", request.NodeID, err.Error())
+		return nil, UA_STATUS_BADINTERNALERROR
+	}
+
+	response := &HistoryResponse{Values: make([]HistoryValue, 0)}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		response.ContinuationPoint = h.cursorSave(key, offset+limit)
+	}
+	for _, row := range rows {
+		response.Values = append(response.Values, node.parse(row))
+	}
+	return response, UA_STATUS_GOOD
+}
+
+// samples reads the values to aggregate, it fails with errHistorySamples above HISTORY_MAX_SAMPLES rows
+func (h *HistoryStore) samples(node historyNode, start, end time.Time, interpolative bool) ([]historySample, error) {
+	rows, err := h.db.HistoryRaw(node.table, node.column, start, end, false, 0, HISTORY_MAX_SAMPLES+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > HISTORY_MAX_SAMPLES {
+		return nil, errHistorySamples
+	}
+
+	if interpolative {
+		before, err := h.db.HistoryBound(node.table, node.column, start, true)
+		if err != nil {
+			return nil, err
+		}
+		after, err := h.db.HistoryBound(node.table, node.column, end, false)
+		if err != nil {
+			return nil, err
+		}
+		if before != nil {
+			rows = append([]HistoryRow{*before}, rows...)
+		}
+		if after != nil {
+			rows = append(rows, *after)
+		}
+	}
+
+	samples := make([]historySample, 0)
+	for _, row := range rows {
+		item := node.parse(row)
+		if item.Value == nil {
+			continue
+		}
+		value, ok := item.Value.ToFloat()
+		if !ok {
+			continue
+		}
+		samples = append(samples, historySample{time: row.Time, value: value})
+	}
+	return samples, nil
+}
+
+func historyInterpolate(samples []historySample, t time.Time) (float64, bool) {
+	var prev, next *historySample
+	for i := range samples {
+		if !samples[i].time.After(t) {
+			prev = &samples[i]
+		}
+		if !samples[i].time.Before(t) {
+			next = &samples[i]
+			break
+		}
+	}
+	if prev == nil || next == nil {
+		return 0, false
+	}
+	if !next.time.After(prev.time) {
+		return prev.value, true
+	}
+	ratio := float64(t.Sub(prev.time)) / float64(next.time.Sub(prev.time))
+	return prev.value + (next.value-prev.value)*ratio, true
+}
+
+func historyAggregate(aggregate uint32, samples []historySample, begin, end, timestamp time.Time) HistoryValue {
+	if aggregate == HISTORY_AGGREGATE_INTERPOLATIVE {
+		value, ok := historyInterpolate(samples, timestamp)
+		if !ok {
+			return HistoryValue{Time: timestamp, Status: UA_STATUS_BADNODATA}
+		}
+		return HistoryValue{Value: &NodeValue{Type: UA_DOUBLE, Value: value}, Time: timestamp, Status: UA_STATUS_GOOD}
+	}
+
+	values := make([]float64, 0)
+	for _, sample := range samples {
+		if !sample.time.Before(begin) && sample.time.Before(end) {
+			values = append(values, sample.value)
+		}
+	}
+
+	if aggregate == HISTORY_AGGREGATE_COUNT {
+		return HistoryValue{Value: &NodeValue{Type: UA_INT32, Value: int32(len(values))}, Time: timestamp, Status: UA_STATUS_GOOD}
+	}
+
+	if len(values) == 0 {
+		return HistoryValue{Time: timestamp, Status: UA_STATUS_BADNODATA}
+	}
+
+	result := values[0]
+	for _, value := range values[1:] {
+		switch aggregate {
+		case HISTORY_AGGREGATE_AVERAGE:
+			result += value
+		case HISTORY_AGGREGATE_MINIMUM:
+			if value < result {
+				result = value
+			}
+		case HISTORY_AGGREGATE_MAXIMUM:
+			if value > result {
+				result = value
+			}
+		}
+	}
+	if aggregate == HISTORY_AGGREGATE_AVERAGE {
+		result = result / float64(len(values))
+	}
+	return HistoryValue{Value: &NodeValue{Type: UA_DOUBLE, Value: result}, Time: timestamp, Status: UA_STATUS_GOOD}
+}
+
+func (h *HistoryStore) readProcessed(node historyNode, key string, request HistoryRequest, offset int) (*HistoryResponse, uint32) {
+	if _, ok := historyAggregateNames[request.Aggregate]; !ok {
+		return nil, UA_STATUS_BADAGGREGATENOTSUPPORTED
+	}
+	if _, ok := node.template.ToFloat(); !ok {
+		return nil, UA_STATUS_BADAGGREGATENOTSUPPORTED
+	}
+
+	start, end := request.Start, request.End
+	if start.IsZero() || end.IsZero() || start.Equal(end) || request.Interval < 0 {
+		return nil, UA_STATUS_BADINVALIDARGUMENT
+	}
+
+	// intervals begin at the start time, and go backward when start is after end
+	reverse := start.After(end)
+	low, high := start, end
+	if reverse {
+		low, high = end, start
+	}
+
+	interval := request.Interval
+	if interval == 0 {
+		interval = high.Sub(low)
+	}
+	total := int((high.Sub(low) + interval - 1) / interval)
+
+	last := offset + HISTORY_MAX_INTERVALS
+	if last > total {
+		last = total
+	}
+	if offset >= last {
+		return &HistoryResponse{Values: make([]HistoryValue, 0)}, UA_STATUS_GOOD
+	}
+
+	bounds := func(index int) (time.Time, time.Time) {
+		if reverse {
+			upper := high.Add(-time.Duration(index) * interval)
+			lower := upper.Add(-interval)
+			if lower.Before(low) {
+				lower = low
+			}
+			return lower, upper
+		}
+		lower := low.Add(time.Duration(index) * interval)
+		upper := lower.Add(interval)
+		if upper.After(high) {
+			upper = high
+		}
+		return lower, upper
+	}
+
+	page := func(last int) (time.Time, time.Time) {
+		pageLow, pageHigh := bounds(offset)
+		for i := offset + 1; i < last; i++ {
+			lower, upper := bounds(i)
+			if lower.Before(pageLow) {
+				pageLow = lower
+			}
+			if upper.After(pageHigh) {
+				pageHigh = upper
+			}
+		}
+		return pageLow, pageHigh
+	}
+
+	// the page takes fewer intervals while its stored values are too many, the rest follows by continuation
+	var samples []historySample
+	var err error
+	for {
+		pageLow, pageHigh := page(last)
+		samples, err = h.samples(node, pageLow, pageHigh, request.Aggregate == HISTORY_AGGREGATE_INTERPOLATIVE)
+		if !errors.Is(err, errHistorySamples) || last-offset == 1 {
+			break
+		}
+		last = offset + (last-offset)/2
+	}
+	if errors.Is(err, errHistorySamples) {
+		logs.Warning("history read processed %s failed, %s in one interval", request.NodeID, err.Error())
+		return nil, UA_STATUS_BADRESOURCEUNAVAILABLE
+	}
+	if err != nil {
+		logs.Error("history read processed %s failed, %s", request.NodeID, err.Error())
+		return nil, UA_STATUS_BADINTERNALERROR
+	}
+
+	response := &HistoryResponse{Values: make([]HistoryValue, 0)}
+	for i := offset; i < last; i++ {
+		lower, upper := bounds(i)
+		timestamp := lower
+		if reverse {
+			timestamp = upper
+		}
+		response.Values = append(response.Values, historyAggregate(request.Aggregate, samples, lower, upper, timestamp))
+	}
+
+	if last < total {
+		response.ContinuationPoint = h.cursorSave(key, last)
+	}
+	return response, UA_STATUS_GOOD
+}
@@ -19,7 +19,7 @@ func ApplicationLogInit(config ApplicationConfig) error {
 		fmt.Printf("json.Marshal failed: %v", err)
 		return err
 	}
-	err = logs.SetLogger(logs.AdapterFile, string(value))
+	err = logs.SetLogger(LOG_ADAPTER_REDACT, string(value))
 	if err != nil {
 		fmt.Printf("logs.SetLogger failed: %v", err)
 		return err
@@ -1,6 +1,17 @@
 package main
 
+import "os"
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(ValidateCommand(os.Args[2:]))
+		case "history":
+			os.Exit(HistoryCommand(os.Args[2:]))
+		}
+	}
+
 	FileInit()
 	ConfigInit()
 	IconInit()
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+type MethodAudit struct {
+	Time     time.Time
+	User     string
+	Client   string
+	Method   string
+	Inputs   string
+	Outputs  string
+	Status   uint32
+	Duration time.Duration
+}
+
+func NodeValueListString(values []*NodeValue) string {
+	items := make([]string, 0)
+	for _, value := range values {
+		if value == nil {
+			items = append(items, "null")
+		} else {
+			items = append(items, value.ToString())
+		}
+	}
+	return "[" + strings.Join(items, "; ") + "]"
+}
+
+func (m ServerMethodInfo) String() string {
+	return fmt.Sprintf("%s <= %s: %s / %s", m.ServerName, m.ClientName, m.ObjectNode.ToString(), m.MethodNode.ToString())
+}
+
+func (a MethodArgument) String() string {
+	return fmt.Sprintf("%s (ns=0;i=%d, rank %d) %s", a.Name, a.DataType, a.ValueRank, a.Description)
+}
+
+func (opc *OpcuaServer) methodAudit(audit MethodAudit) {
+	user := audit.User
+	if user == "" {
+		user = "anonymous"
+	}
+	logs.Info("method call audit, user: %s, method: %s:%s, inputs: %s, outputs: %s, status: 0x%x, duration: %s",
+		user, audit.Client, audit.Method, audit.Inputs, audit.Outputs, audit.Status, audit.Duration)
+
+	// the audit is called in the server loop, it must not wait for the datastore
+	if opc.dbSink != nil {
+		queue := opc.dbSink.Queue("method", QueueConfig{Size: opc.dbQueue.Size, Policy: QUEUE_DROP})
+		queue.Push(opc.workers.Context(), audit)
+	}
+}
+
+// methodCall is the MethodHandler of the proxy server, it forwards the call to the source method
+func (opc *OpcuaServer) methodCall(user string, namespace string, nodeID string, inputs []*NodeValue) ([]*NodeValue, uint32) {
+	opc.RLock()
+	method, ok := opc.methodCache[historyKey(namespace, nodeID)]
+	cli, connected := opc.clients[method.ClientName]
+	opc.RUnlock()
+	if !ok {
+		return nil, UA_STATUS_BADMETHODINVALID
+	}
+
+	audit := MethodAudit{
+		Time:   time.Now(),
+		User:   user,
+		Client: method.ClientName,
+		Method: method.ServerName,
+		Inputs: NodeValueListString(inputs),
+	}
+
+	var outputs []*NodeValue
+	if !opc.server.Permission(user, namespace, nodeID).Write {
+		opc.server.Denied(user, namespace, nodeID, "call")
+		audit.Status = UA_STATUS_BADUSERACCESSDENIED
+	} else if !connected {
+		audit.Status = UA_STATUS_BADNOTCONNECTED
+	} else {
+		outputs, audit.Status = cli.CallMethod(method.ObjectNode, method.MethodNode, inputs)
+	}
+
+	audit.Outputs = NodeValueListString(outputs)
+	audit.Duration = time.Since(audit.Time)
+	opc.methodAudit(audit)
+
+	return outputs, audit.Status
+}
+
+func ServerMethodDialog(from walk.Form, clients []ClientConfig, config *ServerConfig) {
+	var dlg *walk.Dialog
+	var methodBox *walk.ListBox
+	var clientBox *walk.ComboBox
+	var objectNs, methodNs *walk.NumberEdit
+	var objectLine, methodLine, nameLine *walk.LineEdit
+	var argumentEdit *walk.TextEdit
+	var readPB, acceptPB, cancelPB *walk.PushButton
+
+	methods := make([]ServerMethodInfo, len(config.Methods))
+	copy(methods, config.Methods)
+
+	clientNames := make([]string, 0)
+	for _, client := range clients {
+		clientNames = append(clientNames, client.Name)
+	}
+
+	methodModel := func() []string {
+		items := make([]string, 0)
+		for _, method := range methods {
+			items = append(items, method.String())
+		}
+		return items
+	}
+
+	methodForm := func() (ServerMethodInfo, error) {
+		method := ServerMethodInfo{
+			ClientName: clientBox.Text(),
+			ObjectNode: NodeInfo{NsIndex: uint32(objectNs.Value()), NodeID: strings.TrimSpace(objectLine.Text())},
+			MethodNode: NodeInfo{NsIndex: uint32(methodNs.Value()), NodeID: strings.TrimSpace(methodLine.Text())},
+			ServerName: strings.TrimSpace(nameLine.Text()),
+		}
+		if method.ClientName == "" {
+			return method, fmt.Errorf("the client must be selected")
+		}
+		if method.ObjectNode.NodeID == "" || method.MethodNode.NodeID == "" {
+			return method, fmt.Errorf("the object and method node id cannot be empty")
+		}
+		if method.ServerName == "" {
+			return method, fmt.Errorf("the server method name cannot be empty")
+		}
+		return method, nil
+	}
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Server Method Settings",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 650, Height: 500},
+		Size:          Size{Width: 650, Height: 500},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Label{
+				Text: "Method List:",
+			},
+			ListBox{
+				AssignTo: &methodBox,
+				Model:    methodModel(),
+				OnCurrentIndexChanged: func() {
+					index := methodBox.CurrentIndex()
+					if index < 0 || index >= len(methods) {
+						return
+					}
+					method := methods[index]
+					for i, name := range clientNames {
+						if name == method.ClientName {
+							clientBox.SetCurrentIndex(i)
+						}
+					}
+					objectNs.SetValue(float64(method.ObjectNode.NsIndex))
+					objectLine.SetText(method.ObjectNode.NodeID)
+					methodNs.SetValue(float64(method.MethodNode.NsIndex))
+					methodLine.SetText(method.MethodNode.NodeID)
+					nameLine.SetText(method.ServerName)
+				},
+			},
+			Composite{
+				Layout: Grid{Columns: 3},
+				Children: []Widget{
+					Label{
+						Text: "Client:",
+					},
+					ComboBox{
+						AssignTo:   &clientBox,
+						Model:      clientNames,
+						ColumnSpan: 2,
+					},
+					Label{
+						Text: "Object Node:",
+					},
+					NumberEdit{
+						AssignTo:    &objectNs,
+						ToolTipText: "namespace index",
+						MaxValue:    65535,
+						MinValue:    0,
+					},
+					LineEdit{
+						AssignTo: &objectLine,
+					},
+					Label{
+						Text: "Method Node:",
+					},
+					NumberEdit{
+						AssignTo:    &methodNs,
+						ToolTipText: "namespace index",
+						MaxValue:    65535,
+						MinValue:    0,
+					},
+					LineEdit{
+						AssignTo: &methodLine,
+					},
+					Label{
+						Text: "Server Method Name:",
+					},
+					LineEdit{
+						AssignTo:   &nameLine,
+						ColumnSpan: 2,
+					},
+				},
+			},
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					PushButton{
+						AssignTo: &readPB,
+						Text:     "Read Arguments",
+						OnClicked: func() {
+							method, err := methodForm()
+							if err != nil {
+								ErrorBoxAction(dlg, err.Error())
+								return
+							}
+
+							readPB.SetEnabled(false)
+							defer readPB.SetEnabled(true)
+
+							var endpoint string
+							for _, client := range clients {
+								if client.Name == method.ClientName {
+									endpoint = client.Endpoint
+								}
+							}
+							cli, err := NewClient(endpoint)
+							if err != nil {
+								ErrorBoxAction(dlg, "OPCUA connection failed:"+err.Error())
+								return
+							}
+							defer cli.Close()
+
+							inputs, outputs, err := cli.ReadMethodArguments(method.MethodNode)
+							if err != nil {
+								ErrorBoxAction(dlg, "Read method arguments failed:"+err.Error())
+								return
+							}
+
+							lines := []string{"Input Arguments:"}
+							for _, arg := range inputs {
+								lines = append(lines, "  "+arg.String())
+							}
+							lines = append(lines, "Output Arguments:")
+							for _, arg := range outputs {
+								lines = append(lines, "  "+arg.String())
+							}
+							argumentEdit.SetText(strings.Join(lines, "\r\n"))
+						},
+					},
+					PushButton{
+						Text: "Add",
+						OnClicked: func() {
+							method, err := methodForm()
+							if err != nil {
+								ErrorBoxAction(dlg, err.Error())
+								return
+							}
+							for _, item := range methods {
+								if item.ServerName == method.ServerName && item.ClientName == method.ClientName {
+									ErrorBoxAction(dlg, "The server method name already exist!")
+									return
+								}
+							}
+							for _, node := range config.NodeList {
+								if node.ServerName == method.ServerName && node.ClientName == method.ClientName {
+									ErrorBoxAction(dlg, "The server method name is used by a server node!")
+									return
+								}
+							}
+							methods = append(methods, method)
+							methodBox.SetModel(methodModel())
+						},
+					},
+					PushButton{
+						Text: "Delete",
+						OnClicked: func() {
+							index := methodBox.CurrentIndex()
+							if index < 0 || index >= len(methods) {
+								return
+							}
+							methods = append(methods[:index], methods[index+1:]...)
+							methodBox.SetModel(methodModel())
+						},
+					},
+					HSpacer{},
+				},
+			},
+			TextEdit{
+				AssignTo: &argumentEdit,
+				ReadOnly: true,
+				VScroll:  true,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							config.Methods = methods
+							dlg.Accept()
+							logs.Info("server method dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("server method dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("ServerMethodDialog: %s", err.Error())
+	}
+}
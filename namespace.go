@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const UA_NAMESPACE_ARRAY = "i=2255"
+
+// namespaceInit reads the NamespaceArray of the server after connect, the caller holds the client lock
+func (c *Client) namespaceInit() {
+	c.missing = make(map[string]bool)
+
+	value, err := c.readAttribute(UA_NAMESPACE_ARRAY, UA_ATTRIBUTEID_VALUE)
+	if err != nil {
+		logs.Warning("ua client %s read namespace array failed, %s", c.addr, err.Error())
+		return
+	}
+	uris, ok := value.Value.([]string)
+	if !ok {
+		logs.Warning("ua client %s namespace array type %d invalid", c.addr, value.Type)
+		return
+	}
+
+	if len(c.namespaces) > 0 {
+		for i, uri := range c.namespaces {
+			if i >= len(uris) || uris[i] != uri {
+				logs.Warning("ua client %s namespace table changed, %v -> %v", c.addr, c.namespaces, uris)
+				break
+			}
+		}
+	}
+	c.namespaces = uris
+	logs.Info("ua client %s namespaces %v", c.addr, uris)
+}
+
+func (c *Client) Namespaces() []string {
+	c.Lock()
+	defer c.Unlock()
+
+	return append([]string{}, c.namespaces...)
+}
+
+func (c *Client) namespaceUri(index uint32) string {
+	if int(index) < len(c.namespaces) {
+		return c.namespaces[index]
+	}
+	return ""
+}
+
+func (c *Client) NamespaceUri(index uint32) string {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.namespaceUri(index)
+}
+
+// NamespaceIndex returns the index of the namespace uri in the server namespace table
+func (c *Client) NamespaceIndex(uri string) (uint32, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.namespaceIndex(uri)
+}
+
+func (c *Client) namespaceIndex(uri string) (uint32, bool) {
+	for i, item := range c.namespaces {
+		if item == uri {
+			return uint32(i), true
+		}
+	}
+	return 0, false
+}
+
+// resolve sets the namespace index of the node by its namespace uri, the nodes without
+// uri keep the configured index. the caller holds the client lock
+func (c *Client) resolve(node NodeInfo) (NodeInfo, error) {
+	if node.NsUri == "" || len(c.namespaces) == 0 {
+		return node, nil
+	}
+
+	index, ok := c.namespaceIndex(node.NsUri)
+	if !ok {
+		if !c.missing[node.NsUri] {
+			c.missing[node.NsUri] = true
+			logs.Error("ua client %s namespace %s is missing in the server namespace table", c.addr, node.NsUri)
+		}
+		return node, fmt.Errorf("ua client node %s namespace %s is missing", node.ToString(), node.NsUri)
+	}
+
+	if index != node.NsIndex {
+		logs.Debug("ua client %s node %s namespace %s moved to index %d", c.addr, node.ToString(), node.NsUri, index)
+		node.NsIndex = index
+	}
+	return node, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -13,8 +14,9 @@ import (
 type NodeItem struct {
 	Index int
 
-	node  NodeInfo
-	value string
+	node   NodeInfo
+	value  string
+	status string
 
 	checked bool
 }
@@ -25,7 +27,8 @@ type NodeTable struct {
 	sortColumn int
 	sortOrder  walk.SortOrder
 
-	items []*NodeItem
+	client string
+	items  []*NodeItem
 }
 
 func (n *NodeTable) RowCount() int {
@@ -38,13 +41,27 @@ func (n *NodeTable) Value(row, col int) interface{} {
 	case 0:
 		return item.Index
 	case 1:
+		if item.node.Alias != "" {
+			return item.node.ToString() + " (" + item.node.Alias + ")"
+		}
 		return item.node.ToString()
 	case 2:
 		return item.value
+	case 3:
+		return n.Status(item)
 	}
 	panic("unexpected col")
 }
 
+// Status is the read status of the running client, or the status of the last manual read
+func (m *NodeTable) Status(item *NodeItem) string {
+	status, ok := nodeStatus.Get(m.client, item.node)
+	if ok {
+		return status.String()
+	}
+	return item.status
+}
+
 func (n *NodeTable) Checked(row int) bool {
 	return n.items[row].checked
 }
@@ -71,6 +88,8 @@ func (m *NodeTable) Sort(col int, order walk.SortOrder) error {
 			return c(a.node.ToString() < b.node.ToString())
 		case 2:
 			return c(a.value < b.value)
+		case 3:
+			return c(m.Status(a) < m.Status(b))
 		}
 		panic("unreachable")
 	})
@@ -93,6 +112,7 @@ func (m *NodeTable) Save(config *ClientConfig) {
 func (m *NodeTable) Init(config ClientConfig) {
 	defer m.Review()
 
+	m.client = config.Name
 	items := make([]*NodeItem, 0)
 	for index, node := range config.NodeList {
 		items = append(items, &NodeItem{
@@ -182,7 +202,8 @@ func (m *NodeTable) Order(index int, order OrderType, config *ClientConfig) {
 	m.Review()
 }
 
-func (m *NodeTable) ReadValue(config ClientConfig) error {
+// ReadValue reads the node values, and fills the namespace uri of the nodes configured without it
+func (m *NodeTable) ReadValue(config *ClientConfig) error {
 	client, err := NewClient(config.Endpoint)
 	if err != nil {
 		logs.Error("node table value read failed, %s", err.Error())
@@ -191,22 +212,40 @@ func (m *NodeTable) ReadValue(config ClientConfig) error {
 	defer client.Close()
 
 	for _, item := range m.items {
+		if item.node.NsUri == "" {
+			item.node.NsUri = client.NamespaceUri(item.node.NsIndex)
+		}
 		node := item.node
 		value, err := client.ReadNode(node)
 		if err != nil {
-			logs.Error("node table %d:%s value read failed, %s", node.ToString(), err.Error())
+			logs.Error("node table %s value read failed, %s", node.ToString(), err.Error())
+			item.value = ""
+			item.status = err.Error()
 			continue
 		}
 		item.value = value.ToString()
+		item.status = StatusCodeName(UA_STATUS_GOOD)
 	}
+	m.Save(config)
 	m.Review()
 
 	return nil
 }
 
+// Recheck puts the selected quarantined nodes back to the read cycle of the running client
+func (m *NodeTable) Recheck() {
+	defer m.Review()
+
+	for _, item := range m.items {
+		if item.checked {
+			nodeStatus.Release(m.client, item.node)
+		}
+	}
+}
+
 func (m *NodeTable) Query(node NodeInfo) bool {
 	for _, item := range m.items {
-		if item.node.NodeID == node.NodeID && item.node.NsIndex == node.NsIndex {
+		if item.node.Compare(node) {
 			return true
 		}
 	}
@@ -223,24 +262,56 @@ func (m *NodeTable) Append(nodes []NodeInfo, values []string, config *ClientConf
 		}
 		m.items = append(m.items, &NodeItem{
 			Index: len(m.items),
-			node:  NodeInfo{NsIndex: node.NsIndex, NodeID: node.NodeID},
+			node:  node,
+			value: values[i],
+		})
+	}
+}
+
+// Merge updates the settings of the existing nodes and appends the new nodes
+func (m *NodeTable) Merge(nodes []NodeInfo, values []string, config *ClientConfig) {
+	defer m.Save(config)
+	defer m.Review()
+
+	for i, node := range nodes {
+		exist := false
+		for _, item := range m.items {
+			if item.node.Compare(node) {
+				item.node = node
+				item.value = values[i]
+				exist = true
+				break
+			}
+		}
+		if exist {
+			continue
+		}
+		m.items = append(m.items, &NodeItem{
+			Index: len(m.items),
+			node:  node,
 			value: values[i],
 		})
 	}
 }
 
 type NodeTreeItem struct {
-	nsIndex uint32
-	nodeID  string
+	item BrowseItem
 
+	model    *NodeTreeModel
 	parent   *NodeTreeItem
 	children []*NodeTreeItem
+	loaded   bool
+	cycle    bool
 }
 
 var _ walk.TreeItem = new(NodeTreeItem)
 
 func (d *NodeTreeItem) Text() string {
-	return d.nodeID
+	text := fmt.Sprintf("%s [%s]", d.item.Name(), d.item.ClassName())
+	if d.cycle {
+		text += " (cycle)"
+	}
+	return text
 }
 
 func (d *NodeTreeItem) Parent() walk.TreeItem {
@@ -250,7 +321,15 @@ func (d *NodeTreeItem) Parent() walk.TreeItem {
 	return d.parent
 }
 
+func (d *NodeTreeItem) HasChild() bool {
+	if d.cycle {
+		return false
+	}
+	return !d.loaded || len(d.children) > 0
+}
+
 func (d *NodeTreeItem) ChildCount() int {
+	d.load()
 	return len(d.children)
 }
 
@@ -267,27 +346,63 @@ func (d *NodeTreeItem) ResetChildren() error {
 }
 
 func (d *NodeTreeItem) Path() string {
-	elems := []string{d.nodeID}
+	elems := []string{d.item.BrowseName}
 
 	dir, _ := d.Parent().(*NodeTreeItem)
 
 	for dir != nil {
-		elems = append([]string{dir.nodeID}, elems...)
+		elems = append([]string{dir.item.BrowseName}, elems...)
 		dir, _ = dir.Parent().(*NodeTreeItem)
 	}
 
 	return filepath.Join(elems...)
 }
 
-func (d *NodeTreeItem) Export(filter string) []*NodeTreeItem {
+// load browses the children on the first expand, the child already on the path to the
+// root is a reference cycle and is not browsed again
+func (d *NodeTreeItem) load() {
+	if d.loaded || d.cycle {
+		return
+	}
+	d.loaded = true
+
+	items, err := d.model.client.Browse(d.item.NodeID)
+	if err != nil {
+		logs.Error("node tree browse %s failed, %s", d.item.NodeID, err.Error())
+		return
+	}
+	d.children = d.model.items(items, d)
+}
+
+func (d *NodeTreeItem) ancestor(nodeID string) bool {
+	for dir := d; dir != nil; dir = dir.parent {
+		if dir.item.NodeID == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *NodeTreeItem) Export(filter string, level, levelLimit int, visited map[string]bool) []*NodeTreeItem {
 	output := make([]*NodeTreeItem, 0)
+	if d.cycle || visited[d.item.NodeID] {
+		return output
+	}
+	visited[d.item.NodeID] = true
 
-	if strings.Contains(d.nodeID, filter) {
+	if d.item.NodeClass == UA_NODECLASS_VARIABLE &&
+		(strings.Contains(d.item.Node.NodeID, filter) || strings.Contains(d.item.DisplayName, filter)) {
 		output = append(output, d)
 	}
 
+	if level >= levelLimit {
+		logs.Info("node tree export %s level %d >= %d", d.item.NodeID, level, levelLimit)
+		return output
+	}
+
+	d.load()
 	for _, child := range d.children {
-		output = append(output, child.Export(filter)...)
+		output = append(output, child.Export(filter, level+1, levelLimit, visited)...)
 	}
 
 	return output
@@ -295,7 +410,8 @@ func (d *NodeTreeItem) Export(filter string) []*NodeTreeItem {
 
 type NodeTreeModel struct {
 	walk.TreeModelBase
-	roots []*NodeTreeItem
+	client *Client
+	roots  []*NodeTreeItem
 }
 
 var _ walk.TreeModel = new(NodeTreeModel)
@@ -312,40 +428,51 @@ func (m *NodeTreeModel) RootAt(index int) walk.TreeItem {
 	return m.roots[index]
 }
 
+func (m *NodeTreeModel) items(items []BrowseItem, parent *NodeTreeItem) []*NodeTreeItem {
+	children := make([]*NodeTreeItem, 0, len(items))
+	for _, item := range items {
+		children = append(children, &NodeTreeItem{
+			item:     item,
+			model:    m,
+			parent:   parent,
+			children: make([]*NodeTreeItem, 0),
+			cycle:    parent != nil && parent.ancestor(item.NodeID),
+		})
+	}
+	return children
+}
+
+func (m *NodeTreeModel) Close() {
+	if m.client != nil {
+		m.client.Close()
+		m.client = nil
+	}
+}
+
 var nodeTableView *walk.TableView
 var nodeTreeView *walk.TreeView
 var currentNodeTreeItem *NodeTreeItem
 
-func NodeTreeItemInit(node *NodeTree, parent *NodeTreeItem, filter string, level, levelLimit int) *NodeTreeItem {
-	item := &NodeTreeItem{
-		nsIndex:  node.Node.NsIndex,
-		nodeID:   node.Node.NodeID,
-		parent:   parent,
-		children: make([]*NodeTreeItem, 0),
-	}
-
-	if level >= levelLimit {
-		logs.Info("node tree init search level %d >= %d", level, levelLimit)
-		return item
+// NodeTreeInit shows the top level of the address space, the lower levels are browsed
+// on expand with the client kept by the model
+func NodeTreeInit(client *Client) error {
+	items, err := client.Browse(BROWSE_ROOT_NODE)
+	if err != nil {
+		return err
 	}
 
-	for _, child := range node.SubNodes {
-		item.children = append(item.children, NodeTreeItemInit(child, item, filter, level+1, levelLimit))
-	}
-	return item
-}
+	nodeTree := &NodeTreeModel{client: client}
+	nodeTree.roots = nodeTree.items(items, nil)
 
-func NodeTreeInit(nodes []*NodeTree, filter string, levelLimit int) {
-	nodeTree := new(NodeTreeModel)
-	nodeTree.roots = make([]*NodeTreeItem, 0)
-	for _, node := range nodes {
-		nodeTree.roots = append(nodeTree.roots, NodeTreeItemInit(node, nil, filter, 1, levelLimit))
+	if model, ok := nodeTreeView.Model().(*NodeTreeModel); ok {
+		model.Close()
 	}
 	nodeTreeView.SetModel(nodeTree)
 	currentNodeTreeItem = nil
+	return nil
 }
 
-func NodeAddBatch(nodeTable *NodeTable, config *ClientConfig, roots []*NodeTreeItem, filter string) error {
+func NodeAddBatch(nodeTable *NodeTable, config *ClientConfig, roots []*NodeTreeItem, filter string, levelLimit int) error {
 	client, err := NewClient(config.Endpoint)
 	if err != nil {
 		logs.Error("export node tree for %s create client failed, %s", config.Name, err.Error())
@@ -354,15 +481,16 @@ func NodeAddBatch(nodeTable *NodeTable, config *ClientConfig, roots []*NodeTreeI
 	defer client.Close()
 
 	nodesExport := make([]*NodeTreeItem, 0)
+	visited := make(map[string]bool)
 	for _, root := range roots {
-		nodesExport = append(nodesExport, root.Export(filter)...)
+		nodesExport = append(nodesExport, root.Export(filter, 1, levelLimit, visited)...)
 	}
 
 	nodes := make([]NodeInfo, 0)
 	values := make([]string, 0)
 
 	for _, node := range nodesExport {
-		nodeInfo := NodeInfo{NsIndex: node.nsIndex, NodeID: node.nodeID}
+		nodeInfo := node.item.Node
 		value, err := client.ReadNode(nodeInfo)
 		if err != nil {
 			logs.Error("export node tree for %s read node %s failed, %s", config.Name, nodeInfo.ToString(), err.Error())
@@ -384,7 +512,7 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 	var endpoint, filterKey *walk.LineEdit
 	var loadTreePB, addNodePB, addAllNodePB *walk.PushButton
 	var deleteAllPB, deletePB, readValuePB *walk.PushButton
-	var timeout, levelNumber *walk.NumberEdit
+	var timeout, levelNumber, backfill, pipeline *walk.NumberEdit
 	var enable, store, selectBox *walk.CheckBox
 	var nodeTable NodeTable
 
@@ -459,6 +587,33 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 							client.Store = store.Checked()
 						},
 					},
+					// line: 3
+					Label{
+						Text: "Backfill limit (hours):",
+					},
+					NumberEdit{
+						AssignTo:    &backfill,
+						Value:       float64(client.Backfill),
+						ToolTipText: fmt.Sprintf("0~%d hours, 0 disables backfill from the source history", BACKFILL_MAX_HOURS),
+						MaxValue:    BACKFILL_MAX_HOURS,
+						MinValue:    0,
+						OnValueChanged: func() {
+							client.Backfill = int(backfill.Value())
+						},
+					},
+					Label{
+						Text: "Read pipeline (requests):",
+					},
+					NumberEdit{
+						AssignTo:    &pipeline,
+						Value:       float64(client.Pipeline),
+						ToolTipText: fmt.Sprintf("0~%d chunk requests in flight, 0 or 1 sends the chunks one by one", CLIENT_PIPELINE_MAX),
+						MaxValue:    CLIENT_PIPELINE_MAX,
+						MinValue:    0,
+						OnValueChanged: func() {
+							client.Pipeline = int(pipeline.Value())
+						},
+					},
 				},
 			},
 			HSplitter{
@@ -499,15 +654,12 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 													ErrorBoxAction(dlg, "OPCUA connection failed:"+err.Error())
 													return
 												}
-												defer cli.Close()
 
-												nodeTree, err := cli.BrowseNode()
+												err = NodeTreeInit(cli)
 												if err != nil {
+													cli.Close()
 													ErrorBoxAction(dlg, "Failed to load node:"+err.Error())
-													return
 												}
-
-												NodeTreeInit(nodeTree, filterKey.Text(), int(levelNumber.Value()))
 											}()
 										},
 									},
@@ -532,7 +684,7 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 												return
 											}
 
-											err := NodeAddBatch(&nodeTable, &client, []*NodeTreeItem{currentNodeTreeItem}, "")
+											err := NodeAddBatch(&nodeTable, &client, []*NodeTreeItem{currentNodeTreeItem}, "", int(levelNumber.Value()))
 											if err != nil {
 												ErrorBoxAction(dlg, "Error adding node, the reason is as follows:"+err.Error())
 											}
@@ -548,7 +700,7 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 												return
 											}
 
-											err := NodeAddBatch(&nodeTable, &client, nodeTree.roots, "")
+											err := NodeAddBatch(&nodeTable, &client, nodeTree.roots, "", int(levelNumber.Value()))
 											if err != nil {
 												ErrorBoxAction(dlg, "Error adding node, the reason is as follows:"+err.Error())
 											}
@@ -578,7 +730,7 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 												ErrorBoxAction(dlg, "No nodes loaded")
 												return
 											}
-											err := NodeAddBatch(&nodeTable, &client, nodeTree.roots, filterKey.Text())
+											err := NodeAddBatch(&nodeTable, &client, nodeTree.roots, filterKey.Text(), int(levelNumber.Value()))
 											if err != nil {
 												ErrorBoxAction(dlg, "Error adding node, the reason is as follows:"+err.Error())
 											}
@@ -605,6 +757,7 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 									{Title: "#", Width: 60},
 									{Title: "Node Tag", Width: 300},
 									{Title: "Node Data", Width: 200},
+									{Title: "Read Status", Width: 200},
 								},
 								StyleCell: func(style *walk.CellStyle) {
 									if style.Row()%2 == 0 {
@@ -670,6 +823,12 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 									},
 									HSpacer{},
 
+									PushButton{
+										Text: "Recheck",
+										OnClicked: func() {
+											nodeTable.Recheck()
+										},
+									},
 									PushButton{
 										AssignTo: &readValuePB,
 										Text:     "Read Datas",
@@ -678,7 +837,7 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 												readValuePB.SetEnabled(false)
 												defer readValuePB.SetEnabled(true)
 
-												err := nodeTable.ReadValue(client)
+												err := nodeTable.ReadValue(&client)
 												if err != nil {
 													ErrorBoxAction(dlg, "Failed to read the data, following reasons:"+err.Error())
 												}
@@ -695,6 +854,88 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 			Composite{
 				Layout: HBox{MarginsZero: true},
 				Children: []Widget{
+					PushButton{
+						Text: "Alarm Settings",
+						OnClicked: func() {
+							var node NodeInfo
+							index := nodeTableView.CurrentIndex()
+							if index >= 0 && index < len(nodeTable.items) {
+								node = nodeTable.items[index].node
+							}
+							AlarmEditDialog(dlg, &client, node)
+						},
+					},
+					PushButton{
+						Text: "Index Range",
+						OnClicked: func() {
+							index := nodeTableView.CurrentIndex()
+							if index < 0 || index >= len(nodeTable.items) {
+								ErrorBoxAction(dlg, "Please select an array node in the subscribe node list")
+								return
+							}
+							IndexRangeDialog(dlg, &nodeTable, &client, index)
+						},
+					},
+					PushButton{
+						Text: "Node Settings",
+						OnClicked: func() {
+							index := nodeTableView.CurrentIndex()
+							if index < 0 || index >= len(nodeTable.items) {
+								ErrorBoxAction(dlg, "Please select a node in the subscribe node list")
+								return
+							}
+							NodeSettingsDialog(dlg, &nodeTable, &client, index)
+						},
+					},
+					PushButton{
+						Text: "Structure Fields",
+						OnClicked: func() {
+							index := nodeTableView.CurrentIndex()
+							if index < 0 || index >= len(nodeTable.items) {
+								ErrorBoxAction(dlg, "Please select a structure node in the subscribe node list")
+								return
+							}
+							StructFieldDialog(dlg, &nodeTable, &client, nodeTable.items[index].node)
+						},
+					},
+					PushButton{
+						Text: "Event Settings",
+						OnClicked: func() {
+							EventEditDialog(dlg, &client.Events)
+						},
+					},
+					PushButton{
+						Text: "Import Nodes",
+						OnClicked: func() {
+							path, err := TableFileDialogOpen(dlg, "Import the node list")
+							if err != nil || path == "" {
+								return
+							}
+							client.Endpoint = endpoint.Text()
+							nodes, values, report, err := NodeListImport(path, client)
+							if err != nil {
+								ErrorBoxAction(dlg, "Import node list failed: "+err.Error())
+								return
+							}
+							nodeTable.Merge(nodes, values, &client)
+							ImportReportDialog(dlg, report)
+						},
+					},
+					PushButton{
+						Text: "Export Nodes",
+						OnClicked: func() {
+							path, err := TableFileDialogSave(dlg, "Export the node list")
+							if err != nil || path == "" {
+								return
+							}
+							err = NodeListExport(path, client.NodeList)
+							if err != nil {
+								ErrorBoxAction(dlg, "Export node list failed: "+err.Error())
+								return
+							}
+							InfoBoxAction(dlg, fmt.Sprintf("Export %d nodes to %s", len(client.NodeList), path))
+						},
+					},
 					HSpacer{},
 					PushButton{
 						Text: "Accept",
@@ -722,7 +963,306 @@ func ClientNodeEditDialog(from walk.Form, clientItem *ClientItem, config *Config
 		},
 	}.Run(from)
 
+	if nodeTreeView != nil {
+		if model, ok := nodeTreeView.Model().(*NodeTreeModel); ok {
+			model.Close()
+		}
+	}
+
 	if err != nil {
 		logs.Error("ClientNodeEditDialog: %s", err.Error())
 	}
 }
+
+func StructFieldDialog(from walk.Form, nodeTable *NodeTable, config *ClientConfig, node NodeInfo) {
+	var dlg *walk.Dialog
+	var fieldBox *walk.ListBox
+	var acceptPB, cancelPB *walk.PushButton
+
+	node.Field = ""
+
+	client, err := NewClient(config.Endpoint)
+	if err != nil {
+		ErrorBoxAction(from, "Failed to connect the client, following reasons:"+err.Error())
+		return
+	}
+	value, err := client.ReadNode(node)
+	client.Close()
+	if err != nil {
+		ErrorBoxAction(from, "Failed to read the node, following reasons:"+err.Error())
+		return
+	}
+
+	fields := StructFieldPaths(value.Value)
+	if len(fields) == 0 {
+		ErrorBoxAction(from, "The node value "+node.ToString()+" has no decoded structure fields")
+		return
+	}
+
+	_, err = Dialog{
+		AssignTo:      &dlg,
+		Title:         "Structure Fields: " + node.ToString(),
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 500, Height: 400},
+		Size:          Size{Width: 500, Height: 400},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Label{
+				Text: "Select the fields to subscribe as separate tags:",
+			},
+			ListBox{
+				AssignTo:       &fieldBox,
+				Model:          fields,
+				MultiSelection: true,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							nodes := make([]NodeInfo, 0)
+							values := make([]string, 0)
+							for _, index := range fieldBox.SelectedIndexes() {
+								field := NodeInfo{NsIndex: node.NsIndex, NsUri: node.NsUri, NodeID: node.NodeID, IdType: node.IdType, Field: fields[index]}
+								fieldValue, err := StructFieldGet(value.Value, field.Field)
+								if err != nil {
+									continue
+								}
+								text := ""
+								if nodeValue, err := StructNodeValue(fieldValue); err == nil {
+									text = nodeValue.ToString()
+								}
+								nodes = append(nodes, field)
+								values = append(values, text)
+							}
+							if len(nodes) == 0 {
+								ErrorBoxAction(dlg, "No structure field is selected!")
+								return
+							}
+							nodeTable.Append(nodes, values, config)
+
+							dlg.Accept()
+							logs.Info("structure field dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("structure field dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("StructFieldDialog: %s", err.Error())
+	}
+}
+
+// IndexRangeDialog sets the index range of the array node, like "0:9" or "0:1,2:5" for the matrix
+func IndexRangeDialog(from walk.Form, nodeTable *NodeTable, config *ClientConfig, index int) {
+	var dlg *walk.Dialog
+	var rangeLine *walk.LineEdit
+	var acceptPB, cancelPB *walk.PushButton
+
+	item := nodeTable.items[index]
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Index Range: " + item.node.ToString(),
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 400, Height: 150},
+		Size:          Size{Width: 400, Height: 150},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Label{
+				Text: "Index Range (e.g. 0:9 or 0:1,2:5, empty reads the whole array):",
+			},
+			LineEdit{
+				AssignTo: &rangeLine,
+				Text:     item.node.Range,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							text := strings.ReplaceAll(rangeLine.Text(), " ", "")
+							if err := CheckIndexRange(text); err != nil {
+								ErrorBoxAction(dlg, err.Error())
+								return
+							}
+							node := item.node
+							node.Range = text
+							if !node.Compare(item.node) && nodeTable.Query(node) {
+								ErrorBoxAction(dlg, "The node "+node.ToString()+" already exist!")
+								return
+							}
+							item.node = node
+							item.value = ""
+							nodeTable.Save(config)
+							nodeTable.Review()
+
+							dlg.Accept()
+							logs.Info("index range dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("index range dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("IndexRangeDialog: %s", err.Error())
+	}
+}
+
+func NodeSettingsDialog(from walk.Form, nodeTable *NodeTable, config *ClientConfig, index int) {
+	var dlg *walk.Dialog
+	var aliasLine *walk.LineEdit
+	var sampling, scale, offset *walk.NumberEdit
+	var storeCB *walk.CheckBox
+	var acceptPB, cancelPB *walk.PushButton
+
+	item := nodeTable.items[index]
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Node Settings: " + item.node.ToString(),
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 400, Height: 250},
+		Size:          Size{Width: 400, Height: 250},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Composite{
+				Layout: Grid{Columns: 2},
+				Children: []Widget{
+					Label{
+						Text: "Alias:",
+					},
+					LineEdit{
+						AssignTo:    &aliasLine,
+						Text:        item.node.Alias,
+						ToolTipText: "The storage column and proxy browse name, empty uses the node id",
+					},
+					Label{
+						Text: "Sampling:",
+					},
+					NumberEdit{
+						AssignTo:    &sampling,
+						Value:       float64(item.node.Sampling),
+						ToolTipText: "0~3600000 ms, 0 reads the node every collection cycle",
+						MinValue:    0,
+						MaxValue:    3600000,
+					},
+					Label{
+						Text: "Scale:",
+					},
+					NumberEdit{
+						AssignTo:    &scale,
+						Value:       item.node.Scale,
+						Decimals:    6,
+						ToolTipText: "value * scale + offset, 0 is no scaling",
+						MinValue:    -1e15,
+						MaxValue:    1e15,
+					},
+					Label{
+						Text: "Offset:",
+					},
+					NumberEdit{
+						AssignTo: &offset,
+						Value:    item.node.Offset,
+						Decimals: 6,
+						MinValue: -1e15,
+						MaxValue: 1e15,
+					},
+					Label{
+						Text: "Data Store:",
+					},
+					CheckBox{
+						AssignTo: &storeCB,
+						Checked:  !item.node.NoStore,
+					},
+				},
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							node := item.node
+							node.Alias = strings.TrimSpace(aliasLine.Text())
+							if err := AliasCheck(node.Alias); err != nil {
+								ErrorBoxAction(dlg, err.Error())
+								return
+							}
+							if other, ok := AliasConflict(config.NodeList, node); ok {
+								ErrorBoxAction(dlg, "The name "+node.Name()+" is used by the node "+other.ToString())
+								return
+							}
+							node.Sampling = int(sampling.Value())
+							node.Scale = scale.Value()
+							node.Offset = offset.Value()
+							node.NoStore = !storeCB.Checked()
+
+							if node.Alias != item.node.Alias {
+								logs.Info("node %s alias change from %s to %s", node.ToString(), item.node.Name(), node.Name())
+							}
+							item.node = node
+							nodeTable.Save(config)
+							nodeTable.Review()
+
+							dlg.Accept()
+							logs.Info("node settings dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("node settings dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("NodeSettingsDialog: %s", err.Error())
+	}
+}
@@ -0,0 +1,604 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+type IssueSeverity string
+
+const (
+	SEVERITY_ERROR   IssueSeverity = "error"
+	SEVERITY_WARNING IssueSeverity = "warning"
+)
+
+var nodeFileHeader = []string{"Namespace", "NamespaceUri", "NodeId", "Range", "Field", "Alias", "Sampling", "Scale", "Offset", "Store"}
+
+var serverFileHeader = []string{"Client", "Namespace", "NamespaceUri", "NodeId", "Range", "Field", "ServerName"}
+
+// ImportIssue is a problem of the import file, the row 0 is the whole file
+type ImportIssue struct {
+	Row      int
+	Column   string
+	Severity IssueSeverity
+	Message  string
+}
+
+func (i ImportIssue) String() string {
+	if i.Row == 0 {
+		return fmt.Sprintf("[%s] %s", i.Severity, i.Message)
+	}
+	if i.Column == "" {
+		return fmt.Sprintf("row %d [%s] %s", i.Row, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("row %d %s [%s] %s", i.Row, i.Column, i.Severity, i.Message)
+}
+
+type ImportReport struct {
+	File     string
+	Rows     int
+	Imported int
+	Issues   []ImportIssue
+}
+
+func (r *ImportReport) add(row int, column string, severity IssueSeverity, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ImportIssue{
+		Row:      row,
+		Column:   column,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *ImportReport) Summary() string {
+	errors, warnings := 0, 0
+	for _, issue := range r.Issues {
+		if issue.Severity == SEVERITY_ERROR {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	return fmt.Sprintf("%s: %d rows, %d imported, %d errors, %d warnings",
+		filepath.Base(r.File), r.Rows, r.Imported, errors, warnings)
+}
+
+// tableFile maps the header names to the columns, the names are case insensitive
+type tableFile struct {
+	rows    [][]string
+	columns map[string]int
+}
+
+func tableFileOpen(path string, required ...string) (*tableFile, error) {
+	rows, err := TableFileRead(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("file %s is empty", filepath.Base(path))
+	}
+
+	file := &tableFile{rows: rows, columns: make(map[string]int)}
+	for i, name := range rows[0] {
+		file.columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range required {
+		if _, ok := file.columns[strings.ToLower(name)]; !ok {
+			return nil, fmt.Errorf("file %s has no %s column in the header row", filepath.Base(path), name)
+		}
+	}
+	return file, nil
+}
+
+func (f *tableFile) cell(row []string, name string) string {
+	index, ok := f.columns[strings.ToLower(name)]
+	if !ok || index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
+}
+
+func (f *tableFile) empty(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeFileID writes the identifier with its type, s=Tag1 or i=1001
+func nodeFileID(node NodeInfo) string {
+	return node.Type() + "=" + node.NodeID
+}
+
+// parseFileNode parses the namespace, node id, range and field cells of the row
+func (f *tableFile) parseFileNode(report *ImportReport, line int, row []string) (NodeInfo, bool) {
+	var node NodeInfo
+
+	text := f.cell(row, "NodeId")
+	if text == "" {
+		report.add(line, "NodeId", SEVERITY_ERROR, "node id is empty")
+		return node, false
+	}
+	parsed, err := NodeInfoParse(text)
+	if err != nil {
+		// the cell without the identifier type is a string identifier
+		parsed = NodeInfo{NodeID: text}
+	}
+	node.NodeID = parsed.NodeID
+	node.IdType = parsed.IdType
+
+	if ns := f.cell(row, "Namespace"); ns != "" {
+		index, err := strconv.ParseUint(ns, 10, 16)
+		if err != nil {
+			report.add(line, "Namespace", SEVERITY_ERROR, "namespace %s invalid", ns)
+			return node, false
+		}
+		if strings.HasPrefix(text, "ns=") && uint32(index) != parsed.NsIndex {
+			report.add(line, "Namespace", SEVERITY_ERROR, "namespace %d differs from the node id %s", index, text)
+			return node, false
+		}
+		node.NsIndex = uint32(index)
+	} else {
+		node.NsIndex = parsed.NsIndex
+	}
+
+	node.NsUri = f.cell(row, "NamespaceUri")
+
+	node.Range = f.cell(row, "Range")
+	if err := CheckIndexRange(node.Range); err != nil {
+		report.add(line, "Range", SEVERITY_ERROR, "%s", err.Error())
+		return node, false
+	}
+	node.Field = f.cell(row, "Field")
+	return node, true
+}
+
+func parseFileBool(text string) (bool, error) {
+	switch strings.ToLower(text) {
+	case "", "1", "true", "yes", "y", "x":
+		return true, nil
+	case "0", "false", "no", "n":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s is not a boolean", text)
+}
+
+func parseFileSettings(f *tableFile, report *ImportReport, line int, row []string, node *NodeInfo) bool {
+	node.Alias = f.cell(row, "Alias")
+	if err := AliasCheck(node.Alias); err != nil {
+		report.add(line, "Alias", SEVERITY_ERROR, "%s", err.Error())
+		return false
+	}
+
+	if text := f.cell(row, "Sampling"); text != "" {
+		sampling, err := strconv.Atoi(text)
+		if err != nil || sampling < 0 {
+			report.add(line, "Sampling", SEVERITY_ERROR, "sampling %s is not a milliseconds number", text)
+			return false
+		}
+		node.Sampling = sampling
+	}
+
+	for _, item := range []struct {
+		name  string
+		value *float64
+	}{{"Scale", &node.Scale}, {"Offset", &node.Offset}} {
+		text := f.cell(row, item.name)
+		if text == "" {
+			continue
+		}
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			report.add(line, item.name, SEVERITY_ERROR, "%s %s is not a number", strings.ToLower(item.name), text)
+			return false
+		}
+		*item.value = number
+	}
+
+	store, err := parseFileBool(f.cell(row, "Store"))
+	if err != nil {
+		report.add(line, "Store", SEVERITY_ERROR, "%s", err.Error())
+		return false
+	}
+	node.NoStore = !store
+	return true
+}
+
+// resolveFileNodes sets the namespace index of the nodes with uri and the uri of the others
+// from the server namespace table, it returns false for the nodes with a missing namespace
+func resolveFileNodes(client *Client, report *ImportReport, lines []int, nodes []NodeInfo) []bool {
+	resolved := make([]bool, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		if node.NsUri == "" {
+			node.NsUri = client.NamespaceUri(node.NsIndex)
+			if node.NsUri == "" {
+				report.add(lines[i], "Namespace", SEVERITY_ERROR, "namespace %d is not in the server namespace table", node.NsIndex)
+				continue
+			}
+			resolved[i] = true
+			continue
+		}
+		index, ok := client.NamespaceIndex(node.NsUri)
+		if !ok {
+			report.add(lines[i], "NamespaceUri", SEVERITY_ERROR, "namespace %s is not in the server namespace table", node.NsUri)
+			continue
+		}
+		if index != node.NsIndex {
+			report.add(lines[i], "Namespace", SEVERITY_WARNING, "namespace %s is index %d on the server, not %d", node.NsUri, index, node.NsIndex)
+			node.NsIndex = index
+		}
+		resolved[i] = true
+	}
+	return resolved
+}
+
+func NodeListExport(path string, nodes []NodeInfo) error {
+	rows := [][]string{nodeFileHeader}
+	for _, node := range nodes {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", node.NsIndex),
+			node.NsUri,
+			nodeFileID(node),
+			node.Range,
+			node.Field,
+			node.Alias,
+			fmt.Sprintf("%d", node.Sampling),
+			strconv.FormatFloat(node.Scale, 'g', -1, 64),
+			strconv.FormatFloat(node.Offset, 'g', -1, 64),
+			strconv.FormatBool(!node.NoStore),
+		})
+	}
+	return TableFileWrite(path, rows)
+}
+
+// NodeListImport parses the node file and reads every node from the client endpoint,
+// it returns the nodes without errors and their values
+func NodeListImport(path string, config ClientConfig) ([]NodeInfo, []string, *ImportReport, error) {
+	file, err := tableFileOpen(path, "NodeId")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	report := &ImportReport{File: path}
+
+	nodes := make([]NodeInfo, 0)
+	lines := make([]int, 0)
+	aliases := make(map[string]int)
+
+	for i, row := range file.rows[1:] {
+		line := i + 2
+		if file.empty(row) {
+			continue
+		}
+		report.Rows++
+
+		node, ok := file.parseFileNode(report, line, row)
+		if !ok || !parseFileSettings(file, report, line, row, &node) {
+			continue
+		}
+
+		duplicate := false
+		for j, item := range nodes {
+			if item.Compare(node) {
+				report.add(line, "NodeId", SEVERITY_ERROR, "node %s duplicates row %d", node.ToString(), lines[j])
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		if node.Alias != "" {
+			if prev, ok := aliases[strings.ToLower(node.Alias)]; ok {
+				report.add(line, "Alias", SEVERITY_ERROR, "alias %s duplicates row %d", node.Alias, prev)
+				continue
+			}
+			aliases[strings.ToLower(node.Alias)] = line
+		}
+		if item, ok := AliasConflict(config.NodeList, node); ok {
+			report.add(line, "Alias", SEVERITY_ERROR, "name %s is used by the node %s", node.Name(), item.ToString())
+			continue
+		}
+		for _, item := range config.NodeList {
+			if item.Compare(node) {
+				report.add(line, "NodeId", SEVERITY_WARNING, "node %s exists, the settings are updated", node.ToString())
+				break
+			}
+		}
+
+		nodes = append(nodes, node)
+		lines = append(lines, line)
+	}
+
+	values := make([]string, len(nodes))
+	if len(nodes) == 0 {
+		return nodes, values, report, nil
+	}
+
+	client, err := NewClient(config.Endpoint)
+	if err != nil {
+		report.add(0, "", SEVERITY_WARNING, "the nodes are not validated, connect %s failed, %s", config.Endpoint, err.Error())
+		report.Imported = len(nodes)
+		return nodes, values, report, nil
+	}
+	defer client.Close()
+
+	resolved := resolveFileNodes(client, report, lines, nodes)
+
+	read, status, err := client.ReadNodesStatus(nodes)
+	if err != nil {
+		report.add(0, "", SEVERITY_WARNING, "the nodes are not validated, read failed, %s", err.Error())
+		report.Imported = len(nodes)
+		return nodes, values, report, nil
+	}
+
+	output := make([]NodeInfo, 0, len(nodes))
+	outputValues := make([]string, 0, len(nodes))
+	for i, node := range nodes {
+		if !resolved[i] {
+			continue
+		}
+		if status[i]&UA_STATUS_SEVERITY_BAD != 0 {
+			report.add(lines[i], "NodeId", SEVERITY_ERROR, "read node %s failed, %s", node.ToString(), StatusCodeString(status[i]))
+			continue
+		}
+		output = append(output, node)
+		outputValues = append(outputValues, node.Scaled(read[i]).ToString())
+	}
+	report.Imported = len(output)
+
+	logs.Info("import node file %s for %s, %s", path, config.Name, report.Summary())
+	return output, outputValues, report, nil
+}
+
+func ServerNodeExport(path string, nodes []ServerNodeInfo) error {
+	rows := [][]string{serverFileHeader}
+	for _, node := range nodes {
+		rows = append(rows, []string{
+			node.ClientName,
+			fmt.Sprintf("%d", node.ClientNode.NsIndex),
+			node.ClientNode.NsUri,
+			nodeFileID(node.ClientNode),
+			node.ClientNode.Range,
+			node.ClientNode.Field,
+			node.ServerName,
+		})
+	}
+	return TableFileWrite(path, rows)
+}
+
+// ServerNodeImport parses the server mapping file, the client nodes are read from their
+// client endpoints
+func ServerNodeImport(path string, clients []ClientConfig, server ServerConfig) ([]ServerNodeInfo, *ImportReport, error) {
+	file, err := tableFileOpen(path, "Client", "NodeId")
+	if err != nil {
+		return nil, nil, err
+	}
+	report := &ImportReport{File: path}
+
+	clientConfig := func(name string) (ClientConfig, bool) {
+		for _, client := range clients {
+			if client.Name == name {
+				return client, true
+			}
+		}
+		return ClientConfig{}, false
+	}
+
+	items := make([]ServerNodeInfo, 0)
+	lines := make([]int, 0)
+	names := make(map[string]int)
+
+	for i, row := range file.rows[1:] {
+		line := i + 2
+		if file.empty(row) {
+			continue
+		}
+		report.Rows++
+
+		name := file.cell(row, "Client")
+		client, ok := clientConfig(name)
+		if !ok {
+			report.add(line, "Client", SEVERITY_ERROR, "client %s does not exist", name)
+			continue
+		}
+
+		node, ok := file.parseFileNode(report, line, row)
+		if !ok {
+			continue
+		}
+
+		inList := false
+		for _, item := range client.NodeList {
+			if item.Compare(node) {
+				node = item
+				inList = true
+				break
+			}
+		}
+		if !inList {
+			report.add(line, "NodeId", SEVERITY_WARNING, "node %s is not in the client %s node list, its value is not updated", node.ToString(), name)
+		}
+
+		serverName := file.cell(row, "ServerName")
+		if serverName == "" {
+			serverName = fmt.Sprintf("%s.%s", name, node.Name())
+		}
+		if prev, ok := names[serverName]; ok {
+			report.add(line, "ServerName", SEVERITY_ERROR, "server name %s duplicates row %d", serverName, prev)
+			continue
+		}
+		names[serverName] = line
+
+		for _, item := range server.NodeList {
+			if item.ServerName == serverName {
+				report.add(line, "ServerName", SEVERITY_WARNING, "server name %s exists, the mapping is replaced", serverName)
+				break
+			}
+		}
+
+		items = append(items, ServerNodeInfo{
+			ClientName:     name,
+			ClientEndpoint: client.Endpoint,
+			ClientNode:     node,
+			ServerName:     serverName,
+			ServerNode:     NodeInfo{NsIndex: node.NsIndex, NodeID: serverName},
+		})
+		lines = append(lines, line)
+	}
+
+	// read the nodes of every client once
+	failed := make(map[int]bool)
+	for _, client := range clients {
+		indexes := make([]int, 0)
+		nodes := make([]NodeInfo, 0)
+		nodeLines := make([]int, 0)
+		for i, item := range items {
+			if item.ClientName == client.Name {
+				indexes = append(indexes, i)
+				nodes = append(nodes, item.ClientNode)
+				nodeLines = append(nodeLines, lines[i])
+			}
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+
+		cli, err := NewClient(client.Endpoint)
+		if err != nil {
+			report.add(0, "", SEVERITY_WARNING, "the client %s nodes are not validated, connect %s failed, %s", client.Name, client.Endpoint, err.Error())
+			continue
+		}
+		resolved := resolveFileNodes(cli, report, nodeLines, nodes)
+		_, status, err := cli.ReadNodesStatus(nodes)
+		cli.Close()
+		if err != nil {
+			report.add(0, "", SEVERITY_WARNING, "the client %s nodes are not validated, read failed, %s", client.Name, err.Error())
+			continue
+		}
+		for i, index := range indexes {
+			items[index].ClientNode = nodes[i]
+			if !resolved[i] {
+				failed[index] = true
+				continue
+			}
+			if status[i]&UA_STATUS_SEVERITY_BAD != 0 {
+				report.add(lines[index], "NodeId", SEVERITY_ERROR, "read node %s failed, %s", nodes[i].ToString(), StatusCodeString(status[i]))
+				failed[index] = true
+			}
+		}
+	}
+
+	output := make([]ServerNodeInfo, 0, len(items))
+	for i, item := range items {
+		if !failed[i] {
+			output = append(output, item)
+		}
+	}
+	report.Imported = len(output)
+
+	logs.Info("import server node file %s, %s", path, report.Summary())
+	return output, report, nil
+}
+
+func TableFileDialogOpen(from walk.Form, title string) (string, error) {
+	dlg := new(walk.FileDialog)
+
+	dlg.Filter = "Node list (*.csv;*.xlsx)|*.csv;*.xlsx"
+	dlg.Title = title
+
+	if ok, err := dlg.ShowOpen(from); err != nil {
+		return "", err
+	} else if !ok {
+		return "", nil
+	}
+
+	logs.Info("node file dialog open %s", dlg.FilePath)
+
+	return dlg.FilePath, nil
+}
+
+func TableFileDialogSave(from walk.Form, title string) (string, error) {
+	dlg := new(walk.FileDialog)
+
+	dlg.Filter = "CSV (*.csv)|*.csv|Excel (*.xlsx)|*.xlsx"
+	dlg.Title = title
+
+	if ok, err := dlg.ShowSave(from); err != nil {
+		return "", err
+	} else if !ok {
+		return "", nil
+	}
+
+	path := dlg.FilePath
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".csv" && ext != ".xlsx" {
+		if dlg.FilterIndex == 2 {
+			path += ".xlsx"
+		} else {
+			path += ".csv"
+		}
+	}
+	logs.Info("node file dialog save %s", path)
+
+	return path, nil
+}
+
+func ImportReportDialog(from walk.Form, report *ImportReport) {
+	var dlg *walk.Dialog
+	var acceptPB *walk.PushButton
+
+	issues := make([]string, 0)
+	for _, issue := range report.Issues {
+		issues = append(issues, issue.String())
+	}
+	if len(issues) == 0 {
+		issues = append(issues, "no problems found")
+	}
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Import Report",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 600, Height: 400},
+		Size:          Size{Width: 600, Height: 400},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &acceptPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Label{
+				Text: report.Summary(),
+			},
+			ListBox{
+				Model: issues,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "OK",
+						OnClicked: func() {
+							dlg.Accept()
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("ImportReportDialog: %s", err.Error())
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const (
+	NODE_QUARANTINE_FAILURES = 5
+	NODE_QUARANTINE_RECHECK  = time.Minute
+)
+
+type NodeStatus struct {
+	Status      uint32
+	Failures    int
+	LastError   string
+	LastGood    time.Time
+	Quarantined bool
+	Recheck     time.Time
+}
+
+func (s NodeStatus) String() string {
+	if s.Failures == 0 {
+		return StatusCodeName(s.Status)
+	}
+	if s.Quarantined {
+		return fmt.Sprintf("Quarantined, %s (%d failures, recheck %s)", s.LastError, s.Failures, s.Recheck.Format("15:04:05"))
+	}
+	return fmt.Sprintf("%s (%d failures)", s.LastError, s.Failures)
+}
+
+// NodeStatusTable tracks the read status of the client nodes, the nodes keep failing are
+// quarantined and only read again at the recheck time
+type NodeStatusTable struct {
+	sync.RWMutex
+	nodes map[string]*NodeStatus
+}
+
+var nodeStatus = NewNodeStatusTable()
+
+func NewNodeStatusTable() *NodeStatusTable {
+	return &NodeStatusTable{nodes: make(map[string]*NodeStatus)}
+}
+
+func NodeStatusKey(client string, node NodeInfo) string {
+	return fmt.Sprintf("%s|%d|%s", client, node.NsIndex, node.Key())
+}
+
+// Active returns the indexes of the nodes to read in this cycle
+func (t *NodeStatusTable) Active(client string, nodes []NodeInfo, now time.Time) []int {
+	t.RLock()
+	defer t.RUnlock()
+
+	active := make([]int, 0, len(nodes))
+	for i, node := range nodes {
+		status, ok := t.nodes[NodeStatusKey(client, node)]
+		if ok && status.Quarantined && now.Before(status.Recheck) {
+			continue
+		}
+		active = append(active, i)
+	}
+	return active
+}
+
+// Update records the read status of the node, it returns true when the node is good
+func (t *NodeStatusTable) Update(client string, node NodeInfo, code uint32, now time.Time) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	key := NodeStatusKey(client, node)
+	status, ok := t.nodes[key]
+	if !ok {
+		status = &NodeStatus{}
+		t.nodes[key] = status
+	}
+	status.Status = code
+
+	if code&UA_STATUS_SEVERITY_BAD == 0 {
+		if status.Quarantined {
+			logs.Info("opcua client %s node %s recovered from quarantine", client, node.ToString())
+		}
+		status.Failures = 0
+		status.LastError = ""
+		status.LastGood = now
+		status.Quarantined = false
+		return true
+	}
+
+	status.Failures++
+	status.LastError = StatusCodeString(code)
+	if status.Quarantined {
+		status.Recheck = now.Add(NODE_QUARANTINE_RECHECK)
+		logs.Debug("opcua client %s node %s still failing, %s", client, node.ToString(), status.LastError)
+	} else if status.Failures >= NODE_QUARANTINE_FAILURES {
+		status.Quarantined = true
+		status.Recheck = now.Add(NODE_QUARANTINE_RECHECK)
+		logs.Warning("opcua client %s node %s quarantined after %d failures, %s", client, node.ToString(), status.Failures, status.LastError)
+	} else {
+		logs.Warning("opcua client %s node %s read failed, %s", client, node.ToString(), status.LastError)
+	}
+	return false
+}
+
+func (t *NodeStatusTable) Get(client string, node NodeInfo) (NodeStatus, bool) {
+	t.RLock()
+	defer t.RUnlock()
+
+	status, ok := t.nodes[NodeStatusKey(client, node)]
+	if !ok {
+		return NodeStatus{}, false
+	}
+	return *status, true
+}
+
+// Release puts the quarantined node back to the read cycle at once
+func (t *NodeStatusTable) Release(client string, node NodeInfo) {
+	t.Lock()
+	defer t.Unlock()
+
+	status, ok := t.nodes[NodeStatusKey(client, node)]
+	if ok && status.Quarantined {
+		status.Recheck = time.Time{}
+	}
+}
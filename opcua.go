@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -35,8 +37,9 @@ type OpcuaClientData struct {
 }
 
 type OpcuaStoreData struct {
-	table  string
-	values []string
+	table     string
+	values    []string
+	timestamp time.Time
 }
 
 type OpcuaBasket struct {
@@ -46,27 +49,56 @@ type OpcuaBasket struct {
 
 type OpcuaServer struct {
 	sync.RWMutex
-	sync.WaitGroup
 
-	cfg      Config
-	shutdown bool
+	cfg    Config
+	reload sync.Mutex
+	// the clients whose reload failed are retried by the next reload
+	reloadFailed map[string]bool
 
-	db     *DataSave
-	dbChan chan interface{}
+	// the client workers stop before the sink workers drain their queues
+	workers *Supervisor
+	sinks   *Supervisor
+
+	db      *DataSave
+	dbSink  *Sink
+	dbQueue QueueConfig
 
 	basketCache map[string]OpcuaBasket
 
 	server      *Server
-	serverChan  chan interface{}
-	serverCache map[string]NodeInfo
+	serverSink  *Sink
+	serverQueue QueueConfig
+	serverCache map[string][]NodeInfo
+	alarmCache  map[string]map[string]NodeInfo
+	history     *HistoryStore
+	methodCache map[string]ServerMethodInfo
+	eventCache  map[string]NodeInfo
+
+	alarms *AlarmEngine
 
 	clients map[string]*Client
 	stats   map[string]*StatItem
 }
 
-func (opc *OpcuaServer) dataStoreTask() {
-	defer opc.Done()
+// dbPush queues the data of the producer to the datastore, it returns false when the data is dropped
+func (opc *OpcuaServer) dbPush(ctx context.Context, name string, data interface{}) bool {
+	if opc.dbSink == nil {
+		return false
+	}
+	queue := opc.dbSink.Queue(name, opc.dbQueue)
+	return queue.Push(ctx, data)
+}
+
+// serverPush queues the data of the producer to the proxy server, the latest values matter by default
+func (opc *OpcuaServer) serverPush(ctx context.Context, name string, data interface{}) bool {
+	if opc.serverSink == nil {
+		return false
+	}
+	queue := opc.serverSink.Queue(name, opc.serverQueue)
+	return queue.Push(ctx, data)
+}
 
+func (opc *OpcuaServer) dataStoreTask(ctx context.Context) {
 	logs.Info("data store task startup")
 
 	stat := opc.stats[STAT_MYSQL]
@@ -78,15 +110,19 @@ func (opc *OpcuaServer) dataStoreTask() {
 	}
 
 	for {
-		data := <-opc.dbChan
-
-		if _, ok := data.(bool); ok {
+		data, ok := opc.dbSink.Pop(ctx)
+		if !ok {
 			break
 		}
 
 		storeData, ok := data.(OpcuaStoreData)
 		if ok {
-			err := opc.db.TableWrite(storeData.table, storeData.values)
+			var err error
+			if storeData.timestamp.IsZero() {
+				err = opc.db.TableWrite(storeData.table, storeData.values)
+			} else {
+				err = opc.db.TableWriteAt(storeData.table, storeData.timestamp, storeData.values)
+			}
 			if err != nil {
 				logs.Warning("data store table write %s failed, %s", storeData.table, err.Error())
 				atomic.AddUint64(&stat.OperFail, 1)
@@ -94,6 +130,28 @@ func (opc *OpcuaServer) dataStoreTask() {
 				atomic.AddUint64(&stat.OperOK, 1)
 			}
 		}
+
+		alarmEvent, ok := data.(AlarmEvent)
+		if ok {
+			err := opc.db.AlarmWrite(alarmEvent)
+			if err != nil {
+				logs.Warning("data store alarm write %s failed, %s", alarmEvent.Alarm.Name, err.Error())
+				atomic.AddUint64(&stat.OperFail, 1)
+			} else {
+				atomic.AddUint64(&stat.OperOK, 1)
+			}
+		}
+
+		audit, ok := data.(MethodAudit)
+		if ok {
+			err := opc.db.MethodAuditWrite(audit)
+			if err != nil {
+				logs.Warning("data store method audit write %s failed, %s", audit.Method, err.Error())
+				atomic.AddUint64(&stat.OperFail, 1)
+			} else {
+				atomic.AddUint64(&stat.OperOK, 1)
+			}
+		}
 	}
 
 	err = opc.db.TableExpired(false)
@@ -105,18 +163,27 @@ func (opc *OpcuaServer) dataStoreTask() {
 	logs.Info("data store task shutdown")
 }
 
-func (opc *OpcuaServer) serverTask() {
-	defer opc.Done()
-
+func (opc *OpcuaServer) serverTask(ctx context.Context) {
 	logs.Info("server sync data task startup")
 
 	for {
-		data := <-opc.serverChan
-
-		if _, ok := data.(bool); ok {
+		data, ok := opc.serverSink.Pop(ctx)
+		if !ok {
 			break
 		}
 
+		alarmEvent, ok := data.(AlarmEvent)
+		if ok {
+			opc.serverAlarmWrite(alarmEvent)
+			continue
+		}
+
+		clientEvent, ok := data.(OpcuaClientEvent)
+		if ok {
+			opc.serverEventWrite(clientEvent)
+			continue
+		}
+
 		nodeData, ok := data.(OpcuaClientData)
 		if !ok {
 			continue
@@ -124,15 +191,15 @@ func (opc *OpcuaServer) serverTask() {
 
 		success := true
 		for index, node := range nodeData.nodes {
-			serverName := fmt.Sprintf("%s.%s", nodeData.name, node.NodeID)
-			serverNode, b := opc.serverCache[serverName]
-			if !b {
-				continue
-			}
-			err := opc.server.WriteNode(serverNode, *nodeData.values[index])
-			if err != nil {
-				success = false
-				logs.Error("server write node %s failed, %s", serverNode.ToString(), err.Error())
+			opc.RLock()
+			serverNodes := opc.serverCache[historyKey(nodeData.name, node.Key())]
+			opc.RUnlock()
+			for _, serverNode := range serverNodes {
+				err := opc.server.WriteNode(serverNode, *nodeData.values[index])
+				if err != nil {
+					success = false
+					logs.Error("server write node %s failed, %s", serverNode.ToString(), err.Error())
+				}
 			}
 		}
 
@@ -146,11 +213,27 @@ func (opc *OpcuaServer) serverTask() {
 	logs.Info("server sync data task shutdown")
 }
 
-func (opc *OpcuaServer) ReadNodesRetry(cli *Client, name string, nodes []NodeInfo) []*NodeValue {
+func (opc *OpcuaServer) serverAlarmWrite(event AlarmEvent) {
+	opc.RLock()
+	serverNode, ok := opc.alarmCache[event.Client][event.Alarm.Name]
+	opc.RUnlock()
+	if !ok {
+		return
+	}
+	err := opc.server.WriteNode(serverNode, NodeValue{Type: UA_BOOLEAN, Value: event.Active})
+	if err != nil {
+		logs.Error("server write alarm node %s failed, %s", serverNode.ToString(), err.Error())
+		atomic.AddUint64(&opc.stats[STAT_SERVER].OperFail, 1)
+	} else {
+		atomic.AddUint64(&opc.stats[STAT_SERVER].OperOK, 1)
+	}
+}
+
+func (opc *OpcuaServer) ReadNodesRetry(cli *Client, name string, nodes []NodeInfo) ([]*NodeValue, []uint32) {
 	for i := 0; i < 10; i++ {
-		nodeValues, err := cli.ReadNodes(nodes)
+		nodeValues, status, err := cli.ReadNodesStatus(nodes)
 		if err == nil {
-			return nodeValues
+			return nodeValues, status
 		}
 		logs.Error("opcua client %s read nodes failed, %s", name, err.Error())
 
@@ -159,66 +242,230 @@ func (opc *OpcuaServer) ReadNodesRetry(cli *Client, name string, nodes []NodeInf
 		}
 	}
 
-	return nil
+	return nil, nil
 }
 
-func (opc *OpcuaServer) clientTask(cli *Client, name string) {
-	defer opc.Done()
-
+func (opc *OpcuaServer) clientTask(ctx context.Context, cli *Client, name string) {
 	logs.Info("opcua client %s startup", name)
 
-	cfg := opc.cfg.ClientConfig(name)
+	cfg := opc.clientConfig(name)
 	stat := opc.stats[STAT_CLIENT]
 
+	cli.SetPipeline(cfg.Pipeline)
+
 	nodeList := make([]NodeInfo, 0)
-	for _, node := range cfg.NodeList {
-		nodeList = append(nodeList, NodeInfo{
-			NsIndex: node.NsIndex,
-			NodeID:  node.NodeID,
-		})
+	storeIndex := make([]int, 0)
+	storeNodes := make([]NodeInfo, 0)
+	for i, node := range cfg.NodeList {
+		nodeList = append(nodeList, node)
+		if !node.NoStore {
+			storeIndex = append(storeIndex, i)
+			storeNodes = append(storeNodes, node)
+		}
 	}
 	tableName := EscapeString(cfg.Name)
 
+	backfill := cfg.Store && cfg.Backfill > 0 && opc.db != nil
+
+	var lastStored time.Time
+	if backfill {
+		var err error
+		lastStored, err = opc.db.TableLast(tableName)
+		if err != nil {
+			logs.Warning("opcua client %s read last stored time failed, %s", name, err.Error())
+		} else {
+			opc.clientBackfill(ctx, cli, cfg, storeNodes, lastStored)
+		}
+	}
+	disconnected := false
+
+	// the next read time of the nodes with their own sampling interval
+	nextRead := make([]time.Time, len(nodeList))
+
+	// the zero time means subscribed, otherwise it is the next subscribe time
+	eventNext := time.Now()
+
+	timer := time.NewTimer(time.Duration(cfg.Timeout) * time.Millisecond)
+	defer timer.Stop()
+
 	for {
-		time.Sleep(time.Duration(cfg.Timeout) * time.Millisecond)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			logs.Info("opcua client %s shutdown", cfg.Name)
+			return
+		}
+		timer.Reset(time.Duration(cfg.Timeout) * time.Millisecond)
 
-		if opc.shutdown {
-			break
+		if cfg.Events.Enable {
+			eventNext = opc.clientEvents(ctx, cli, cfg, eventNext)
 		}
 
 		if len(nodeList) == 0 {
 			continue
 		}
 
-		nodeValues := opc.ReadNodesRetry(cli, name, nodeList)
-		if nodeValues == nil {
+		// the quarantined nodes are skipped until the recheck time
+		active := nodeStatus.Active(name, nodeList, time.Now())
+		active = sampleDue(nodeList, active, nextRead, time.Now())
+		if len(active) == 0 {
+			continue
+		}
+		activeNodes := make([]NodeInfo, 0, len(active))
+		for _, index := range active {
+			activeNodes = append(activeNodes, nodeList[index])
+		}
+
+		values, status := opc.ReadNodesRetry(cli, name, activeNodes)
+		if values == nil {
+			disconnected = true
+			eventNext = time.Now()
 			atomic.AddUint64(&stat.OperFail, 1)
 			continue
 		}
 
+		now := time.Now()
+		nodeValues := make([]*NodeValue, len(nodeList))
+		for i := range nodeValues {
+			nodeValues[i] = NewEmptyNodeValue()
+		}
+		goodNodes := make([]NodeInfo, 0, len(active))
+		goodValues := make([]*NodeValue, 0, len(active))
+		for i, index := range active {
+			values[i] = nodeList[index].Scaled(values[i])
+			nodeValues[index] = values[i]
+			if nodeStatus.Update(name, nodeList[index], status[i], now) {
+				goodNodes = append(goodNodes, nodeList[index])
+				goodValues = append(goodValues, values[i])
+			}
+		}
+
+		if disconnected && backfill {
+			opc.clientBackfill(ctx, cli, cfg, storeNodes, lastStored)
+		}
+		disconnected = false
+
+		opc.alarms.Evaluate(name, goodNodes, goodValues)
+
 		if cfg.Store && opc.db != nil {
 			strList := make([]string, 0)
-			for _, value := range nodeValues {
-				strList = append(strList, value.ToString())
+			for _, index := range storeIndex {
+				strList = append(strList, nodeValues[index].StoreString())
 			}
-			opc.dbChan <- OpcuaStoreData{
-				table:  tableName,
-				values: strList,
+			if backfill {
+				strList = append(strList, "")
+			}
+			if opc.dbPush(ctx, name, OpcuaStoreData{table: tableName, values: strList}) {
+				lastStored = time.Now()
+				atomic.AddUint64(&stat.OperOK, 1)
+			} else {
+				atomic.AddUint64(&stat.OperFail, 1)
 			}
-			atomic.AddUint64(&stat.OperOK, 1)
 		}
 
 		if opc.server != nil {
-			opc.serverChan <- OpcuaClientData{
-				name:   name,
-				nodes:  nodeList,
-				values: nodeValues,
+			if opc.serverPush(ctx, name, OpcuaClientData{name: name, nodes: goodNodes, values: goodValues}) {
+				atomic.AddUint64(&stat.OperOK, 1)
+			} else {
+				atomic.AddUint64(&stat.OperFail, 1)
 			}
-			atomic.AddUint64(&stat.OperOK, 1)
 		}
 	}
+}
 
-	logs.Info("opcua client %s shutdown", cfg.Name)
+// sampleDue keeps the active nodes whose sampling interval is due, and sets their next read time
+func sampleDue(nodeList []NodeInfo, active []int, nextRead []time.Time, now time.Time) []int {
+	due := make([]int, 0, len(active))
+	for _, index := range active {
+		node := nodeList[index]
+		if node.Sampling <= 0 {
+			due = append(due, index)
+			continue
+		}
+		if now.Before(nextRead[index]) {
+			continue
+		}
+		nextRead[index] = now.Add(time.Duration(node.Sampling) * time.Millisecond)
+		due = append(due, index)
+	}
+	return due
+}
+
+// clientBackfill reads the source history from the last stored time, and stores the rows with the backfilled marker
+func (opc *OpcuaServer) clientBackfill(ctx context.Context, cli *Client, cfg ClientConfig, nodeList []NodeInfo, lastStored time.Time) {
+	end := time.Now()
+	start := lastStored.Add(time.Second)
+
+	if lastStored.IsZero() || end.Sub(start) < 2*time.Duration(cfg.Timeout)*time.Millisecond {
+		return
+	}
+
+	limit := end.Add(-time.Duration(cfg.Backfill) * time.Hour)
+	if start.Before(limit) {
+		logs.Warning("opcua client %s backfill is limited to %d hours, the data before %s is lost",
+			cfg.Name, cfg.Backfill, limit.Format(HISTORY_TIME_FORMAT))
+		start = limit
+	}
+
+	logs.Info("opcua client %s backfill from %s to %s", cfg.Name,
+		start.Format(HISTORY_TIME_FORMAT), end.Format(HISTORY_TIME_FORMAT))
+
+	rows := make(map[int64][]string)
+	for i, node := range nodeList {
+		values, err := cli.HistoryReadRaw(node, start, end, BACKFILL_MAX_VALUES)
+		if err == ErrHistoryNotSupported {
+			logs.Warning("opcua client %s backfill skipped, %s", cfg.Name, err.Error())
+			return
+		}
+		if err != nil {
+			logs.Warning("opcua client %s backfill node %s failed, %s", cfg.Name, node.ToString(), err.Error())
+		}
+
+		for _, value := range values {
+			if value.Value == nil || value.Status != UA_STATUS_GOOD {
+				continue
+			}
+			if value.Time.Before(start) || value.Time.After(end) {
+				continue
+			}
+			second := value.Time.Unix()
+			row, ok := rows[second]
+			if !ok {
+				row = make([]string, len(nodeList)+1)
+				row[len(nodeList)] = "1"
+				rows[second] = row
+			}
+			row[i] = node.Scaled(value.Value).StoreString()
+		}
+	}
+
+	seconds := make([]int64, 0)
+	for second := range rows {
+		seconds = append(seconds, second)
+	}
+	sort.Slice(seconds, func(i, j int) bool { return seconds[i] < seconds[j] })
+
+	for _, second := range seconds {
+		opc.dbPush(ctx, cfg.Name, OpcuaStoreData{
+			table:     EscapeString(cfg.Name),
+			values:    rows[second],
+			timestamp: time.Unix(second, 0),
+		})
+	}
+
+	logs.Info("opcua client %s backfill %d rows", cfg.Name, len(seconds))
+}
+
+func ServerNodeMeta(cli *Client, node ServerNodeInfo) NodeMeta {
+	if node.MetaOverride {
+		return node.Meta
+	}
+	meta, err := cli.ReadNodeMeta(node.ClientNode)
+	if err != nil {
+		logs.Warning("opcua client read node %s meta failed, %s", node.ClientNode.ToString(), err.Error())
+		return NodeMeta{DisplayName: node.ServerName}
+	}
+	return *meta
 }
 
 func (opc *OpcuaServer) serverInit(cli *Client, name string) error {
@@ -228,6 +475,15 @@ func (opc *OpcuaServer) serverInit(cli *Client, name string) error {
 		return err
 	}
 
+	var builder *HierarchyBuilder
+	if opc.cfg.Server.Hierarchy.Enable {
+		builder, err = NewHierarchyBuilder(opc.server, cli, opc.cfg.Server.Hierarchy, NodeInfo{NsIndex: uint32(index), NodeID: name})
+		if err != nil {
+			logs.Error("opcua server hierarchy %s init failed, %s", name, err.Error())
+			return err
+		}
+	}
+
 	for _, node := range opc.cfg.Server.NodeList {
 		if node.ClientName != name {
 			continue
@@ -238,44 +494,178 @@ func (opc *OpcuaServer) serverInit(cli *Client, name string) error {
 			logs.Error("opcua server read node %s failed, %s", node.ClientNode.ToString(), err.Error())
 			return err
 		}
+		value = opc.cfg.ClientConfig(name).Settings(node.ClientNode).Scaled(value)
 
 		clientNode := NodeInfo{NsIndex: uint32(index), NodeID: node.ClientName}
 		serverNode := NodeInfo{NsIndex: uint32(index), NodeID: node.ServerName}
 
-		err = opc.server.AddNode(clientNode, serverNode, node.ServerName, *value)
+		browseName := node.ServerName
+		if builder != nil {
+			clientNode, browseName, err = builder.Place(node)
+			if err != nil {
+				logs.Error("opcua server place node %s failed, %s", serverNode.ToString(), err.Error())
+				return err
+			}
+			if browseName == "" {
+				continue
+			}
+		}
+
+		err = opc.server.AddNodeMeta(clientNode, serverNode, browseName, *value, ServerNodeMeta(cli, node))
 		if err != nil {
 			logs.Error("opcua server add node %s failed, %s", serverNode.ToString(), err.Error())
 			return err
 		}
 
-		opc.serverCache[node.ServerName] = serverNode
+		// the server names follow the aliases, the client data finds the server nodes by the client node
+		cacheKey := historyKey(name, node.ClientNode.Key())
+		opc.Lock()
+		opc.serverCache[cacheKey] = append(opc.serverCache[cacheKey], serverNode)
+		opc.Unlock()
+
+		if opc.history != nil {
+			opc.historyAdd(name, node, serverNode, *value)
+		}
 
 		logs.Info("opcua server add node %s success", serverNode.ToString())
 	}
 
+	for _, alarm := range opc.alarms.Alarms(name) {
+		serverName := fmt.Sprintf("%s.alarm.%s", name, alarm.Name)
+
+		clientNode := NodeInfo{NsIndex: uint32(index), NodeID: name}
+		serverNode := NodeInfo{NsIndex: uint32(index), NodeID: serverName}
+
+		err = opc.server.AddNode(clientNode, serverNode, serverName, NodeValue{Type: UA_BOOLEAN, Value: false})
+		if err != nil {
+			logs.Error("opcua server add alarm node %s failed, %s", serverNode.ToString(), err.Error())
+			return err
+		}
+
+		opc.Lock()
+		if opc.alarmCache[name] == nil {
+			opc.alarmCache[name] = make(map[string]NodeInfo)
+		}
+		opc.alarmCache[name][alarm.Name] = serverNode
+		opc.Unlock()
+
+		logs.Info("opcua server add alarm node %s success", serverNode.ToString())
+	}
+
+	if cfg := opc.cfg.ClientConfig(name); cfg.Events.Enable {
+		origin := NodeInfo{NsIndex: uint32(index), NodeID: name}
+		err = opc.server.EnableEvents(origin)
+		if err != nil {
+			logs.Error("opcua server enable events on %s failed, %s", origin.ToString(), err.Error())
+			return err
+		}
+
+		opc.Lock()
+		opc.eventCache[name] = origin
+		opc.Unlock()
+
+		logs.Info("opcua server enable events on %s success", origin.ToString())
+	}
+
+	for _, method := range opc.cfg.Server.Methods {
+		if method.ClientName != name {
+			continue
+		}
+
+		inputs, outputs, err := cli.ReadMethodArguments(method.MethodNode)
+		if err != nil {
+			logs.Error("opcua server read method %s arguments failed, %s", method.MethodNode.ToString(), err.Error())
+			return err
+		}
+
+		clientNode := NodeInfo{NsIndex: uint32(index), NodeID: name}
+		serverNode := NodeInfo{NsIndex: uint32(index), NodeID: method.ServerName}
+
+		err = opc.server.AddMethod(clientNode, serverNode, method.ServerName, inputs, outputs)
+		if err != nil {
+			logs.Error("opcua server add method %s failed, %s", serverNode.ToString(), err.Error())
+			return err
+		}
+
+		opc.Lock()
+		opc.methodCache[historyKey(name, method.ServerName)] = method
+		opc.Unlock()
+
+		logs.Info("opcua server add method %s success", serverNode.ToString())
+	}
+
 	return nil
 }
 
+func (opc *OpcuaServer) historyInit() {
+	if opc.db == nil {
+		logs.Warning("opcua server history disabled, the datastore is not enabled")
+		return
+	}
+
+	history := NewHistoryStore(opc.db)
+	err := opc.server.EnableHistory(history.Read)
+	if err != nil {
+		logs.Error("opcua server history enable failed, %s", err.Error())
+		return
+	}
+	opc.history = history
+
+	logs.Info("opcua server history enable success")
+}
+
+func (opc *OpcuaServer) historyAdd(name string, node ServerNodeInfo, serverNode NodeInfo, value NodeValue) {
+	cfg := opc.cfg.ClientConfig(name)
+	if !cfg.Store {
+		return
+	}
+
+	stored := false
+	column := ""
+	for _, item := range cfg.NodeList {
+		if item.Compare(node.ClientNode) {
+			stored = !item.NoStore
+			column = ColumnName(item.Name())
+			break
+		}
+	}
+	if !stored {
+		return
+	}
+
+	opc.history.Add(name, node.ServerName, EscapeString(cfg.Name), column, value)
+
+	err := opc.server.SetHistorizing(serverNode)
+	if err != nil {
+		logs.Warning("opcua server set node %s historizing failed, %s", serverNode.ToString(), err.Error())
+	}
+}
+
+func (opc *OpcuaServer) clientStart(cli *Client, name string) {
+	opc.workers.Go(name, func(ctx context.Context) {
+		opc.clientTask(ctx, cli, name)
+	})
+	opc.stats[STAT_CLIENT].Status = true
+}
+
 func (opc *OpcuaServer) Close() {
 	logs.Info("opcua server ready close")
 
-	opc.shutdown = true
-	opc.serverChan <- true
-	opc.dbChan <- true
-	opc.Wait()
+	opc.workers.Close()
+	opc.sinks.Close()
 
 	for _, cli := range opc.clients {
 		cli.Close()
 	}
 
-	if opc.db != nil {
-		opc.db.Close()
-	}
-
 	if opc.server != nil {
 		opc.server.Close()
 	}
 
+	if opc.db != nil {
+		opc.db.Close()
+	}
+
 	for _, stat := range opc.stats {
 		stat.Clear()
 	}
@@ -283,21 +673,75 @@ func (opc *OpcuaServer) Close() {
 	logs.Info("opcua server close done")
 }
 
+// clientConfig is the config of the running client, the config changes at the reload
+func (opc *OpcuaServer) clientConfig(name string) ClientConfig {
+	opc.RLock()
+	defer opc.RUnlock()
+
+	return opc.cfg.ClientConfig(name)
+}
+
+func (opc *OpcuaServer) clientDataInit(db *DataSave, cfg ClientConfig) error {
+	if !cfg.Store || !cfg.Enable {
+		return nil
+	}
+	columns := make([]ColumnInfo, 0)
+	for _, node := range cfg.NodeList {
+		if node.NoStore {
+			continue
+		}
+		columns = append(columns, ColumnInfo{
+			Name:    ColumnName(node.Name()),
+			Comment: EscapeString(node.Key()),
+		})
+	}
+	if cfg.Backfill > 0 {
+		columns = append(columns, ColumnInfo{
+			Name:    BACKFILL_COLUMN,
+			Comment: "backfilled from source history",
+		})
+	}
+	err := db.TableInit(EscapeString(cfg.Name), columns)
+	if err != nil {
+		logs.Error("opcua client table init %s failed", EscapeString(cfg.Name))
+		return err
+	}
+
+	if cfg.Events.Enable {
+		err = db.TableInit(EventsTableName(cfg.Name), EventColumns(cfg.Events.Selects()))
+		if err != nil {
+			logs.Error("opcua client events table init %s failed", EventsTableName(cfg.Name))
+			return err
+		}
+	}
+	return nil
+}
+
 func (opc *OpcuaServer) dataInit(db *DataSave) error {
 	for _, cfg := range opc.cfg.Clients {
-		if !cfg.Store || !cfg.Enable {
-			continue
+		err := opc.clientDataInit(db, cfg)
+		if err != nil {
+			return err
 		}
-		columns := make([]ColumnInfo, 0)
-		for _, node := range cfg.NodeList {
-			columns = append(columns, ColumnInfo{
-				Name:    ColumnName(node.NodeID),
-				Comment: EscapeString(node.NodeID),
-			})
+	}
+
+	return opc.commonDataInit(db)
+}
+
+// commonDataInit creates the tables shared by the clients
+func (opc *OpcuaServer) commonDataInit(db *DataSave) error {
+	if opc.cfg.Server.Enable && len(opc.cfg.Server.Methods) > 0 {
+		err := db.TableInit(METHOD_AUDIT_TABLE, MethodAuditColumns())
+		if err != nil {
+			logs.Error("opcua method audit table init %s failed", METHOD_AUDIT_TABLE)
+			return err
 		}
-		err := db.TableInit(EscapeString(cfg.Name), columns)
+	}
+
+	if opc.cfg.Alarm.Database && opc.alarms.Count() > 0 {
+		err := db.TableInit(ALARM_TABLE, AlarmColumns())
 		if err != nil {
-			logs.Error("opcua client table init %s failed", EscapeString(cfg.Name))
+			logs.Error("opcua alarm table init %s failed", ALARM_TABLE)
 			return err
 		}
 	}
@@ -309,15 +753,23 @@ func (opc *OpcuaServer) dataInit(db *DataSave) error {
 func NewOpcuaServer(config Config, stats []*StatItem) (*OpcuaServer, error) {
 	var err error
 
+	// the running config is a copy, the dialogs edit the global config in place
+	config = config.Clone()
+
 	opc := &OpcuaServer{
 		cfg:         config,
-		shutdown:    false,
-		dbChan:      make(chan interface{}, 1024),
-		serverChan:  make(chan interface{}, 1024),
+		dbQueue:     config.Datastore.Queue.Default(QUEUE_BLOCK),
+		serverQueue: config.Server.Queue.Default(QUEUE_DROP),
+		workers:     NewSupervisor("client"),
+		sinks:       NewSupervisor("sink"),
 		stats:       make(map[string]*StatItem),
 		clients:     make(map[string]*Client),
-		serverCache: make(map[string]NodeInfo),
+		serverCache: make(map[string][]NodeInfo),
+		alarmCache:  make(map[string]map[string]NodeInfo),
+		methodCache: make(map[string]ServerMethodInfo),
+		eventCache:  make(map[string]NodeInfo),
 		basketCache: make(map[string]OpcuaBasket),
+		alarms:      NewAlarmEngine(config.Clients),
 	}
 
 	defer func() {
@@ -341,9 +793,18 @@ func NewOpcuaServer(config Config, stats []*StatItem) (*OpcuaServer, error) {
 			logs.Error("opcua client table init failed, %s", err.Error())
 			return nil, err
 		}
-		opc.Add(1)
-		go opc.dataStoreTask()
+		opc.dbSink = NewSink("database")
+		opc.sinks.Go("database", opc.dataStoreTask)
 		opc.stats[STAT_MYSQL].Status = true
+
+		if config.Alarm.Database {
+			queue := opc.dbSink.Queue("alarm", QueueConfig{Size: opc.dbQueue.Size, Policy: QUEUE_DROP})
+			opc.alarms.AddSink(NewAlarmQueueSink(opc.workers.Context(), "database", queue))
+		}
+	}
+
+	if config.Alarm.Logfile {
+		opc.alarms.AddSink(NewAlarmFileSink(defaultApplicationConfig.LogPath))
 	}
 
 	for _, cfg := range opc.cfg.Clients {
@@ -358,18 +819,24 @@ func NewOpcuaServer(config Config, stats []*StatItem) (*OpcuaServer, error) {
 		}
 	}
 
-	for name, cli := range opc.clients {
-		opc.Add(1)
-		go opc.clientTask(cli, name)
-		opc.stats[STAT_CLIENT].Status = true
-	}
-
 	if config.Server.Enable {
-		opc.server, err = NewServer(config.Server.Endpoint, config.Server.Port)
+		var security *ServerSecurity
+		security, err = config.Server.SecurityLoad()
+		if err != nil {
+			logs.Error("opcua server security load failed, %s", err.Error())
+			return nil, err
+		}
+		opc.server, err = NewServer(config.Server.Endpoint, config.Server.Port, security)
 		if err != nil {
 			logs.Error("opcua server init failed, %s", err.Error())
 			return nil, err
 		}
+		if config.Server.History {
+			opc.historyInit()
+		}
+		if len(config.Server.Methods) > 0 {
+			opc.server.EnableMethods(opc.methodCall)
+		}
 		for name, cli := range opc.clients {
 			err = opc.serverInit(cli, name)
 			if err != nil {
@@ -377,9 +844,19 @@ func NewOpcuaServer(config Config, stats []*StatItem) (*OpcuaServer, error) {
 				return nil, err
 			}
 		}
-		opc.Add(1)
-		go opc.serverTask()
+		opc.serverSink = NewSink("server")
+		opc.sinks.Go("server", opc.serverTask)
 		opc.stats[STAT_SERVER].Status = true
+
+		if config.Alarm.Server {
+			queue := opc.serverSink.Queue("alarm", QueueConfig{Size: opc.serverQueue.Size, Policy: QUEUE_DROP})
+			opc.alarms.AddSink(NewAlarmQueueSink(opc.workers.Context(), "server", queue))
+		}
+	}
+
+	// the client workers start after the sinks are ready
+	for name, cli := range opc.clients {
+		opc.clientStart(cli, name)
 	}
 
 	return opc, nil
@@ -14437,6 +14437,490 @@ static UA_DataTypeMember EventNotificationList_members[1] = {
     true, /* .isArray */
     false  /* .isOptional */
 },};
+/* PerformUpdateType */
+#define PerformUpdateType_members NULL
+
+/* HistoryUpdateType */
+#define HistoryUpdateType_members NULL
+
+/* HistoryReadValueId */
+static UA_DataTypeMember HistoryReadValueId_members[4] = {
+{
+    UA_TYPENAME("NodeId") /* .memberName */
+    &UA_TYPES[UA_TYPES_NODEID], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("IndexRange") /* .memberName */
+    &UA_TYPES[UA_TYPES_STRING], /* .memberType */
+    offsetof(UA_HistoryReadValueId, indexRange) - offsetof(UA_HistoryReadValueId, nodeId) - sizeof(UA_NodeId), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("DataEncoding") /* .memberName */
+    &UA_TYPES[UA_TYPES_QUALIFIEDNAME], /* .memberType */
+    offsetof(UA_HistoryReadValueId, dataEncoding) - offsetof(UA_HistoryReadValueId, indexRange) - sizeof(UA_String), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("ContinuationPoint") /* .memberName */
+    &UA_TYPES[UA_TYPES_BYTESTRING], /* .memberType */
+    offsetof(UA_HistoryReadValueId, continuationPoint) - offsetof(UA_HistoryReadValueId, dataEncoding) - sizeof(UA_QualifiedName), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryReadResult */
+static UA_DataTypeMember HistoryReadResult_members[3] = {
+{
+    UA_TYPENAME("StatusCode") /* .memberName */
+    &UA_TYPES[UA_TYPES_STATUSCODE], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("ContinuationPoint") /* .memberName */
+    &UA_TYPES[UA_TYPES_BYTESTRING], /* .memberType */
+    offsetof(UA_HistoryReadResult, continuationPoint) - offsetof(UA_HistoryReadResult, statusCode) - sizeof(UA_StatusCode), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("HistoryData") /* .memberName */
+    &UA_TYPES[UA_TYPES_EXTENSIONOBJECT], /* .memberType */
+    offsetof(UA_HistoryReadResult, historyData) - offsetof(UA_HistoryReadResult, continuationPoint) - sizeof(UA_ByteString), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* ReadEventDetails */
+static UA_DataTypeMember ReadEventDetails_members[4] = {
+{
+    UA_TYPENAME("NumValuesPerNode") /* .memberName */
+    &UA_TYPES[UA_TYPES_UINT32], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("StartTime") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    offsetof(UA_ReadEventDetails, startTime) - offsetof(UA_ReadEventDetails, numValuesPerNode) - sizeof(UA_UInt32), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("EndTime") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    offsetof(UA_ReadEventDetails, endTime) - offsetof(UA_ReadEventDetails, startTime) - sizeof(UA_DateTime), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("Filter") /* .memberName */
+    &UA_TYPES[UA_TYPES_EVENTFILTER], /* .memberType */
+    offsetof(UA_ReadEventDetails, filter) - offsetof(UA_ReadEventDetails, endTime) - sizeof(UA_DateTime), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* ReadRawModifiedDetails */
+static UA_DataTypeMember ReadRawModifiedDetails_members[5] = {
+{
+    UA_TYPENAME("IsReadModified") /* .memberName */
+    &UA_TYPES[UA_TYPES_BOOLEAN], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("StartTime") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    offsetof(UA_ReadRawModifiedDetails, startTime) - offsetof(UA_ReadRawModifiedDetails, isReadModified) - sizeof(UA_Boolean), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("EndTime") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    offsetof(UA_ReadRawModifiedDetails, endTime) - offsetof(UA_ReadRawModifiedDetails, startTime) - sizeof(UA_DateTime), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("NumValuesPerNode") /* .memberName */
+    &UA_TYPES[UA_TYPES_UINT32], /* .memberType */
+    offsetof(UA_ReadRawModifiedDetails, numValuesPerNode) - offsetof(UA_ReadRawModifiedDetails, endTime) - sizeof(UA_DateTime), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("ReturnBounds") /* .memberName */
+    &UA_TYPES[UA_TYPES_BOOLEAN], /* .memberType */
+    offsetof(UA_ReadRawModifiedDetails, returnBounds) - offsetof(UA_ReadRawModifiedDetails, numValuesPerNode) - sizeof(UA_UInt32), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* ReadProcessedDetails */
+static UA_DataTypeMember ReadProcessedDetails_members[5] = {
+{
+    UA_TYPENAME("StartTime") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("EndTime") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    offsetof(UA_ReadProcessedDetails, endTime) - offsetof(UA_ReadProcessedDetails, startTime) - sizeof(UA_DateTime), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("ProcessingInterval") /* .memberName */
+    &UA_TYPES[UA_TYPES_DOUBLE], /* .memberType */
+    offsetof(UA_ReadProcessedDetails, processingInterval) - offsetof(UA_ReadProcessedDetails, endTime) - sizeof(UA_DateTime), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("AggregateType") /* .memberName */
+    &UA_TYPES[UA_TYPES_NODEID], /* .memberType */
+    offsetof(UA_ReadProcessedDetails, aggregateTypeSize) - offsetof(UA_ReadProcessedDetails, processingInterval) - sizeof(UA_Double), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("AggregateConfiguration") /* .memberName */
+    &UA_TYPES[UA_TYPES_AGGREGATECONFIGURATION], /* .memberType */
+    offsetof(UA_ReadProcessedDetails, aggregateConfiguration) - offsetof(UA_ReadProcessedDetails, aggregateType) - sizeof(void *), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* ReadAtTimeDetails */
+static UA_DataTypeMember ReadAtTimeDetails_members[2] = {
+{
+    UA_TYPENAME("ReqTimes") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    0, /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("UseSimpleBounds") /* .memberName */
+    &UA_TYPES[UA_TYPES_BOOLEAN], /* .memberType */
+    offsetof(UA_ReadAtTimeDetails, useSimpleBounds) - offsetof(UA_ReadAtTimeDetails, reqTimes) - sizeof(void *), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryData */
+static UA_DataTypeMember HistoryData_members[1] = {
+{
+    UA_TYPENAME("DataValues") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATAVALUE], /* .memberType */
+    0, /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* ModificationInfo */
+static UA_DataTypeMember ModificationInfo_members[3] = {
+{
+    UA_TYPENAME("ModificationTime") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("UpdateType") /* .memberName */
+    &UA_TYPES[UA_TYPES_HISTORYUPDATETYPE], /* .memberType */
+    offsetof(UA_ModificationInfo, updateType) - offsetof(UA_ModificationInfo, modificationTime) - sizeof(UA_DateTime), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("UserName") /* .memberName */
+    &UA_TYPES[UA_TYPES_STRING], /* .memberType */
+    offsetof(UA_ModificationInfo, userName) - offsetof(UA_ModificationInfo, updateType) - sizeof(UA_HistoryUpdateType), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryModifiedData */
+static UA_DataTypeMember HistoryModifiedData_members[2] = {
+{
+    UA_TYPENAME("DataValues") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATAVALUE], /* .memberType */
+    0, /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("ModificationInfos") /* .memberName */
+    &UA_TYPES[UA_TYPES_MODIFICATIONINFO], /* .memberType */
+    offsetof(UA_HistoryModifiedData, modificationInfosSize) - offsetof(UA_HistoryModifiedData, dataValues) - sizeof(void *), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryEventFieldList */
+static UA_DataTypeMember HistoryEventFieldList_members[1] = {
+{
+    UA_TYPENAME("EventFields") /* .memberName */
+    &UA_TYPES[UA_TYPES_VARIANT], /* .memberType */
+    0, /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryEvent */
+static UA_DataTypeMember HistoryEvent_members[1] = {
+{
+    UA_TYPENAME("Events") /* .memberName */
+    &UA_TYPES[UA_TYPES_HISTORYEVENTFIELDLIST], /* .memberType */
+    0, /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryReadRequest */
+static UA_DataTypeMember HistoryReadRequest_members[5] = {
+{
+    UA_TYPENAME("RequestHeader") /* .memberName */
+    &UA_TYPES[UA_TYPES_REQUESTHEADER], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("HistoryReadDetails") /* .memberName */
+    &UA_TYPES[UA_TYPES_EXTENSIONOBJECT], /* .memberType */
+    offsetof(UA_HistoryReadRequest, historyReadDetails) - offsetof(UA_HistoryReadRequest, requestHeader) - sizeof(UA_RequestHeader), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("TimestampsToReturn") /* .memberName */
+    &UA_TYPES[UA_TYPES_TIMESTAMPSTORETURN], /* .memberType */
+    offsetof(UA_HistoryReadRequest, timestampsToReturn) - offsetof(UA_HistoryReadRequest, historyReadDetails) - sizeof(UA_ExtensionObject), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("ReleaseContinuationPoints") /* .memberName */
+    &UA_TYPES[UA_TYPES_BOOLEAN], /* .memberType */
+    offsetof(UA_HistoryReadRequest, releaseContinuationPoints) - offsetof(UA_HistoryReadRequest, timestampsToReturn) - sizeof(UA_TimestampsToReturn), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("NodesToRead") /* .memberName */
+    &UA_TYPES[UA_TYPES_HISTORYREADVALUEID], /* .memberType */
+    offsetof(UA_HistoryReadRequest, nodesToReadSize) - offsetof(UA_HistoryReadRequest, releaseContinuationPoints) - sizeof(UA_Boolean), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryReadResponse */
+static UA_DataTypeMember HistoryReadResponse_members[3] = {
+{
+    UA_TYPENAME("ResponseHeader") /* .memberName */
+    &UA_TYPES[UA_TYPES_RESPONSEHEADER], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("Results") /* .memberName */
+    &UA_TYPES[UA_TYPES_HISTORYREADRESULT], /* .memberType */
+    offsetof(UA_HistoryReadResponse, resultsSize) - offsetof(UA_HistoryReadResponse, responseHeader) - sizeof(UA_ResponseHeader), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("DiagnosticInfos") /* .memberName */
+    &UA_TYPES[UA_TYPES_DIAGNOSTICINFO], /* .memberType */
+    offsetof(UA_HistoryReadResponse, diagnosticInfosSize) - offsetof(UA_HistoryReadResponse, results) - sizeof(void *), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* UpdateDataDetails */
+static UA_DataTypeMember UpdateDataDetails_members[3] = {
+{
+    UA_TYPENAME("NodeId") /* .memberName */
+    &UA_TYPES[UA_TYPES_NODEID], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("PerformInsertReplace") /* .memberName */
+    &UA_TYPES[UA_TYPES_PERFORMUPDATETYPE], /* .memberType */
+    offsetof(UA_UpdateDataDetails, performInsertReplace) - offsetof(UA_UpdateDataDetails, nodeId) - sizeof(UA_NodeId), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("UpdateValues") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATAVALUE], /* .memberType */
+    offsetof(UA_UpdateDataDetails, updateValuesSize) - offsetof(UA_UpdateDataDetails, performInsertReplace) - sizeof(UA_PerformUpdateType), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* UpdateStructureDataDetails */
+static UA_DataTypeMember UpdateStructureDataDetails_members[3] = {
+{
+    UA_TYPENAME("NodeId") /* .memberName */
+    &UA_TYPES[UA_TYPES_NODEID], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("PerformInsertReplace") /* .memberName */
+    &UA_TYPES[UA_TYPES_PERFORMUPDATETYPE], /* .memberType */
+    offsetof(UA_UpdateStructureDataDetails, performInsertReplace) - offsetof(UA_UpdateStructureDataDetails, nodeId) - sizeof(UA_NodeId), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("UpdateValues") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATAVALUE], /* .memberType */
+    offsetof(UA_UpdateStructureDataDetails, updateValuesSize) - offsetof(UA_UpdateStructureDataDetails, performInsertReplace) - sizeof(UA_PerformUpdateType), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* UpdateEventDetails */
+static UA_DataTypeMember UpdateEventDetails_members[4] = {
+{
+    UA_TYPENAME("NodeId") /* .memberName */
+    &UA_TYPES[UA_TYPES_NODEID], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("PerformInsertReplace") /* .memberName */
+    &UA_TYPES[UA_TYPES_PERFORMUPDATETYPE], /* .memberType */
+    offsetof(UA_UpdateEventDetails, performInsertReplace) - offsetof(UA_UpdateEventDetails, nodeId) - sizeof(UA_NodeId), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("Filter") /* .memberName */
+    &UA_TYPES[UA_TYPES_EVENTFILTER], /* .memberType */
+    offsetof(UA_UpdateEventDetails, filter) - offsetof(UA_UpdateEventDetails, performInsertReplace) - sizeof(UA_PerformUpdateType), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("EventData") /* .memberName */
+    &UA_TYPES[UA_TYPES_HISTORYEVENTFIELDLIST], /* .memberType */
+    offsetof(UA_UpdateEventDetails, eventDataSize) - offsetof(UA_UpdateEventDetails, filter) - sizeof(UA_EventFilter), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* DeleteRawModifiedDetails */
+static UA_DataTypeMember DeleteRawModifiedDetails_members[4] = {
+{
+    UA_TYPENAME("NodeId") /* .memberName */
+    &UA_TYPES[UA_TYPES_NODEID], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("IsDeleteModified") /* .memberName */
+    &UA_TYPES[UA_TYPES_BOOLEAN], /* .memberType */
+    offsetof(UA_DeleteRawModifiedDetails, isDeleteModified) - offsetof(UA_DeleteRawModifiedDetails, nodeId) - sizeof(UA_NodeId), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("StartTime") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    offsetof(UA_DeleteRawModifiedDetails, startTime) - offsetof(UA_DeleteRawModifiedDetails, isDeleteModified) - sizeof(UA_Boolean), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("EndTime") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    offsetof(UA_DeleteRawModifiedDetails, endTime) - offsetof(UA_DeleteRawModifiedDetails, startTime) - sizeof(UA_DateTime), /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* DeleteAtTimeDetails */
+static UA_DataTypeMember DeleteAtTimeDetails_members[2] = {
+{
+    UA_TYPENAME("NodeId") /* .memberName */
+    &UA_TYPES[UA_TYPES_NODEID], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("ReqTimes") /* .memberName */
+    &UA_TYPES[UA_TYPES_DATETIME], /* .memberType */
+    offsetof(UA_DeleteAtTimeDetails, reqTimesSize) - offsetof(UA_DeleteAtTimeDetails, nodeId) - sizeof(UA_NodeId), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* DeleteEventDetails */
+static UA_DataTypeMember DeleteEventDetails_members[2] = {
+{
+    UA_TYPENAME("NodeId") /* .memberName */
+    &UA_TYPES[UA_TYPES_NODEID], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("EventIds") /* .memberName */
+    &UA_TYPES[UA_TYPES_BYTESTRING], /* .memberType */
+    offsetof(UA_DeleteEventDetails, eventIdsSize) - offsetof(UA_DeleteEventDetails, nodeId) - sizeof(UA_NodeId), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryUpdateResult */
+static UA_DataTypeMember HistoryUpdateResult_members[3] = {
+{
+    UA_TYPENAME("StatusCode") /* .memberName */
+    &UA_TYPES[UA_TYPES_STATUSCODE], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("OperationResults") /* .memberName */
+    &UA_TYPES[UA_TYPES_STATUSCODE], /* .memberType */
+    offsetof(UA_HistoryUpdateResult, operationResultsSize) - offsetof(UA_HistoryUpdateResult, statusCode) - sizeof(UA_StatusCode), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("DiagnosticInfos") /* .memberName */
+    &UA_TYPES[UA_TYPES_DIAGNOSTICINFO], /* .memberType */
+    offsetof(UA_HistoryUpdateResult, diagnosticInfosSize) - offsetof(UA_HistoryUpdateResult, operationResults) - sizeof(void *), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryUpdateRequest */
+static UA_DataTypeMember HistoryUpdateRequest_members[2] = {
+{
+    UA_TYPENAME("RequestHeader") /* .memberName */
+    &UA_TYPES[UA_TYPES_REQUESTHEADER], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("HistoryUpdateDetails") /* .memberName */
+    &UA_TYPES[UA_TYPES_EXTENSIONOBJECT], /* .memberType */
+    offsetof(UA_HistoryUpdateRequest, historyUpdateDetailsSize) - offsetof(UA_HistoryUpdateRequest, requestHeader) - sizeof(UA_RequestHeader), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
+/* HistoryUpdateResponse */
+static UA_DataTypeMember HistoryUpdateResponse_members[3] = {
+{
+    UA_TYPENAME("ResponseHeader") /* .memberName */
+    &UA_TYPES[UA_TYPES_RESPONSEHEADER], /* .memberType */
+    0, /* .padding */
+    false, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("Results") /* .memberName */
+    &UA_TYPES[UA_TYPES_HISTORYUPDATERESULT], /* .memberType */
+    offsetof(UA_HistoryUpdateResponse, resultsSize) - offsetof(UA_HistoryUpdateResponse, responseHeader) - sizeof(UA_ResponseHeader), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},{
+    UA_TYPENAME("DiagnosticInfos") /* .memberName */
+    &UA_TYPES[UA_TYPES_DIAGNOSTICINFO], /* .memberType */
+    offsetof(UA_HistoryUpdateResponse, diagnosticInfosSize) - offsetof(UA_HistoryUpdateResponse, results) - sizeof(void *), /* .padding */
+    true, /* .isArray */
+    false  /* .isOptional */
+},};
+
 const UA_DataType UA_TYPES[UA_TYPES_COUNT] = {
 /* Boolean */
 {
@@ -16729,6 +17213,293 @@ const UA_DataType UA_TYPES[UA_TYPES_COUNT] = {
     false, /* .overlayable */
     1, /* .membersSize */
     EventNotificationList_members  /* .members */
+},/* PerformUpdateType */
+{
+    UA_TYPENAME("PerformUpdateType") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {11293LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {0}}, /* .binaryEncodingId */
+    sizeof(UA_PerformUpdateType), /* .memSize */
+    UA_DATATYPEKIND_ENUM, /* .typeKind */
+    true, /* .pointerFree */
+    UA_BINARY_OVERLAYABLE_INTEGER, /* .overlayable */
+    0, /* .membersSize */
+    PerformUpdateType_members  /* .members */
+},
+/* HistoryUpdateType */
+{
+    UA_TYPENAME("HistoryUpdateType") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {11234LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {0}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryUpdateType), /* .memSize */
+    UA_DATATYPEKIND_ENUM, /* .typeKind */
+    true, /* .pointerFree */
+    UA_BINARY_OVERLAYABLE_INTEGER, /* .overlayable */
+    0, /* .membersSize */
+    HistoryUpdateType_members  /* .members */
+},
+/* HistoryReadValueId */
+{
+    UA_TYPENAME("HistoryReadValueId") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {635LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {637LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryReadValueId), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    4, /* .membersSize */
+    HistoryReadValueId_members  /* .members */
+},
+/* HistoryReadResult */
+{
+    UA_TYPENAME("HistoryReadResult") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {638LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {640LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryReadResult), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    3, /* .membersSize */
+    HistoryReadResult_members  /* .members */
+},
+/* ReadEventDetails */
+{
+    UA_TYPENAME("ReadEventDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {644LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {646LU}}, /* .binaryEncodingId */
+    sizeof(UA_ReadEventDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    4, /* .membersSize */
+    ReadEventDetails_members  /* .members */
+},
+/* ReadRawModifiedDetails */
+{
+    UA_TYPENAME("ReadRawModifiedDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {647LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {649LU}}, /* .binaryEncodingId */
+    sizeof(UA_ReadRawModifiedDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    true, /* .pointerFree */
+    false, /* .overlayable */
+    5, /* .membersSize */
+    ReadRawModifiedDetails_members  /* .members */
+},
+/* ReadProcessedDetails */
+{
+    UA_TYPENAME("ReadProcessedDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {650LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {652LU}}, /* .binaryEncodingId */
+    sizeof(UA_ReadProcessedDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    5, /* .membersSize */
+    ReadProcessedDetails_members  /* .members */
+},
+/* ReadAtTimeDetails */
+{
+    UA_TYPENAME("ReadAtTimeDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {653LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {655LU}}, /* .binaryEncodingId */
+    sizeof(UA_ReadAtTimeDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    2, /* .membersSize */
+    ReadAtTimeDetails_members  /* .members */
+},
+/* HistoryData */
+{
+    UA_TYPENAME("HistoryData") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {656LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {658LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryData), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    1, /* .membersSize */
+    HistoryData_members  /* .members */
+},
+/* ModificationInfo */
+{
+    UA_TYPENAME("ModificationInfo") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {11216LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {11226LU}}, /* .binaryEncodingId */
+    sizeof(UA_ModificationInfo), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    3, /* .membersSize */
+    ModificationInfo_members  /* .members */
+},
+/* HistoryModifiedData */
+{
+    UA_TYPENAME("HistoryModifiedData") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {11217LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {11227LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryModifiedData), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    2, /* .membersSize */
+    HistoryModifiedData_members  /* .members */
+},
+/* HistoryEventFieldList */
+{
+    UA_TYPENAME("HistoryEventFieldList") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {920LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {922LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryEventFieldList), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    1, /* .membersSize */
+    HistoryEventFieldList_members  /* .members */
+},
+/* HistoryEvent */
+{
+    UA_TYPENAME("HistoryEvent") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {659LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {661LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryEvent), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    1, /* .membersSize */
+    HistoryEvent_members  /* .members */
+},
+/* HistoryReadRequest */
+{
+    UA_TYPENAME("HistoryReadRequest") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {662LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {664LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryReadRequest), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    5, /* .membersSize */
+    HistoryReadRequest_members  /* .members */
+},
+/* HistoryReadResponse */
+{
+    UA_TYPENAME("HistoryReadResponse") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {665LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {667LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryReadResponse), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    3, /* .membersSize */
+    HistoryReadResponse_members  /* .members */
+},
+/* UpdateDataDetails */
+{
+    UA_TYPENAME("UpdateDataDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {680LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {682LU}}, /* .binaryEncodingId */
+    sizeof(UA_UpdateDataDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    3, /* .membersSize */
+    UpdateDataDetails_members  /* .members */
+},
+/* UpdateStructureDataDetails */
+{
+    UA_TYPENAME("UpdateStructureDataDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {11295LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {11300LU}}, /* .binaryEncodingId */
+    sizeof(UA_UpdateStructureDataDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    3, /* .membersSize */
+    UpdateStructureDataDetails_members  /* .members */
+},
+/* UpdateEventDetails */
+{
+    UA_TYPENAME("UpdateEventDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {683LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {685LU}}, /* .binaryEncodingId */
+    sizeof(UA_UpdateEventDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    4, /* .membersSize */
+    UpdateEventDetails_members  /* .members */
+},
+/* DeleteRawModifiedDetails */
+{
+    UA_TYPENAME("DeleteRawModifiedDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {686LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {688LU}}, /* .binaryEncodingId */
+    sizeof(UA_DeleteRawModifiedDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    4, /* .membersSize */
+    DeleteRawModifiedDetails_members  /* .members */
+},
+/* DeleteAtTimeDetails */
+{
+    UA_TYPENAME("DeleteAtTimeDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {689LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {691LU}}, /* .binaryEncodingId */
+    sizeof(UA_DeleteAtTimeDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    2, /* .membersSize */
+    DeleteAtTimeDetails_members  /* .members */
+},
+/* DeleteEventDetails */
+{
+    UA_TYPENAME("DeleteEventDetails") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {692LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {694LU}}, /* .binaryEncodingId */
+    sizeof(UA_DeleteEventDetails), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    2, /* .membersSize */
+    DeleteEventDetails_members  /* .members */
+},
+/* HistoryUpdateResult */
+{
+    UA_TYPENAME("HistoryUpdateResult") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {695LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {697LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryUpdateResult), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    3, /* .membersSize */
+    HistoryUpdateResult_members  /* .members */
+},
+/* HistoryUpdateRequest */
+{
+    UA_TYPENAME("HistoryUpdateRequest") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {698LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {700LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryUpdateRequest), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    2, /* .membersSize */
+    HistoryUpdateRequest_members  /* .members */
+},
+/* HistoryUpdateResponse */
+{
+    UA_TYPENAME("HistoryUpdateResponse") /* .typeName */
+    {0, UA_NODEIDTYPE_NUMERIC, {701LU}}, /* .typeId */
+    {0, UA_NODEIDTYPE_NUMERIC, {703LU}}, /* .binaryEncodingId */
+    sizeof(UA_HistoryUpdateResponse), /* .memSize */
+    UA_DATATYPEKIND_STRUCTURE, /* .typeKind */
+    false, /* .pointerFree */
+    false, /* .overlayable */
+    3, /* .membersSize */
+    HistoryUpdateResponse_members  /* .members */
 },
 };
 
@@ -22829,61 +23600,67 @@ UA_Server_initNS0(UA_Server *server) {
 #ifndef UA_ENABLE_HISTORIZING
     UA_Server_deleteNode(server, UA_NODEID_NUMERIC(0, UA_NS0ID_HISTORYSERVERCAPABILITIES), true);
 #else
-    /* ServerCapabilities - HistoryServerCapabilities - AccessHistoryDataCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_ACCESSHISTORYDATACAPABILITY,
-                               &server->config.accessHistoryDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - MaxReturnDataValues */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_MAXRETURNDATAVALUES,
-                               &server->config.maxReturnDataValues, &UA_TYPES[UA_TYPES_UINT32]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - AccessHistoryEventsCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_ACCESSHISTORYEVENTSCAPABILITY,
-                               &server->config.accessHistoryEventsCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - MaxReturnEventValues */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_MAXRETURNEVENTVALUES,
-                               &server->config.maxReturnEventValues, &UA_TYPES[UA_TYPES_UINT32]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - InsertDataCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_INSERTDATACAPABILITY,
-                               &server->config.insertDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - InsertEventCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_INSERTEVENTCAPABILITY,
-                               &server->config.insertEventCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - InsertAnnotationsCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_INSERTANNOTATIONCAPABILITY,
-                               &server->config.insertAnnotationsCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - ReplaceDataCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_REPLACEDATACAPABILITY,
-                               &server->config.replaceDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - ReplaceEventCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_REPLACEEVENTCAPABILITY,
-                               &server->config.replaceEventCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - UpdateDataCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_UPDATEDATACAPABILITY,
-                               &server->config.updateDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - UpdateEventCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_UPDATEEVENTCAPABILITY,
-                               &server->config.updateEventCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - DeleteRawCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_DELETERAWCAPABILITY,
-                               &server->config.deleteRawCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - DeleteEventCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_DELETEEVENTCAPABILITY,
-                               &server->config.deleteEventCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
-
-    /* ServerCapabilities - HistoryServerCapabilities - DeleteAtTimeDataCapability */
-    retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_DELETEATTIMECAPABILITY,
-                               &server->config.deleteAtTimeDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+    /* The reduced namespace zero has no HistoryServerCapabilities. The
+     * capabilities are written only if the node exists. */
+    UA_NodeClass historyCapabilitiesClass = UA_NODECLASS_UNSPECIFIED;
+    if(UA_Server_readNodeClass(server, UA_NODEID_NUMERIC(0, UA_NS0ID_HISTORYSERVERCAPABILITIES),
+                               &historyCapabilitiesClass) == UA_STATUSCODE_GOOD) {
+        /* ServerCapabilities - HistoryServerCapabilities - AccessHistoryDataCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_ACCESSHISTORYDATACAPABILITY,
+                                   &server->config.accessHistoryDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - MaxReturnDataValues */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_MAXRETURNDATAVALUES,
+                                   &server->config.maxReturnDataValues, &UA_TYPES[UA_TYPES_UINT32]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - AccessHistoryEventsCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_ACCESSHISTORYEVENTSCAPABILITY,
+                                   &server->config.accessHistoryEventsCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - MaxReturnEventValues */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_MAXRETURNEVENTVALUES,
+                                   &server->config.maxReturnEventValues, &UA_TYPES[UA_TYPES_UINT32]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - InsertDataCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_INSERTDATACAPABILITY,
+                                   &server->config.insertDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - InsertEventCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_INSERTEVENTCAPABILITY,
+                                   &server->config.insertEventCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - InsertAnnotationsCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_INSERTANNOTATIONCAPABILITY,
+                                   &server->config.insertAnnotationsCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - ReplaceDataCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_REPLACEDATACAPABILITY,
+                                   &server->config.replaceDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - ReplaceEventCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_REPLACEEVENTCAPABILITY,
+                                   &server->config.replaceEventCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - UpdateDataCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_UPDATEDATACAPABILITY,
+                                   &server->config.updateDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - UpdateEventCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_UPDATEEVENTCAPABILITY,
+                                   &server->config.updateEventCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - DeleteRawCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_DELETERAWCAPABILITY,
+                                   &server->config.deleteRawCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - DeleteEventCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_DELETEEVENTCAPABILITY,
+                                   &server->config.deleteEventCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+
+        /* ServerCapabilities - HistoryServerCapabilities - DeleteAtTimeDataCapability */
+        retVal |= writeNs0Variable(server, UA_NS0ID_HISTORYSERVERCAPABILITIES_DELETEATTIMECAPABILITY,
+                                   &server->config.deleteAtTimeDataCapability, &UA_TYPES[UA_TYPES_BOOLEAN]);
+    }
 #endif
 
 #if defined(UA_ENABLE_METHODCALLS) && defined(UA_ENABLE_SUBSCRIPTIONS)
@@ -2,7 +2,7 @@ package main
 
 /*
 #cgo CFLAGS: -I. -std=c99
-#cgo LDFLAGS: -lws2_32 -lIphlpapi
+#cgo LDFLAGS: -lmbedtls -lmbedx509 -lmbedcrypto -lbcrypt -ladvapi32 -lws2_32 -lIphlpapi
 
 #include <stdlib.h>
 #include <stdio.h>
@@ -15,41 +15,313 @@ import "C"
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/astaxie/beego/logs"
 )
 
+const CLIENT_PIPELINE_MAX = 16
+
 type Client struct {
-	addr    string
-	cli     uintptr
-	cLogger C.UA_Logger
+	sync.Mutex
+	addr        string
+	cli         uintptr
+	eventSub    uint32
+	eventHandle uint64
+	types       *TypeRegistry
+	maxRead     int
+	maxWrite    int
+	pipeline    int
+	namespaces  []string
+	missing     map[string]bool
+	cLogger     C.UA_Logger
 }
 
 type Server struct {
 	sync.WaitGroup
 	addr      string
 	running   bool
+	nsMutex   sync.RWMutex
 	namespace map[string]uint32
+	access    AccessHandler
+	denied    DeniedHandler
+	history   HistoryHandler
+	method    MethodHandler
+	tasks     chan func()
+	stopped   chan struct{}
 	srv       uintptr
 	cLogger   C.UA_Logger
 }
 
+type AccessPermission struct {
+	Read   bool `json:"read"`
+	Write  bool `json:"write"`
+	Browse bool `json:"browse"`
+}
+
+type AccessHandler func(user string, namespace string, nodeID string) AccessPermission
+
+// DeniedHandler is called when the browse, the read, the write or the method call of the user is rejected
+type DeniedHandler func(user string, namespace string, nodeID string, operation string)
+
+var serverRegistry sync.Map
+
+const (
+	HISTORY_AGGREGATE_RAW           uint32 = 0
+	HISTORY_AGGREGATE_INTERPOLATIVE uint32 = C.UA_NS0ID_AGGREGATEFUNCTION_INTERPOLATIVE
+	HISTORY_AGGREGATE_AVERAGE       uint32 = C.UA_NS0ID_AGGREGATEFUNCTION_AVERAGE
+	HISTORY_AGGREGATE_MINIMUM       uint32 = C.UA_NS0ID_AGGREGATEFUNCTION_MINIMUM
+	HISTORY_AGGREGATE_MAXIMUM       uint32 = C.UA_NS0ID_AGGREGATEFUNCTION_MAXIMUM
+	HISTORY_AGGREGATE_COUNT         uint32 = C.UA_NS0ID_AGGREGATEFUNCTION_COUNT
+)
+
+const (
+	UA_STATUS_GOOD                           uint32 = C.UA_STATUSCODE_GOOD
+	UA_STATUS_BADNODATA                      uint32 = C.UA_STATUSCODE_BADNODATA
+	UA_STATUS_BADNODEIDUNKNOWN               uint32 = C.UA_STATUSCODE_BADNODEIDUNKNOWN
+	UA_STATUS_BADINVALIDARGUMENT             uint32 = C.UA_STATUSCODE_BADINVALIDARGUMENT
+	UA_STATUS_BADCONTINUATIONPOINTINVALID    uint32 = C.UA_STATUSCODE_BADCONTINUATIONPOINTINVALID
+	UA_STATUS_BADAGGREGATENOTSUPPORTED       uint32 = C.UA_STATUSCODE_BADAGGREGATENOTSUPPORTED
+	UA_STATUS_BADDATAENCODINGINVALID         uint32 = C.UA_STATUSCODE_BADDATAENCODINGINVALID
+	UA_STATUS_BADHISTORYOPERATIONUNSUPPORTED uint32 = C.UA_STATUSCODE_BADHISTORYOPERATIONUNSUPPORTED
+	UA_STATUS_BADINTERNALERROR               uint32 = C.UA_STATUSCODE_BADINTERNALERROR
+	UA_STATUS_BADMETHODINVALID               uint32 = C.UA_STATUSCODE_BADMETHODINVALID
+	UA_STATUS_BADTYPEMISMATCH                uint32 = C.UA_STATUSCODE_BADTYPEMISMATCH
+	UA_STATUS_BADUSERACCESSDENIED            uint32 = C.UA_STATUSCODE_BADUSERACCESSDENIED
+	UA_STATUS_BADNOTCONNECTED                uint32 = C.UA_STATUSCODE_BADNOTCONNECTED
+	UA_STATUS_BADRESOURCEUNAVAILABLE         uint32 = C.UA_STATUSCODE_BADRESOURCEUNAVAILABLE
+	UA_STATUS_BADTIMEOUT                     uint32 = C.UA_STATUSCODE_BADTIMEOUT
+	UA_STATUS_SEVERITY_BAD                   uint32 = 0x80000000
+)
+
+// HistoryRequest is one node of a history read, zero Start or End means unspecified
+type HistoryRequest struct {
+	Namespace         string
+	NodeID            string
+	Start             time.Time
+	End               time.Time
+	MaxValues         uint32
+	Aggregate         uint32
+	Interval          time.Duration
+	ContinuationPoint []byte
+	Release           bool
+}
+
+type HistoryValue struct {
+	Value  *NodeValue
+	Time   time.Time
+	Status uint32
+}
+
+type HistoryResponse struct {
+	Values            []HistoryValue
+	ContinuationPoint []byte
+}
+
+type HistoryHandler func(request HistoryRequest) (*HistoryResponse, uint32)
+
+type MethodArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	DataType    uint32 `json:"dataType"`
+	ValueRank   int32  `json:"valueRank"`
+}
+
+const (
+	EVENT_OPERATOR_EQUALS             uint32 = C.UA_FILTEROPERATOR_EQUALS
+	EVENT_OPERATOR_GREATERTHAN        uint32 = C.UA_FILTEROPERATOR_GREATERTHAN
+	EVENT_OPERATOR_LESSTHAN           uint32 = C.UA_FILTEROPERATOR_LESSTHAN
+	EVENT_OPERATOR_GREATERTHANOREQUAL uint32 = C.UA_FILTEROPERATOR_GREATERTHANOREQUAL
+	EVENT_OPERATOR_LESSTHANOREQUAL    uint32 = C.UA_FILTEROPERATOR_LESSTHANOREQUAL
+	EVENT_OPERATOR_LIKE               uint32 = C.UA_FILTEROPERATOR_LIKE
+	EVENT_OPERATOR_OFTYPE             uint32 = C.UA_FILTEROPERATOR_OFTYPE
+)
+
+const UA_BASEEVENTTYPE uint32 = C.UA_NS0ID_BASEEVENTTYPE
+
+// EventWhere is one term of the event where clause, Type is used by OfType only
+type EventWhere struct {
+	Operator uint32
+	Field    string
+	TypeNs   uint16
+	TypeID   uint32
+	Value    NodeValue
+}
+
+// EventField is one select field of the received event, Text is set even if the value type is not supported
+type EventField struct {
+	Name  string
+	Value *NodeValue
+	Text  string
+}
+
+type EventHandler func(fields []EventField)
+
+type ServerEvent struct {
+	Type       uint32
+	Time       time.Time
+	Severity   uint16
+	Message    string
+	SourceName string
+}
+
+type MethodHandler func(user string, namespace string, nodeID string, inputs []*NodeValue) ([]*NodeValue, uint32)
+
 type NodeInfo struct {
 	NsIndex uint32
+	NsUri   string `json:",omitempty"`
 	NodeID  string
+	IdType  string `json:",omitempty"`
+	Field   string `json:",omitempty"`
+	Range   string `json:",omitempty"`
+
+	// the client node settings, the zero values keep the default behavior
+	Alias    string  `json:",omitempty"`
+	Sampling int     `json:",omitempty"` // milliseconds, 0 reads the node every client cycle
+	Scale    float64 `json:",omitempty"` // 0 is no scaling
+	Offset   float64 `json:",omitempty"`
+	NoStore  bool    `json:",omitempty"`
+}
+
+// node id identifier types, the empty type is the string identifier
+const (
+	NODEID_STRING  = "s"
+	NODEID_NUMERIC = "i"
+	NODEID_GUID    = "g"
+	NODEID_BYTES   = "b"
+)
+
+type VariableType int
+
+const (
+	VARIABLE_BASE VariableType = iota
+	VARIABLE_ANALOG
+	VARIABLE_TWOSTATE
+)
+
+var variableTypeNames = []string{"BaseDataVariable", "AnalogItem", "TwoStateDiscrete"}
+
+func (t VariableType) String() string {
+	if int(t) < 0 || int(t) >= len(variableTypeNames) {
+		return "UNKNOWN"
+	}
+	return variableTypeNames[t]
+}
+
+func VariableTypeNames() []string {
+	return variableTypeNames
+}
+
+const (
+	UA_ACCESSLEVEL_READ         uint8 = 0x01
+	UA_ACCESSLEVEL_WRITE        uint8 = 0x02
+	UA_ACCESSLEVEL_HISTORYREAD  uint8 = 0x04
+	UA_ACCESSLEVEL_HISTORYWRITE uint8 = 0x08
+)
+
+const (
+	UA_ATTRIBUTEID_BROWSENAME         uint32 = 3
+	UA_ATTRIBUTEID_VALUE              uint32 = 13
+	UA_ATTRIBUTEID_DATATYPEDEFINITION uint32 = 23
+)
+
+const (
+	UA_REFERENCE_HIERARCHICAL   uint32 = 33
+	UA_REFERENCE_HASENCODING    uint32 = 38
+	UA_REFERENCE_HASDESCRIPTION uint32 = 39
+	UA_REFERENCE_HASSUBTYPE     uint32 = 45
+	UA_REFERENCE_HASCOMPONENT   uint32 = 47
+)
+
+const UA_UNITS_NAMESPACE = "http://www.opcfoundation.org/UA/units/un/cefact"
+
+type NodeMeta struct {
+	Type            VariableType `json:"type"`
+	DisplayName     string       `json:"displayName"`
+	Description     string       `json:"description"`
+	AccessLevel     uint8        `json:"accessLevel"`
+	RangeLow        float64      `json:"rangeLow"`
+	RangeHigh       float64      `json:"rangeHigh"`
+	HasUnits        bool         `json:"hasUnits"`
+	UnitNamespace   string       `json:"unitNamespace"`
+	UnitID          int32        `json:"unitId"`
+	UnitName        string       `json:"unitName"`
+	UnitDescription string       `json:"unitDescription"`
+	TrueState       string       `json:"trueState"`
+	FalseState      string       `json:"falseState"`
+}
+
+func (m NodeMeta) Compatible(value NodeValue) VariableType {
+	switch m.Type {
+	case VARIABLE_ANALOG:
+		switch value.Type {
+		case UA_INT8, UA_UINT8, UA_INT16, UA_UINT16, UA_INT32, UA_UINT32,
+			UA_INT64, UA_UINT64, UA_FLOAT, UA_DOUBLE:
+			return VARIABLE_ANALOG
+		}
+	case VARIABLE_TWOSTATE:
+		if value.Type == UA_BOOLEAN && !value.Array {
+			return VARIABLE_TWOSTATE
+		}
+	}
+	return VARIABLE_BASE
 }
 
 type NodeTree struct {
-	Level    uint32
-	Node     NodeInfo
+	Level uint32
+	BrowseItem
 	SubNodes []*NodeTree
 }
 
+const (
+	UA_NODECLASS_OBJECT   uint32 = 1
+	UA_NODECLASS_VARIABLE uint32 = 2
+	UA_NODECLASS_METHOD   uint32 = 4
+)
+
+const (
+	BROWSE_ROOT_NODE   = "i=85"
+	BROWSE_LEVEL_LIMIT = 16
+)
+
+type BrowseItem struct {
+	NodeID      string
+	Node        NodeInfo
+	BrowseName  string
+	DisplayName string
+	NodeClass   uint32
+	DataType    string
+	AccessLevel uint8
+}
+
+func (b BrowseItem) ClassName() string {
+	switch b.NodeClass {
+	case UA_NODECLASS_OBJECT:
+		return "Object"
+	case UA_NODECLASS_VARIABLE:
+		return "Variable"
+	case UA_NODECLASS_METHOD:
+		return "Method"
+	}
+	return fmt.Sprintf("Class(%d)", b.NodeClass)
+}
+
+func (b BrowseItem) Name() string {
+	if b.DisplayName != "" {
+		return b.DisplayName
+	}
+	return b.BrowseName
+}
+
 type ValueType int
 
 const (
@@ -66,15 +338,15 @@ const (
 	UA_DOUBLE
 	UA_STRING
 	UA_DATETIME
-	// UA_GUID
+	UA_GUID
 	UA_BYTESTRING
 	// UA_XMLELEMENT
-	// UA_NODEID
+	UA_NODEID
 	// UA_EXPANDEDNODEID
-	// UA_STATUSCODE
-	// UA_QUALIFIEDNAME
-	// UA_LOCALIZEDTEXT
-	// UA_EXTENSIONOBJECT
+	UA_STATUSCODE
+	UA_QUALIFIEDNAME
+	UA_LOCALIZEDTEXT
+	UA_EXTENSIONOBJECT
 	// UA_DATAVALUE
 	// UA_VARIANT
 	// UA_DIAGNOSTICINFO
@@ -86,11 +358,117 @@ type NodeValue struct {
 	Value interface{} /*
 		if array is true, the value type is : []bool, []int8, []uint8, []int16, []uint16, []int32, []uint32, []int64, []uint64, []float, []double, []string, [][]byte
 		if array is false, the value type is : bool, int8, uint8, int16, uint16, int32, uint32, int64, uint64, float, double, string, []byte
+		guid and nodeid are string, statuscode is uint32, qualifiedname, localizedtext and extensionobject are the structs below
 	*/
+	Dims []uint32 // the dimensions of the multi dimension array, the value keeps the flat slice in row major order
+}
+
+type QualifiedName struct {
+	NamespaceIndex uint16 `json:"namespaceIndex"`
+	Name           string `json:"name"`
+}
+
+type LocalizedText struct {
+	Locale string `json:"locale"`
+	Text   string `json:"text"`
+}
+
+// ExtensionObject keeps the binary body to be republished as it is, the
+// decoded value is only for display and storage
+type ExtensionObject struct {
+	TypeID string      `json:"typeId"`
+	Type   string      `json:"type,omitempty"`
+	Body   []byte      `json:"body"`
+	Value  interface{} `json:"value,omitempty"`
+}
+
+func (q QualifiedName) String() string {
+	if q.NamespaceIndex == 0 {
+		return q.Name
+	}
+	return fmt.Sprintf("%d:%s", q.NamespaceIndex, q.Name)
+}
+
+func ParseQualifiedName(text string) QualifiedName {
+	if index := strings.Index(text, ":"); index > 0 {
+		ns, err := strconv.ParseUint(text[:index], 10, 16)
+		if err == nil {
+			return QualifiedName{NamespaceIndex: uint16(ns), Name: text[index+1:]}
+		}
+	}
+	return QualifiedName{Name: text}
+}
+
+func (l LocalizedText) String() string {
+	if l.Locale == "" {
+		return l.Text
+	}
+	body, _ := json.Marshal(l)
+	return string(body)
+}
+
+func ParseLocalizedText(text string) LocalizedText {
+	var value LocalizedText
+	if strings.HasPrefix(text, "{") && json.Unmarshal([]byte(text), &value) == nil {
+		return value
+	}
+	return LocalizedText{Text: text}
+}
+
+func (e ExtensionObject) String() string {
+	body, err := json.Marshal(e)
+	if err != nil {
+		logs.Error("extension object %s marshal failed, %s", e.TypeID, err.Error())
+		return ""
+	}
+	return string(body)
+}
+
+func ParseExtensionObject(text string) (ExtensionObject, error) {
+	var value ExtensionObject
+	err := json.Unmarshal([]byte(text), &value)
+	if err != nil {
+		return value, fmt.Errorf("extension object unmarshal failed, %s", err.Error())
+	}
+	if value.TypeID == "" {
+		return value, fmt.Errorf("extension object type id is empty")
+	}
+	return value, nil
+}
+
+func (e ExtensionObject) Clone() ExtensionObject {
+	return ExtensionObject{TypeID: e.TypeID, Type: e.Type, Body: ByteClone(e.Body), Value: e.Value}
+}
+
+func (e ExtensionObject) Equal(b ExtensionObject) bool {
+	return e.TypeID == b.TypeID && bytes.Equal(e.Body, b.Body)
+}
+
+func StatusCodeName(code uint32) string {
+	return C.GoString(C.UA_StatusCodeText(C.UA_StatusCode(code)))
+}
+
+func StatusCodeString(code uint32) string {
+	return fmt.Sprintf("%s (0x%08X)", StatusCodeName(code), code)
+}
+
+func ParseStatusCode(text string) (uint32, error) {
+	begin := strings.LastIndex(text, "(0x")
+	if begin >= 0 && strings.HasSuffix(text, ")") {
+		text = text[begin+1 : len(text)-1]
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(text), 0, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(value), nil
 }
 
 func (v *NodeValue) Clone() *NodeValue {
 	value := &NodeValue{Type: v.Type, Array: v.Array}
+	if len(v.Dims) > 0 {
+		value.Dims = Uint32ListClone(v.Dims)
+	}
 
 	switch v.Type {
 	case UA_BOOLEAN:
@@ -177,6 +555,36 @@ func (v *NodeValue) Clone() *NodeValue {
 		} else {
 			value.Value = ByteClone(v.Value.([]byte))
 		}
+	case UA_GUID, UA_NODEID:
+		if v.Array {
+			value.Value = StringListClone(v.Value.([]string))
+		} else {
+			value.Value = v.Value.(string)
+		}
+	case UA_STATUSCODE:
+		if v.Array {
+			value.Value = Uint32ListClone(v.Value.([]uint32))
+		} else {
+			value.Value = v.Value.(uint32)
+		}
+	case UA_QUALIFIEDNAME:
+		if v.Array {
+			value.Value = QualifiedNameListClone(v.Value.([]QualifiedName))
+		} else {
+			value.Value = v.Value.(QualifiedName)
+		}
+	case UA_LOCALIZEDTEXT:
+		if v.Array {
+			value.Value = LocalizedTextListClone(v.Value.([]LocalizedText))
+		} else {
+			value.Value = v.Value.(LocalizedText)
+		}
+	case UA_EXTENSIONOBJECT:
+		if v.Array {
+			value.Value = ExtensionObjectListClone(v.Value.([]ExtensionObject))
+		} else {
+			value.Value = v.Value.(ExtensionObject).Clone()
+		}
 	default:
 		logs.Error("NodeValue clone failed, unsupported type %v", v.Type)
 		return nil
@@ -188,6 +596,11 @@ func (v *NodeValue) Compare(b *NodeValue) bool {
 	if v.Type != b.Type || v.Array != b.Array {
 		return false
 	}
+	if len(v.Dims) > 1 || len(b.Dims) > 1 {
+		if !Uint32ListCompare(v.Dims, b.Dims) {
+			return false
+		}
+	}
 	switch v.Type {
 	case UA_BOOLEAN:
 		if v.Array {
@@ -259,6 +672,31 @@ func (v *NodeValue) Compare(b *NodeValue) bool {
 			return ByteListCompare(v.Value.([][]byte), b.Value.([][]byte))
 		}
 		return bytes.Equal(v.Value.([]byte), b.Value.([]byte))
+	case UA_GUID, UA_NODEID:
+		if v.Array {
+			return StringListCompare(v.Value.([]string), b.Value.([]string))
+		}
+		return v.Value.(string) == b.Value.(string)
+	case UA_STATUSCODE:
+		if v.Array {
+			return Uint32ListCompare(v.Value.([]uint32), b.Value.([]uint32))
+		}
+		return v.Value.(uint32) == b.Value.(uint32)
+	case UA_QUALIFIEDNAME:
+		if v.Array {
+			return QualifiedNameListCompare(v.Value.([]QualifiedName), b.Value.([]QualifiedName))
+		}
+		return v.Value.(QualifiedName) == b.Value.(QualifiedName)
+	case UA_LOCALIZEDTEXT:
+		if v.Array {
+			return LocalizedTextListCompare(v.Value.([]LocalizedText), b.Value.([]LocalizedText))
+		}
+		return v.Value.(LocalizedText) == b.Value.(LocalizedText)
+	case UA_EXTENSIONOBJECT:
+		if v.Array {
+			return ExtensionObjectListCompare(v.Value.([]ExtensionObject), b.Value.([]ExtensionObject))
+		}
+		return v.Value.(ExtensionObject).Equal(b.Value.(ExtensionObject))
 	default:
 		logs.Error("NodeValue compare failed, unsupported type %v", v.Type)
 		return false
@@ -266,6 +704,9 @@ func (v *NodeValue) Compare(b *NodeValue) bool {
 }
 
 func (v *NodeValue) ToString() string {
+	if v.Array && len(v.Dims) > 1 {
+		return v.MatrixString()
+	}
 	switch v.Type {
 	case UA_BOOLEAN:
 		if v.Array {
@@ -337,12 +778,139 @@ func (v *NodeValue) ToString() string {
 			return ByteListToString(v.Value.([][]byte))
 		}
 		return base64.StdEncoding.EncodeToString(v.Value.([]byte))
+	case UA_GUID, UA_NODEID:
+		if v.Array {
+			return StringListToString(v.Value.([]string))
+		}
+		return v.Value.(string)
+	case UA_STATUSCODE:
+		if v.Array {
+			return StatusCodeListToString(v.Value.([]uint32))
+		}
+		return StatusCodeString(v.Value.(uint32))
+	case UA_QUALIFIEDNAME:
+		if v.Array {
+			return QualifiedNameListToString(v.Value.([]QualifiedName))
+		}
+		return v.Value.(QualifiedName).String()
+	case UA_LOCALIZEDTEXT:
+		if v.Array {
+			return LocalizedTextListToString(v.Value.([]LocalizedText))
+		}
+		return v.Value.(LocalizedText).String()
+	case UA_EXTENSIONOBJECT:
+		if v.Array {
+			return ExtensionObjectListToString(v.Value.([]ExtensionObject))
+		}
+		return v.Value.(ExtensionObject).String()
 	default:
 		logs.Error("NodeValue toString failed, unsupported type %v", v.Type)
 		return ""
 	}
 }
 
+// MatrixString formats the multi dimension array as the nested json array
+func (v *NodeValue) MatrixString() string {
+	items := reflect.ValueOf(v.Value)
+	if items.Kind() != reflect.Slice || MatrixLength(v.Dims) != items.Len() {
+		flat := &NodeValue{Type: v.Type, Array: true, Value: v.Value}
+		return flat.ToString()
+	}
+
+	numeric := false
+	switch v.Type {
+	case UA_BOOLEAN, UA_INT8, UA_UINT8, UA_INT16, UA_UINT16, UA_INT32, UA_UINT32,
+		UA_INT64, UA_UINT64, UA_FLOAT, UA_DOUBLE, UA_DATETIME, UA_STATUSCODE:
+		numeric = true
+	}
+
+	elements := make([]string, items.Len())
+	for i := range elements {
+		element := &NodeValue{Type: v.Type, Value: items.Index(i).Interface()}
+		text := element.ToString()
+		if !numeric || !json.Valid([]byte(text)) {
+			body, _ := json.Marshal(text)
+			text = string(body)
+		}
+		elements[i] = text
+	}
+
+	var buffer bytes.Buffer
+	var nested func(dim int, offset int) int
+	nested = func(dim int, offset int) int {
+		buffer.WriteString("[")
+		for i := 0; i < int(v.Dims[dim]); i++ {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			if dim+1 == len(v.Dims) {
+				buffer.WriteString(elements[offset])
+				offset++
+			} else {
+				offset = nested(dim+1, offset)
+			}
+		}
+		buffer.WriteString("]")
+		return offset
+	}
+	nested(0, 0)
+	return buffer.String()
+}
+
+// StoreString returns the text of the stored history value, the array is the json array so
+// the string elements keep their commas
+func (v *NodeValue) StoreString() string {
+	if !v.Array || len(v.Dims) > 1 {
+		return v.ToString()
+	}
+	items := reflect.ValueOf(v.Value)
+	if items.Kind() != reflect.Slice {
+		return v.ToString()
+	}
+	list := &NodeValue{Type: v.Type, Array: true, Dims: []uint32{uint32(items.Len())}, Value: v.Value}
+	return list.MatrixString()
+}
+
+func (v *NodeValue) ToFloat() (float64, bool) {
+	if v.Array {
+		return 0, false
+	}
+	switch v.Type {
+	case UA_BOOLEAN:
+		if v.Value.(bool) {
+			return 1, true
+		}
+		return 0, true
+	case UA_INT8:
+		return float64(v.Value.(int8)), true
+	case UA_UINT8:
+		return float64(v.Value.(uint8)), true
+	case UA_INT16:
+		return float64(v.Value.(int16)), true
+	case UA_UINT16:
+		return float64(v.Value.(uint16)), true
+	case UA_INT32:
+		return float64(v.Value.(int32)), true
+	case UA_UINT32:
+		return float64(v.Value.(uint32)), true
+	case UA_INT64:
+		return float64(v.Value.(int64)), true
+	case UA_UINT64:
+		return float64(v.Value.(uint64)), true
+	case UA_FLOAT:
+		return float64(v.Value.(float32)), true
+	case UA_DOUBLE:
+		return v.Value.(float64), true
+	case UA_STRING:
+		val, err := strconv.ParseFloat(v.Value.(string), 64)
+		if err != nil {
+			return 0, false
+		}
+		return val, true
+	}
+	return 0, false
+}
+
 func (v *NodeValue) FromString(values []string) error {
 	if !v.Array && len(values) > 1 {
 		return fmt.Errorf("ua client node value from string failed, the node value type is not array")
@@ -571,21 +1139,193 @@ func (v *NodeValue) FromString(values []string) error {
 		} else {
 			v.Value = values[0]
 		}
+	case UA_GUID, UA_NODEID:
+		if v.Array {
+			arrayList := v.Value.([]string)
+			length := SmallLength(len(arrayList), len(values))
+			for i := 0; i < length; i++ {
+				arrayList[i] = strings.TrimSpace(values[i])
+			}
+		} else {
+			v.Value = strings.TrimSpace(values[0])
+		}
+	case UA_STATUSCODE:
+		if v.Array {
+			arrayList := v.Value.([]uint32)
+			length := SmallLength(len(arrayList), len(values))
+			for i := 0; i < length; i++ {
+				val, err := ParseStatusCode(values[i])
+				if err != nil {
+					return fmt.Errorf("ua client node value from string failed, strconv.statuscode %s", err.Error())
+				}
+				arrayList[i] = val
+			}
+		} else {
+			val, err := ParseStatusCode(values[0])
+			if err != nil {
+				return fmt.Errorf("ua client node value from string failed, strconv.statuscode %s", err.Error())
+			}
+			v.Value = val
+		}
+	case UA_QUALIFIEDNAME:
+		if v.Array {
+			arrayList := v.Value.([]QualifiedName)
+			length := SmallLength(len(arrayList), len(values))
+			for i := 0; i < length; i++ {
+				arrayList[i] = ParseQualifiedName(values[i])
+			}
+		} else {
+			v.Value = ParseQualifiedName(values[0])
+		}
+	case UA_LOCALIZEDTEXT:
+		if v.Array {
+			arrayList := v.Value.([]LocalizedText)
+			length := SmallLength(len(arrayList), len(values))
+			for i := 0; i < length; i++ {
+				arrayList[i] = ParseLocalizedText(values[i])
+			}
+		} else {
+			v.Value = ParseLocalizedText(values[0])
+		}
+	case UA_EXTENSIONOBJECT:
+		if v.Array {
+			arrayList := v.Value.([]ExtensionObject)
+			length := SmallLength(len(arrayList), len(values))
+			for i := 0; i < length; i++ {
+				val, err := ParseExtensionObject(values[i])
+				if err != nil {
+					return fmt.Errorf("ua client node value from string failed, %s", err.Error())
+				}
+				arrayList[i] = val
+			}
+		} else {
+			val, err := ParseExtensionObject(values[0])
+			if err != nil {
+				return fmt.Errorf("ua client node value from string failed, %s", err.Error())
+			}
+			v.Value = val
+		}
 	default:
 		return fmt.Errorf("ua client node value from string failed, type = %d not support", uint32(v.Type))
 	}
 	return nil
 }
 
-func (n NodeInfo) ToString() string {
-	return fmt.Sprintf("ns=%d,s=%s", n.NsIndex, n.NodeID)
+func (n NodeInfo) Type() string {
+	if n.IdType == "" {
+		return NODEID_STRING
+	}
+	return n.IdType
 }
 
-func (n NodeInfo) Compare(b NodeInfo) bool {
-	return n.NsIndex == b.NsIndex && n.NodeID == b.NodeID
+func (n NodeInfo) ToString() string {
+	text := fmt.Sprintf("ns=%d,%s=%s", n.NsIndex, n.Type(), n.NodeID)
+	if n.Range != "" {
+		text += "[" + n.Range + "]"
+	}
+	if n.Field != "" {
+		text += "#" + n.Field
+	}
+	return text
 }
 
-/*
+// Key is the node id with the index range and structure field path, used for the column and server names
+func (n NodeInfo) Key() string {
+	key := n.NodeID
+	if n.Type() != NODEID_STRING {
+		key = n.IdType + "=" + key
+	}
+	if n.Range != "" {
+		key += "[" + n.Range + "]"
+	}
+	if n.Field != "" {
+		key += "." + n.Field
+	}
+	return key
+}
+
+// Name is the user alias of the node, or the node key without alias
+func (n NodeInfo) Name() string {
+	if n.Alias != "" {
+		return n.Alias
+	}
+	return n.Key()
+}
+
+func (n NodeInfo) Compare(b NodeInfo) bool {
+	return n.NsIndex == b.NsIndex && n.NodeID == b.NodeID && n.Type() == b.Type() && n.Field == b.Field && n.Range == b.Range
+}
+
+// Scaled converts the numeric scalar value to value * Scale + Offset as a double
+func (n NodeInfo) Scaled(value *NodeValue) *NodeValue {
+	if (n.Scale == 0 && n.Offset == 0) || value == nil || value.Value == nil {
+		return value
+	}
+	if value.Type == UA_BOOLEAN || value.Type == UA_STRING {
+		return value
+	}
+	number, ok := value.ToFloat()
+	if !ok {
+		return value
+	}
+	scale := n.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return &NodeValue{Type: UA_DOUBLE, Value: number*scale + n.Offset}
+}
+
+// Text is the node id in the open62541 text format, ns=1;i=100
+func (n NodeInfo) Text() string {
+	return fmt.Sprintf("ns=%d;%s=%s", n.NsIndex, n.Type(), n.NodeID)
+}
+
+// NodeInfoParse parses the node id text, ns=1;i=100 or s=name without the namespace
+func NodeInfoParse(text string) (NodeInfo, error) {
+	var node NodeInfo
+	if strings.HasPrefix(text, "ns=") {
+		index := strings.Index(text, ";")
+		if index < 0 {
+			return node, fmt.Errorf("node id %s has no identifier", text)
+		}
+		ns, err := strconv.ParseUint(text[3:index], 10, 16)
+		if err != nil {
+			return node, fmt.Errorf("node id %s namespace invalid, %s", text, err.Error())
+		}
+		node.NsIndex = uint32(ns)
+		text = text[index+1:]
+	}
+	if len(text) < 2 || text[1] != '=' {
+		return node, fmt.Errorf("node id %s identifier type invalid", text)
+	}
+	switch idType := text[:1]; idType {
+	case NODEID_NUMERIC, NODEID_GUID, NODEID_BYTES:
+		node.IdType = idType
+	case NODEID_STRING:
+	default:
+		return node, fmt.Errorf("node id %s identifier type %s not support", text, idType)
+	}
+	node.NodeID = text[2:]
+	return node, nil
+}
+
+// uaNodeID returns the C node id over the identifier memory, the caller frees the
+// memory after the node id is used
+func (n NodeInfo) uaNodeID() (C.UA_NodeId, unsafe.Pointer) {
+	idType := n.Type()
+	if idType == NODEID_BYTES {
+		body, err := base64.StdEncoding.DecodeString(n.NodeID)
+		if err != nil {
+			body = []byte(n.NodeID)
+		}
+		cBody := C.CBytes(body)
+		return C.UA_NodeIdMake(C.UA_UInt16(n.NsIndex), C.char(idType[0]), (*C.char)(cBody), C.size_t(len(body))), cBody
+	}
+	cID := C.CString(n.NodeID)
+	return C.UA_NodeIdMake(C.UA_UInt16(n.NsIndex), C.char(idType[0]), cID, C.size_t(len(n.NodeID))), unsafe.Pointer(cID)
+}
+
+/*
 
 typedef enum {
     UA_LOGLEVEL_TRACE = 0,
@@ -790,10 +1530,75 @@ func UA_VariantToArrayValue(variant *C.UA_Variant) (*NodeValue, error) {
 			arrayValue = arrayList
 			valueType = UA_BYTESTRING
 		}
+	case C.UA_TYPES_GUID:
+		{
+			arrayList := make([]string, length)
+			for i := 0; i < int(length); i++ {
+				arrayList[i] = uaGuidString((*C.UA_Guid)(C.UA_VariantValueAt(variant, C.int(i))))
+			}
+			arrayValue = arrayList
+			valueType = UA_GUID
+		}
+	case C.UA_TYPES_NODEID:
+		{
+			arrayList := make([]string, length)
+			for i := 0; i < int(length); i++ {
+				arrayList[i] = uaNodeIdString((*C.UA_NodeId)(C.UA_VariantValueAt(variant, C.int(i))))
+			}
+			arrayValue = arrayList
+			valueType = UA_NODEID
+		}
+	case C.UA_TYPES_STATUSCODE:
+		{
+			arrayList := make([]uint32, length)
+			for i := 0; i < int(length); i++ {
+				arrayList[i] = uint32(C.UA_VariantValueUint32(variant, C.int(i)))
+			}
+			arrayValue = arrayList
+			valueType = UA_STATUSCODE
+		}
+	case C.UA_TYPES_QUALIFIEDNAME:
+		{
+			arrayList := make([]QualifiedName, length)
+			for i := 0; i < int(length); i++ {
+				arrayList[i] = uaQualifiedName((*C.UA_QualifiedName)(C.UA_VariantValueAt(variant, C.int(i))))
+			}
+			arrayValue = arrayList
+			valueType = UA_QUALIFIEDNAME
+		}
+	case C.UA_TYPES_LOCALIZEDTEXT:
+		{
+			arrayList := make([]LocalizedText, length)
+			for i := 0; i < int(length); i++ {
+				arrayList[i] = uaLocalizedText((*C.UA_LocalizedText)(C.UA_VariantValueAt(variant, C.int(i))))
+			}
+			arrayValue = arrayList
+			valueType = UA_LOCALIZEDTEXT
+		}
+	case C.UA_TYPES_EXTENSIONOBJECT:
+		{
+			arrayList := make([]ExtensionObject, length)
+			for i := 0; i < int(length); i++ {
+				object, err := uaExtensionObject((*C.UA_ExtensionObject)(C.UA_VariantValueAt(variant, C.int(i))))
+				if err != nil {
+					return nil, err
+				}
+				arrayList[i] = object
+			}
+			arrayValue = arrayList
+			valueType = UA_EXTENSIONOBJECT
+		}
 	default:
 		return nil, fmt.Errorf("ua client covert variant to node value failed, type = %d", uint32(uaType))
 	}
-	return &NodeValue{Type: valueType, Array: true, Value: arrayValue}, nil
+	dims := make([]uint32, 0)
+	for i := C.size_t(0); i < variant.arrayDimensionsSize; i++ {
+		dims = append(dims, uint32(C.UA_VariantDimension(variant, i)))
+	}
+	if len(dims) < 2 {
+		dims = nil
+	}
+	return &NodeValue{Type: valueType, Array: true, Value: arrayValue, Dims: dims}, nil
 }
 
 func UA_VariantToSingleValue(variant *C.UA_Variant) (*NodeValue, error) {
@@ -853,6 +1658,28 @@ func UA_VariantToSingleValue(variant *C.UA_Variant) (*NodeValue, error) {
 		C.UA_VariantValueByteString(variant, 0, &cString)
 		value = C.GoBytes(unsafe.Pointer(cString.data), C.int(cString.length))
 		valueType = UA_BYTESTRING
+	case C.UA_TYPES_GUID:
+		value = uaGuidString((*C.UA_Guid)(variant.data))
+		valueType = UA_GUID
+	case C.UA_TYPES_NODEID:
+		value = uaNodeIdString((*C.UA_NodeId)(variant.data))
+		valueType = UA_NODEID
+	case C.UA_TYPES_STATUSCODE:
+		value = uint32(C.UA_VariantValueUint32(variant, 0))
+		valueType = UA_STATUSCODE
+	case C.UA_TYPES_QUALIFIEDNAME:
+		value = uaQualifiedName((*C.UA_QualifiedName)(variant.data))
+		valueType = UA_QUALIFIEDNAME
+	case C.UA_TYPES_LOCALIZEDTEXT:
+		value = uaLocalizedText((*C.UA_LocalizedText)(variant.data))
+		valueType = UA_LOCALIZEDTEXT
+	case C.UA_TYPES_EXTENSIONOBJECT:
+		object, err := uaExtensionObject((*C.UA_ExtensionObject)(variant.data))
+		if err != nil {
+			return nil, err
+		}
+		value = object
+		valueType = UA_EXTENSIONOBJECT
 	default:
 		return nil, fmt.Errorf("ua client covert variant to node value failed, type = %d", uint32(uaType))
 	}
@@ -904,6 +1731,35 @@ func UA_VariantFromSingleValue(value NodeValue, variant *C.UA_Variant) error {
 		defer C.free(cString)
 
 		retval = C.UA_VariantScalarValueByteString(variant, cString, C.size_t(len(value.Value.([]byte))))
+	case UA_GUID:
+		cString := C.CString(value.Value.(string))
+		defer C.free(unsafe.Pointer(cString))
+		retval = C.UA_VariantScalarValueGuid(variant, cString)
+	case UA_NODEID:
+		cString := C.CString(value.Value.(string))
+		defer C.free(unsafe.Pointer(cString))
+		retval = C.UA_VariantScalarValueNodeId(variant, cString)
+	case UA_STATUSCODE:
+		retval = C.UA_VariantScalarValueStatusCode(variant, C.UA_StatusCode(value.Value.(uint32)))
+	case UA_QUALIFIEDNAME:
+		name := value.Value.(QualifiedName)
+		cString := C.CString(name.Name)
+		defer C.free(unsafe.Pointer(cString))
+		retval = C.UA_VariantScalarValueQualifiedName(variant, C.UA_UInt16(name.NamespaceIndex), cString)
+	case UA_LOCALIZEDTEXT:
+		text := value.Value.(LocalizedText)
+		cLocale := C.CString(text.Locale)
+		defer C.free(unsafe.Pointer(cLocale))
+		cText := C.CString(text.Text)
+		defer C.free(unsafe.Pointer(cText))
+		retval = C.UA_VariantScalarValueLocalizedText(variant, cLocale, cText)
+	case UA_EXTENSIONOBJECT:
+		object := value.Value.(ExtensionObject)
+		cTypeID := C.CString(object.TypeID)
+		defer C.free(unsafe.Pointer(cTypeID))
+		cBody := C.CBytes(object.Body)
+		defer C.free(cBody)
+		retval = C.UA_VariantScalarValueExtensionObject(variant, cTypeID, cBody, C.size_t(len(object.Body)))
 	default:
 		return fmt.Errorf("ua client covert node value to variant failed, type = %d not support", uint32(value.Type))
 	}
@@ -1135,11 +1991,126 @@ func UA_VariantFromArrayValue(value NodeValue, variant *C.UA_Variant) error {
 				}
 			}
 		}
+	case UA_GUID:
+		{
+			uaType = C.UA_TYPES_GUID
+			arrayList := value.Value.([]string)
+			retval = C.UA_ArrayValueInit(&cArray, uaType)
+			if retval != C.UA_STATUSCODE_GOOD {
+				goto failed
+			}
+			for _, value := range arrayList {
+				cString := C.CString(value)
+				defer C.free(unsafe.Pointer(cString))
+				retval = C.UA_ArrayValueAppendGuid(&cArray, cString)
+				if retval != C.UA_STATUSCODE_GOOD {
+					goto failed
+				}
+			}
+		}
+	case UA_NODEID:
+		{
+			uaType = C.UA_TYPES_NODEID
+			arrayList := value.Value.([]string)
+			retval = C.UA_ArrayValueInit(&cArray, uaType)
+			if retval != C.UA_STATUSCODE_GOOD {
+				goto failed
+			}
+			for _, value := range arrayList {
+				cString := C.CString(value)
+				defer C.free(unsafe.Pointer(cString))
+				retval = C.UA_ArrayValueAppendNodeId(&cArray, cString)
+				if retval != C.UA_STATUSCODE_GOOD {
+					goto failed
+				}
+			}
+		}
+	case UA_STATUSCODE:
+		{
+			uaType = C.UA_TYPES_STATUSCODE
+			arrayList := value.Value.([]uint32)
+			retval = C.UA_ArrayValueInit(&cArray, uaType)
+			if retval != C.UA_STATUSCODE_GOOD {
+				goto failed
+			}
+			for _, value := range arrayList {
+				retval = C.UA_ArrayValueAppendStatusCode(&cArray, C.UA_StatusCode(value))
+				if retval != C.UA_STATUSCODE_GOOD {
+					goto failed
+				}
+			}
+		}
+	case UA_QUALIFIEDNAME:
+		{
+			uaType = C.UA_TYPES_QUALIFIEDNAME
+			arrayList := value.Value.([]QualifiedName)
+			retval = C.UA_ArrayValueInit(&cArray, uaType)
+			if retval != C.UA_STATUSCODE_GOOD {
+				goto failed
+			}
+			for _, value := range arrayList {
+				cString := C.CString(value.Name)
+				defer C.free(unsafe.Pointer(cString))
+				retval = C.UA_ArrayValueAppendQualifiedName(&cArray, C.UA_UInt16(value.NamespaceIndex), cString)
+				if retval != C.UA_STATUSCODE_GOOD {
+					goto failed
+				}
+			}
+		}
+	case UA_LOCALIZEDTEXT:
+		{
+			uaType = C.UA_TYPES_LOCALIZEDTEXT
+			arrayList := value.Value.([]LocalizedText)
+			retval = C.UA_ArrayValueInit(&cArray, uaType)
+			if retval != C.UA_STATUSCODE_GOOD {
+				goto failed
+			}
+			for _, value := range arrayList {
+				cLocale := C.CString(value.Locale)
+				defer C.free(unsafe.Pointer(cLocale))
+				cText := C.CString(value.Text)
+				defer C.free(unsafe.Pointer(cText))
+				retval = C.UA_ArrayValueAppendLocalizedText(&cArray, cLocale, cText)
+				if retval != C.UA_STATUSCODE_GOOD {
+					goto failed
+				}
+			}
+		}
+	case UA_EXTENSIONOBJECT:
+		{
+			uaType = C.UA_TYPES_EXTENSIONOBJECT
+			arrayList := value.Value.([]ExtensionObject)
+			retval = C.UA_ArrayValueInit(&cArray, uaType)
+			if retval != C.UA_STATUSCODE_GOOD {
+				goto failed
+			}
+			for _, value := range arrayList {
+				cTypeID := C.CString(value.TypeID)
+				defer C.free(unsafe.Pointer(cTypeID))
+				cBody := C.CBytes(value.Body)
+				defer C.free(cBody)
+				retval = C.UA_ArrayValueAppendExtensionObject(&cArray, cTypeID, cBody, C.size_t(len(value.Body)))
+				if retval != C.UA_STATUSCODE_GOOD {
+					goto failed
+				}
+			}
+		}
 	default:
 		return fmt.Errorf("ua client covert node array value to variant failed, type = %d not support", uint32(value.Type))
 	}
 
 	C.UA_VariantArrayValue(variant, &cArray, uaType)
+	if len(value.Dims) > 1 {
+		if MatrixLength(value.Dims) != int(cArray.length) {
+			C.UA_Variant_clear(variant)
+			return fmt.Errorf("ua client covert node array value to variant failed, dimensions %v not match length %d", value.Dims, int(cArray.length))
+		}
+		retval = C.UA_VariantSetDimensions(variant, (*C.UA_UInt32)(unsafe.Pointer(&value.Dims[0])), C.size_t(len(value.Dims)))
+		if retval != C.UA_STATUSCODE_GOOD {
+			C.UA_Variant_clear(variant)
+			goto failed
+		}
+	}
 	return nil
 
 failed:
@@ -1153,22 +2124,211 @@ func UA_VariantClangValue(value NodeValue, variant *C.UA_Variant) error {
 	return UA_VariantFromSingleValue(value, variant)
 }
 
-func UA_NodeTreeExpand(cNodeTree *C.NodeTree) *NodeTree {
-	subNodes := make([]*NodeTree, 0)
-	node := C.UA_NodeTree_head(cNodeTree)
-	for {
-		if unsafe.Pointer(node) == nil {
-			break
+func uaString(value C.UA_String) string {
+	if value.length == 0 {
+		return ""
+	}
+	return string(C.GoBytes(unsafe.Pointer(value.data), C.int(value.length)))
+}
+
+func uaGuidString(guid *C.UA_Guid) string {
+	var output [C.UA_GUID_TEXT_LENGTH]C.char
+	C.UA_GuidText(guid, &output[0])
+	return C.GoString(&output[0])
+}
+
+func uaNodeIdString(nodeID *C.UA_NodeId) string {
+	text := C.UA_NodeIdText(nodeID)
+	if text == nil {
+		return ""
+	}
+	defer C.free(unsafe.Pointer(text))
+	return C.GoString(text)
+}
+
+func uaQualifiedName(name *C.UA_QualifiedName) QualifiedName {
+	return QualifiedName{NamespaceIndex: uint16(name.namespaceIndex), Name: uaString(name.name)}
+}
+
+func uaLocalizedText(text *C.UA_LocalizedText) LocalizedText {
+	return LocalizedText{Locale: uaString(text.locale), Text: uaString(text.text)}
+}
+
+func uaExtensionObject(eo *C.UA_ExtensionObject) (ExtensionObject, error) {
+	var typeID *C.char
+	var body C.UA_ByteString
+
+	retval := C.UA_ExtensionObjectEncoded(eo, &typeID, &body)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return ExtensionObject{}, fmt.Errorf("ua extension object encode failed, retval = 0x%x", uint32(retval))
+	}
+	defer C.free(unsafe.Pointer(typeID))
+	defer C.UA_ByteString_clear(&body)
+
+	object := ExtensionObject{
+		TypeID: C.GoString(typeID),
+		Body:   C.GoBytes(unsafe.Pointer(body.data), C.int(body.length)),
+	}
+
+	var data unsafe.Pointer
+	dataType := C.UA_ExtensionObjectDecoded(eo, &data)
+	if dataType != nil {
+		var info C.DataTypeInfo
+		C.UA_DataTypeInfoGet(dataType, &info)
+		object.Type = C.GoString(info.typeName)
+		object.Value = uaStructValue(data, dataType)
+	}
+	return object, nil
+}
+
+// uaStructValue decodes the value in memory by the data type description,
+// the structures become maps of the member names for the json storage
+func uaStructValue(data unsafe.Pointer, dataType *C.UA_DataType) interface{} {
+	var info C.DataTypeInfo
+	C.UA_DataTypeInfoGet(dataType, &info)
+
+	switch info.typeKind {
+	case C.UA_DATATYPEKIND_ENUM:
+		return int32(*(*C.UA_Int32)(data))
+	case C.UA_DATATYPEKIND_STRUCTURE, C.UA_DATATYPEKIND_OPTSTRUCT:
+		return uaStructMembers(data, dataType, info)
+	case C.UA_DATATYPEKIND_UNION:
+		return uaUnionMember(data, dataType, info)
+	case C.UA_DATATYPEKIND_VARIANT:
+		value, err := UA_VariantGolangValue((*C.UA_Variant)(data))
+		if err != nil {
+			return nil
+		}
+		return structBuiltinValue(value)
+	}
+	return uaBuiltinValue(data, dataType, 0, false)
+}
+
+func uaStructMembers(data unsafe.Pointer, dataType *C.UA_DataType, info C.DataTypeInfo) map[string]interface{} {
+	fields := make(map[string]interface{})
+	ptr := data
+	for i := 0; i < int(info.membersSize); i++ {
+		var member C.DataTypeMember
+		C.UA_DataTypeMemberGet(dataType, C.size_t(i), &member)
+		name := C.GoString(member.name)
+		ptr = unsafe.Add(ptr, int(member.padding))
+
+		if member.isArray {
+			length := *(*C.size_t)(ptr)
+			ptr = unsafe.Add(ptr, unsafe.Sizeof(C.size_t(0)))
+			items := *(*unsafe.Pointer)(ptr)
+			ptr = unsafe.Add(ptr, unsafe.Sizeof(uintptr(0)))
+			fields[name] = uaStructArray(items, int(length), member._type)
+		} else if member.isOptional {
+			item := *(*unsafe.Pointer)(ptr)
+			ptr = unsafe.Add(ptr, unsafe.Sizeof(uintptr(0)))
+			if item != nil {
+				fields[name] = uaStructValue(item, member._type)
+			}
+		} else {
+			var memberInfo C.DataTypeInfo
+			C.UA_DataTypeInfoGet(member._type, &memberInfo)
+			fields[name] = uaStructValue(ptr, member._type)
+			ptr = unsafe.Add(ptr, int(memberInfo.memSize))
+		}
+	}
+	return fields
+}
+
+// uaUnionMember decodes the selected member, the padding of union members is
+// the offset from the start of the union
+func uaUnionMember(data unsafe.Pointer, dataType *C.UA_DataType, info C.DataTypeInfo) map[string]interface{} {
+	selected := int(*(*C.UA_UInt32)(data))
+	fields := map[string]interface{}{"SwitchField": selected}
+	if selected == 0 || selected > int(info.membersSize) {
+		return fields
+	}
+
+	var member C.DataTypeMember
+	C.UA_DataTypeMemberGet(dataType, C.size_t(selected-1), &member)
+	ptr := unsafe.Add(data, int(member.padding))
+	if member.isArray {
+		length := *(*C.size_t)(ptr)
+		items := *(*unsafe.Pointer)(unsafe.Add(ptr, unsafe.Sizeof(C.size_t(0))))
+		fields[C.GoString(member.name)] = uaStructArray(items, int(length), member._type)
+	} else {
+		fields[C.GoString(member.name)] = uaStructValue(ptr, member._type)
+	}
+	return fields
+}
+
+func uaStructArray(items unsafe.Pointer, length int, dataType *C.UA_DataType) interface{} {
+	var info C.DataTypeInfo
+	C.UA_DataTypeInfoGet(dataType, &info)
+
+	switch info.typeKind {
+	case C.UA_DATATYPEKIND_ENUM, C.UA_DATATYPEKIND_STRUCTURE, C.UA_DATATYPEKIND_OPTSTRUCT,
+		C.UA_DATATYPEKIND_UNION, C.UA_DATATYPEKIND_VARIANT:
+		values := make([]interface{}, length)
+		for i := 0; i < length; i++ {
+			values[i] = uaStructValue(unsafe.Add(items, i*int(info.memSize)), dataType)
 		}
-		subNodes = append(subNodes, UA_NodeTreeExpand(node))
-		node = C.UA_NodeTree_next(node)
+		return values
+	}
+	return uaBuiltinValue(items, dataType, length, true)
+}
+
+// uaBuiltinValue converts the builtin value through a variant view without copy,
+// the types not supported by NodeValue fall back to the printed text
+func uaBuiltinValue(data unsafe.Pointer, dataType *C.UA_DataType, length int, array bool) interface{} {
+	var variant C.UA_Variant
+	C.UA_VariantView(&variant, data, C.size_t(length), dataType, C.UA_Boolean(array))
+
+	value, err := UA_VariantGolangValue(&variant)
+	if err == nil {
+		return structBuiltinValue(value)
+	}
+
+	text := C.UA_VariantText(&variant)
+	if text == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(text))
+	return C.GoString(text)
+}
+
+// readAttribute reads the attribute of the node given by the node id text, the caller holds the client lock
+func (c *Client) readAttribute(nodeID string, attributeID uint32) (*NodeValue, error) {
+	cID := C.CString(nodeID)
+	defer C.free(unsafe.Pointer(cID))
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	var variant C.UA_Variant
+	retval := C.UA_ClientReadAttributeText(client, cID, C.UA_UInt32(attributeID), &variant)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return nil, fmt.Errorf("ua client read %s attribute %d failed, retval = 0x%x", nodeID, attributeID, uint32(retval))
+	}
+	defer C.UA_Variant_clear(&variant)
+
+	return UA_VariantGolangValue(&variant)
+}
+
+// browseFirst returns the first target of the reference, the caller holds the client lock
+func (c *Client) browseFirst(nodeID string, referenceType uint32, inverse bool) (string, error) {
+	return c.browseReference(nodeID, referenceType, false, inverse)
+}
+
+// browseReference returns the first target of the reference or its subtypes, the caller holds the client lock
+func (c *Client) browseReference(nodeID string, referenceType uint32, subtypes bool, inverse bool) (string, error) {
+	cID := C.CString(nodeID)
+	defer C.free(unsafe.Pointer(cID))
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	var retval C.UA_StatusCode
+	target := C.UA_ClientBrowseFirst(client, cID, C.UA_UInt32(referenceType), C.UA_Boolean(subtypes), C.UA_Boolean(inverse), &retval)
+	if target == nil {
+		return "", fmt.Errorf("ua client browse %s reference %d failed, retval = 0x%x", nodeID, referenceType, uint32(retval))
 	}
-	return &NodeTree{
-		Level: uint32(cNodeTree.level),
-		Node: NodeInfo{
-			NsIndex: uint32(cNodeTree.index),
-			NodeID:  C.GoString(cNodeTree.nodeID)},
-		SubNodes: subNodes}
+	defer C.free(unsafe.Pointer(target))
+
+	return C.GoString(target), nil
 }
 
 // UA_Client //
@@ -1178,7 +2338,7 @@ func NewClient(addr string) (*Client, error) {
 		return nil, errors.New("ua client create failed")
 	}
 
-	goClient := &Client{addr: addr, cli: uintptr(unsafe.Pointer(client))}
+	goClient := &Client{addr: addr, cli: uintptr(unsafe.Pointer(client)), types: NewTypeRegistry(), pipeline: 1}
 	C.UA_Logger_init(&goClient.cLogger, C.UA_Logger_golang, C.UA_LoggerWrapper, nil)
 
 	cConfig := C.UA_Client_getConfig(client)
@@ -1193,17 +2353,27 @@ func NewClient(addr string) (*Client, error) {
 		C.UA_Client_delete(client)
 		return nil, fmt.Errorf("ua client connect failed, retval = 0x%x", uint32(retval))
 	}
+	goClient.operationLimits()
+	goClient.namespaceInit()
 
 	return goClient, nil
 }
 
 func (c *Client) Close() {
+	c.Lock()
+	defer c.Unlock()
+
+	c.eventsReset()
+
 	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
 	C.UA_Client_disconnect(client)
 	C.UA_Client_delete(client)
 }
 
 func (c *Client) Connect() error {
+	c.Lock()
+	defer c.Unlock()
+
 	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
 
 	C.UA_Client_disconnect(client)
@@ -1212,15 +2382,83 @@ func (c *Client) Connect() error {
 	defer C.free(unsafe.Pointer(cStr))
 
 	retval := C.UA_Client_connect(client, cStr)
+	c.eventsReset()
+	c.types = NewTypeRegistry()
+
 	if retval != C.UA_STATUSCODE_GOOD {
 		err := fmt.Errorf("ua client reconnect failed, retval = 0x%x", uint32(retval))
 		logs.Warning(err.Error())
 		return err
 	}
+	c.operationLimits()
+	c.namespaceInit()
 	return nil
 }
 
+// operationLimits reads the MaxNodesPerRead and MaxNodesPerWrite of the server, zero means no limit
+func (c *Client) operationLimits() {
+	limits := []struct {
+		nodeID string
+		limit  *int
+	}{
+		{fmt.Sprintf("i=%d", C.UA_NS0ID_SERVER_SERVERCAPABILITIES_OPERATIONLIMITS_MAXNODESPERREAD), &c.maxRead},
+		{fmt.Sprintf("i=%d", C.UA_NS0ID_SERVER_SERVERCAPABILITIES_OPERATIONLIMITS_MAXNODESPERWRITE), &c.maxWrite},
+	}
+	for _, item := range limits {
+		*item.limit = 0
+		value, err := c.readAttribute(item.nodeID, UA_ATTRIBUTEID_VALUE)
+		if err != nil {
+			logs.Debug("ua client %s operation limit %s not available, %s", c.addr, item.nodeID, err.Error())
+			continue
+		}
+		if limit, ok := value.ToFloat(); ok && limit > 0 {
+			*item.limit = int(limit)
+		}
+	}
+	logs.Info("ua client %s operation limits, read %d, write %d", c.addr, c.maxRead, c.maxWrite)
+}
+
+// SetPipeline sets the number of read and write chunks waiting for the responses at the same time
+func (c *Client) SetPipeline(depth int) {
+	c.Lock()
+	defer c.Unlock()
+
+	if depth < 1 {
+		depth = 1
+	}
+	if depth > CLIENT_PIPELINE_MAX {
+		depth = CLIENT_PIPELINE_MAX
+	}
+	c.pipeline = depth
+}
+
+func chunkSize(limit int, size int) int {
+	if limit <= 0 || limit > size {
+		return size
+	}
+	return limit
+}
+
+// chunkRejected lowers the chunk size and the pipeline when the server rejects the chunk for the limits
+func (c *Client) chunkRejected(status C.UA_StatusCode, limit *int, size int) {
+	switch status {
+	case C.UA_STATUSCODE_BADTOOMANYOPERATIONS, C.UA_STATUSCODE_BADREQUESTTOOLARGE:
+		if size > 1 {
+			*limit = size / 2
+			logs.Warning("ua client %s chunk of %d nodes rejected, retval = 0x%x, reduce to %d", c.addr, size, uint32(status), *limit)
+		}
+	case C.UA_STATUSCODE_BADTCPNOTENOUGHRESOURCES:
+		if c.pipeline > 1 {
+			c.pipeline = 1
+			logs.Warning("ua client %s pipeline rejected, retval = 0x%x, send chunks one by one", c.addr, uint32(status))
+		}
+	}
+}
+
 func (c *Client) CheckState() bool {
+	c.Lock()
+	defer c.Unlock()
+
 	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
 
 	var retval C.UA_StatusCode
@@ -1234,115 +2472,1009 @@ func (c *Client) CheckState() bool {
 }
 
 func (c *Client) ReadNode(node NodeInfo) (*NodeValue, error) {
-	cID := C.CString(node.NodeID)
-	defer C.free(unsafe.Pointer(cID))
+	c.Lock()
+	defer c.Unlock()
+
+	node, err := c.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeID, cID := node.uaNodeID()
+	defer C.free(cID)
 
 	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
 
 	var variant C.UA_Variant
-	retval := C.UA_Client_readValueAttribute(client, C.UA_NODEID_STRING(C.UA_UInt16(node.NsIndex), cID), &variant)
+	var retval C.UA_StatusCode
+	if node.Range != "" {
+		cRange := C.CString(node.Range)
+		defer C.free(unsafe.Pointer(cRange))
+		retval = C.UA_ClientReadValueRange(client, nodeID, cRange, &variant)
+	} else {
+		retval = C.UA_Client_readValueAttribute(client, nodeID, &variant)
+	}
 	if retval != C.UA_STATUSCODE_GOOD {
 		return nil, fmt.Errorf("ua client read value failed, retval = 0x%x", uint32(retval))
 	}
 	defer C.UA_Variant_clear(&variant)
 
-	return UA_VariantGolangValue(&variant)
+	value, err := UA_VariantGolangValue(&variant)
+	if err != nil {
+		return nil, err
+	}
+	return c.structValue(node, value)
 }
 
 func (c *Client) ReadNodes(nodes []NodeInfo) ([]*NodeValue, error) {
+	nodeValues, _, err := c.ReadNodesStatus(nodes)
+	return nodeValues, err
+}
+
+// ReadNodesStatus reads the nodes with the status of each node, the bad nodes get the empty value
+func (c *Client) ReadNodesStatus(nodes []NodeInfo) ([]*NodeValue, []uint32, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(nodes) == 0 {
+		return []*NodeValue{}, []uint32{}, nil
+	}
+
 	cReadValueIDs := C.UA_ReadValueID_alloc(C.int(len(nodes)))
 	if cReadValueIDs == nil {
-		return nil, errors.New("ua client alloc read value ids failed, point is nil")
+		return nil, nil, errors.New("ua client alloc read value ids failed, point is nil")
 	}
 
 	defer C.UA_ReadValueID_free(cReadValueIDs)
 
 	cNodeIDs := make([]unsafe.Pointer, 0)
 
-	for i, node := range nodes {
-		cID := C.CString(node.NodeID)
-		cNodeIDs = append(cNodeIDs, unsafe.Pointer(cID))
-		C.UA_ReadValueID_string(cReadValueIDs, C.int(i), C.UA_UInt16(node.NsIndex), cID, C.UA_ATTRIBUTEID_VALUE)
+	for i, node := range nodes {
+		// the node of a missing namespace reads the null node id, the server answers it with a bad status
+		node, err := c.resolve(node)
+		if err != nil {
+			node = NodeInfo{IdType: NODEID_NUMERIC, NodeID: "0"}
+		}
+		nodeID, cID := node.uaNodeID()
+		cNodeIDs = append(cNodeIDs, cID)
+		C.UA_ReadValueID_set(cReadValueIDs, C.int(i), nodeID, C.UA_ATTRIBUTEID_VALUE)
+		if node.Range != "" {
+			cRange := C.CString(node.Range)
+			cNodeIDs = append(cNodeIDs, unsafe.Pointer(cRange))
+			C.UA_ReadValueID_range(cReadValueIDs, C.int(i), cRange)
+		}
+	}
+
+	defer func() {
+		for _, cID := range cNodeIDs {
+			C.free(cID)
+		}
+	}()
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	chunk := chunkSize(c.maxRead, len(nodes))
+	chunks := (len(nodes) + chunk - 1) / chunk
+
+	cResponses := C.UA_ReadResponse_alloc(C.size_t(chunks))
+	if cResponses == nil {
+		return nil, nil, errors.New("ua client alloc read responses failed, point is nil")
+	}
+	defer C.UA_ReadResponse_free(cResponses, C.size_t(chunks))
+
+	status := make([]C.UA_StatusCode, chunks)
+	retval := C.UA_ClientReadChunks(client, cReadValueIDs, C.size_t(len(nodes)), C.size_t(chunk), C.size_t(c.pipeline),
+		cResponses, (*C.UA_StatusCode)(unsafe.Pointer(&status[0])))
+
+	nodeValues := make([]*NodeValue, 0)
+	nodeStatus := make([]uint32, 0)
+	failed := 0
+
+	for i := 0; i < chunks; i++ {
+		offset := i * chunk
+		count := SmallLength(chunk, len(nodes)-offset)
+
+		if status[i] != C.UA_STATUSCODE_GOOD {
+			// only the nodes of the failed chunk are marked bad
+			failed++
+			logs.Warning("ua client read nodes chunk %d/%d failed, retval = 0x%x", i+1, chunks, uint32(status[i]))
+			c.chunkRejected(status[i], &c.maxRead, chunk)
+			for j := 0; j < count; j++ {
+				nodeValues = append(nodeValues, NewEmptyNodeValue())
+				nodeStatus = append(nodeStatus, uint32(status[i]))
+			}
+			continue
+		}
+
+		for j := 0; j < count; j++ {
+			result := C.UA_ReadResponse_result(cResponses, C.size_t(i), C.size_t(j))
+			if result == nil {
+				nodeValues = append(nodeValues, NewEmptyNodeValue())
+				nodeStatus = append(nodeStatus, UA_STATUS_BADNODATA)
+				continue
+			}
+			retval := uint32(C.UA_DataValueStatus(result))
+			if retval&UA_STATUS_SEVERITY_BAD != 0 {
+				nodeValues = append(nodeValues, NewEmptyNodeValue())
+				nodeStatus = append(nodeStatus, retval)
+				continue
+			}
+			nodeValue, err := UA_VariantGolangValue(&result.value)
+			if err == nil {
+				nodeValue, err = c.structValue(nodes[offset+j], nodeValue)
+			}
+			if err != nil {
+				logs.Debug("ua client read node %s convert failed, %s", nodes[offset+j].ToString(), err.Error())
+				nodeValues = append(nodeValues, NewEmptyNodeValue())
+				nodeStatus = append(nodeStatus, UA_STATUS_BADDATAENCODINGINVALID)
+			} else {
+				nodeValues = append(nodeValues, nodeValue)
+				nodeStatus = append(nodeStatus, retval)
+			}
+		}
+	}
+
+	if failed == chunks {
+		if retval == C.UA_STATUSCODE_GOOD {
+			retval = status[0]
+		}
+		return nil, nil, fmt.Errorf("ua client read nodes failed, retval = 0x%x", uint32(retval))
+	}
+	return nodeValues, nodeStatus, nil
+}
+
+// WriteNodes writes the values in the chunks of the server limit, the errors are per node
+func (c *Client) WriteNodes(nodes []NodeInfo, values []NodeValue) []error {
+	errs := make([]error, len(nodes))
+	if len(nodes) == 0 {
+		return errs
+	}
+
+	for i, node := range nodes {
+		if node.Field != "" || node.Range != "" {
+			errs[i] = fmt.Errorf("ua client write node %s failed, structure field and index range are read only", node.ToString())
+		}
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	cItems := C.UA_WriteValue_alloc(C.size_t(len(nodes)))
+	if cItems == nil {
+		for i := range errs {
+			errs[i] = errors.New("ua client alloc write values failed, point is nil")
+		}
+		return errs
+	}
+	defer C.UA_WriteValue_free(cItems, C.size_t(len(nodes)))
+
+	cNodeIDs := make([]unsafe.Pointer, 0)
+	defer func() {
+		for _, cID := range cNodeIDs {
+			C.free(cID)
+		}
+	}()
+
+	// the nodes failed to convert are sent without value, the server answers them with bad status
+	for i, node := range nodes {
+		node, err := c.resolve(node)
+		if err != nil {
+			errs[i] = err
+			node = NodeInfo{IdType: NODEID_NUMERIC, NodeID: "0"}
+		}
+		nodeID, cID := node.uaNodeID()
+		cNodeIDs = append(cNodeIDs, cID)
+
+		var variant C.UA_Variant
+		if errs[i] == nil {
+			errs[i] = UA_VariantClangValue(values[i], &variant)
+		}
+		C.UA_WriteValue_set(cItems, C.size_t(i), nodeID, &variant)
+	}
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	chunk := chunkSize(c.maxWrite, len(nodes))
+	chunks := (len(nodes) + chunk - 1) / chunk
+
+	cResponses := C.UA_WriteResponse_alloc(C.size_t(chunks))
+	if cResponses == nil {
+		for i := range errs {
+			errs[i] = errors.New("ua client alloc write responses failed, point is nil")
+		}
+		return errs
+	}
+	defer C.UA_WriteResponse_free(cResponses, C.size_t(chunks))
+
+	status := make([]C.UA_StatusCode, chunks)
+	C.UA_ClientWriteChunks(client, cItems, C.size_t(len(nodes)), C.size_t(chunk), C.size_t(c.pipeline),
+		cResponses, (*C.UA_StatusCode)(unsafe.Pointer(&status[0])))
+
+	for i := 0; i < chunks; i++ {
+		offset := i * chunk
+		count := SmallLength(chunk, len(nodes)-offset)
+
+		if status[i] != C.UA_STATUSCODE_GOOD {
+			logs.Warning("ua client write nodes chunk %d/%d failed, retval = 0x%x", i+1, chunks, uint32(status[i]))
+			c.chunkRejected(status[i], &c.maxWrite, chunk)
+		}
+		for j := 0; j < count; j++ {
+			if errs[offset+j] != nil {
+				continue
+			}
+			retval := status[i]
+			if retval == C.UA_STATUSCODE_GOOD {
+				retval = C.UA_WriteResponse_result(cResponses, C.size_t(i), C.size_t(j))
+			}
+			if retval != C.UA_STATUSCODE_GOOD {
+				errs[offset+j] = fmt.Errorf("ua client write node %s failed, retval = 0x%x", nodes[offset+j].ToString(), uint32(retval))
+			}
+		}
+	}
+	return errs
+}
+
+// Browse returns the children of the node one level, nodeID is the full node id text
+func (c *Client) Browse(nodeID string) ([]BrowseItem, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.browse(nodeID)
+}
+
+func (c *Client) browse(nodeID string) ([]BrowseItem, error) {
+	cID := C.CString(nodeID)
+	defer C.free(unsafe.Pointer(cID))
+
+	// every variable reads the data type and the access level
+	chunk := 0
+	if c.maxRead > 0 {
+		chunk = max(c.maxRead/2, 1)
+	}
+
+	var cItems *C.BrowseItem
+	var size C.size_t
+	retval := C.UA_ClientBrowseChildren((*C.UA_Client)(unsafe.Pointer(c.cli)), cID, C.size_t(chunk), &cItems, &size)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return nil, fmt.Errorf("ua client browse %s failed, retval = 0x%x", nodeID, uint32(retval))
+	}
+	defer C.UA_BrowseItems_free(cItems, size)
+
+	items := make([]BrowseItem, 0, int(size))
+	exist := make(map[string]bool)
+	for _, item := range unsafe.Slice(cItems, int(size)) {
+		id := C.GoString(item.nodeID)
+		// the same child can be referenced by several hierarchical references
+		if exist[id] {
+			continue
+		}
+		exist[id] = true
+
+		node, err := NodeInfoParse(id)
+		if err != nil {
+			logs.Warning("ua client browse %s skip node, %s", nodeID, err.Error())
+			continue
+		}
+		node.NsIndex = uint32(item.nsIndex)
+		node.NsUri = c.namespaceUri(node.NsIndex)
+
+		items = append(items, BrowseItem{
+			NodeID:      id,
+			Node:        node,
+			BrowseName:  C.GoString(item.browseName),
+			DisplayName: C.GoString(item.displayName),
+			NodeClass:   uint32(item.nodeClass),
+			DataType:    C.GoString(item.dataType),
+			AccessLevel: uint8(item.accessLevel),
+		})
+	}
+	return items, nil
+}
+
+// BrowseCache keeps the parents and the children browsed for the paths of one source
+type BrowseCache struct {
+	parents  map[string]string
+	children map[string][]BrowseItem
+}
+
+func NewBrowseCache() *BrowseCache {
+	return &BrowseCache{parents: make(map[string]string), children: make(map[string][]BrowseItem)}
+}
+
+// BrowsePath returns the path from the Objects folder down to the node. It follows the inverse
+// hierarchical references up to the Objects folder and browses only the parents on the path.
+func (c *Client) BrowsePath(node NodeInfo, cache *BrowseCache) ([]*NodeTree, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	node, err := c.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+	target := NodeInfo{NsIndex: node.NsIndex, IdType: node.IdType, NodeID: node.NodeID}
+
+	chain := []string{target.Text()}
+	for {
+		if len(chain) > BROWSE_LEVEL_LIMIT {
+			return nil, fmt.Errorf("ua client node %s is deeper than %d levels", target.ToString(), BROWSE_LEVEL_LIMIT)
+		}
+		parent, ok := cache.parents[chain[0]]
+		if !ok {
+			parent, err = c.browseReference(chain[0], UA_REFERENCE_HIERARCHICAL, true, true)
+			if err != nil {
+				return nil, err
+			}
+			cache.parents[chain[0]] = parent
+		}
+		if parent == BROWSE_ROOT_NODE {
+			break
+		}
+		chain = append([]string{parent}, chain...)
+	}
+
+	path := make([]*NodeTree, 0, len(chain))
+	parent := BROWSE_ROOT_NODE
+	for i, id := range chain {
+		items, ok := cache.children[parent]
+		if !ok {
+			items, err = c.browse(parent)
+			if err != nil {
+				return nil, err
+			}
+			cache.children[parent] = items
+		}
+
+		child, err := NodeInfoParse(id)
+		if err != nil {
+			return nil, err
+		}
+		var tree *NodeTree
+		for _, item := range items {
+			if item.Node.NsIndex == child.NsIndex && item.Node.Type() == child.Type() && item.Node.NodeID == child.NodeID {
+				tree = &NodeTree{Level: uint32(i + 1), BrowseItem: item, SubNodes: make([]*NodeTree, 0)}
+				break
+			}
+		}
+		if tree == nil {
+			return nil, fmt.Errorf("ua client node %s is not found below %s", id, parent)
+		}
+		path = append(path, tree)
+		parent = id
+	}
+	return path, nil
+}
+
+func (c *Client) ReadNodeMeta(node NodeInfo) (*NodeMeta, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	node, err := c.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeID, cID := node.uaNodeID()
+	defer C.free(cID)
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	var cMeta C.NodeMeta
+	retval := C.UA_ClientReadNodeMeta(client, nodeID, &cMeta)
+	defer C.UA_NodeMeta_clear(&cMeta)
+
+	if retval != C.UA_STATUSCODE_GOOD {
+		return nil, fmt.Errorf("ua client read node meta failed, retval = 0x%x", uint32(retval))
+	}
+
+	meta := &NodeMeta{
+		DisplayName:     C.GoString(cMeta.displayName),
+		Description:     C.GoString(cMeta.description),
+		AccessLevel:     uint8(cMeta.accessLevel),
+		RangeLow:        float64(cMeta.rangeLow),
+		RangeHigh:       float64(cMeta.rangeHigh),
+		HasUnits:        bool(cMeta.hasUnits),
+		UnitNamespace:   C.GoString(cMeta.unitNamespace),
+		UnitID:          int32(cMeta.unitID),
+		UnitName:        C.GoString(cMeta.unitName),
+		UnitDescription: C.GoString(cMeta.unitDescription),
+		TrueState:       C.GoString(cMeta.trueState),
+		FalseState:      C.GoString(cMeta.falseState),
+	}
+
+	switch {
+	case cMeta.typeDefinition == C.UA_NS0ID_ANALOGITEMTYPE || bool(cMeta.hasRange) || meta.HasUnits:
+		meta.Type = VARIABLE_ANALOG
+	case cMeta.typeDefinition == C.UA_NS0ID_TWOSTATEDISCRETETYPE || cMeta.trueState != nil || cMeta.falseState != nil:
+		meta.Type = VARIABLE_TWOSTATE
+	default:
+		meta.Type = VARIABLE_BASE
+	}
+
+	return meta, nil
+}
+
+func (c *Client) WriteNode(node NodeInfo, value NodeValue) error {
+	if node.Field != "" {
+		return fmt.Errorf("ua client write node %s failed, structure field is read only", node.ToString())
+	}
+	if node.Range != "" {
+		return fmt.Errorf("ua client write node %s failed, index range is read only", node.ToString())
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	node, err := c.resolve(node)
+	if err != nil {
+		return err
+	}
+
+	nodeID, cID := node.uaNodeID()
+	defer C.free(cID)
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	var variant C.UA_Variant
+	err = UA_VariantClangValue(value, &variant)
+	if err != nil {
+		return err
+	}
+	defer C.UA_Variant_clear(&variant)
+
+	retval := C.UA_Client_writeValueAttribute(client, nodeID, &variant)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua client write value failed, retval = 0x%x", uint32(retval))
+	}
+
+	return nil
+}
+
+type clientHistory struct {
+	values []HistoryValue
+	limit  int
+}
+
+var ErrHistoryNotSupported = errors.New("ua history read is not supported by the server")
+
+var clientHistoryRegistry sync.Map
+var clientCallbackHandle uint64
+
+//export UA_ClientHistoryValue_golang
+func UA_ClientHistoryValue_golang(handle C.UA_UInt64, value *C.UA_Variant, timestamp C.UA_DateTime, status C.UA_StatusCode) C.UA_Boolean {
+	item, ok := clientHistoryRegistry.Load(uint64(handle))
+	if !ok {
+		return false
+	}
+	history := item.(*clientHistory)
+
+	historyValue := HistoryValue{Time: datetimeToHistoryTime(timestamp), Status: uint32(status)}
+	if value != nil {
+		nodeValue, err := UA_VariantGolangValue(value)
+		if err != nil {
+			historyValue.Status = UA_STATUS_BADDATAENCODINGINVALID
+		} else {
+			historyValue.Value = nodeValue
+		}
+	}
+	history.values = append(history.values, historyValue)
+
+	return C.UA_Boolean(history.limit <= 0 || len(history.values) < history.limit)
+}
+
+// HistoryReadRaw reads the source history of the node, limit is the max values of all the pages
+func (c *Client) HistoryReadRaw(node NodeInfo, start, end time.Time, limit int) ([]HistoryValue, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	node, err := c.resolve(node)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeID, cID := node.uaNodeID()
+	defer C.free(cID)
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	handle := atomic.AddUint64(&clientCallbackHandle, 1)
+	history := &clientHistory{values: make([]HistoryValue, 0), limit: limit}
+	clientHistoryRegistry.Store(handle, history)
+	defer clientHistoryRegistry.Delete(handle)
+
+	retval := C.UA_ClientHistoryReadRaw(client, nodeID,
+		TimeToDatetime(start), TimeToDatetime(end), 0, C.UA_UInt64(handle))
+	switch retval {
+	case C.UA_STATUSCODE_BADNOTSUPPORTED, C.UA_STATUSCODE_BADSERVICEUNSUPPORTED,
+		C.UA_STATUSCODE_BADHISTORYOPERATIONUNSUPPORTED:
+		return nil, ErrHistoryNotSupported
+	}
+	if retval != C.UA_STATUSCODE_GOOD {
+		return history.values, fmt.Errorf("ua client history read failed, retval = 0x%x", uint32(retval))
+	}
+
+	return history.values, nil
+}
+
+func methodArgumentsExpand(args *C.MethodArgument, size C.size_t) []MethodArgument {
+	output := make([]MethodArgument, 0)
+	if size == 0 {
+		return output
+	}
+	for _, arg := range unsafe.Slice(args, int(size)) {
+		output = append(output, MethodArgument{
+			Name:        C.GoString(arg.name),
+			Description: C.GoString(arg.description),
+			DataType:    uint32(arg.dataType),
+			ValueRank:   int32(arg.valueRank),
+		})
+	}
+	return output
+}
+
+func (c *Client) ReadMethodArguments(method NodeInfo) ([]MethodArgument, []MethodArgument, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	method, err := c.resolve(method)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodeID, cID := method.uaNodeID()
+	defer C.free(cID)
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	var cArgs C.MethodArguments
+	retval := C.UA_ClientReadMethodArguments(client, nodeID, &cArgs)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return nil, nil, fmt.Errorf("ua client read method arguments failed, retval = 0x%x", uint32(retval))
+	}
+	defer C.UA_MethodArguments_clear(&cArgs)
+
+	return methodArgumentsExpand(cArgs.inputs, cArgs.inputSize), methodArgumentsExpand(cArgs.outputs, cArgs.outputSize), nil
+}
+
+// METHOD_CALL_TIMEOUT bounds the wait for the busy client and the call round trip together
+const METHOD_CALL_TIMEOUT = 2 * time.Second
+
+// CallMethod calls the source method, the status is the service or the method result.
+// It is called in the server loop, so the wait for the client which is busy with the poll
+// or the reconnect and the call itself end after METHOD_CALL_TIMEOUT with BadTimeout.
+func (c *Client) CallMethod(object, method NodeInfo, inputs []*NodeValue) ([]*NodeValue, uint32) {
+	deadline := time.Now().Add(METHOD_CALL_TIMEOUT)
+	for !c.TryLock() {
+		if time.Now().After(deadline) {
+			return nil, UA_STATUS_BADTIMEOUT
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	defer c.Unlock()
+
+	object, err := c.resolve(object)
+	if err != nil {
+		return nil, UA_STATUS_BADNODEIDUNKNOWN
+	}
+	method, err = c.resolve(method)
+	if err != nil {
+		return nil, UA_STATUS_BADNODEIDUNKNOWN
+	}
+
+	objectID, cObject := object.uaNodeID()
+	defer C.free(cObject)
+
+	methodID, cMethod := method.uaNodeID()
+	defer C.free(cMethod)
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	var cInputs *C.UA_Variant
+	if len(inputs) > 0 {
+		cInputs = C.UA_VariantArray_alloc(C.size_t(len(inputs)))
+		if cInputs == nil {
+			return nil, C.UA_STATUSCODE_BADOUTOFMEMORY
+		}
+		defer C.UA_VariantArray_delete(cInputs, C.size_t(len(inputs)))
+
+		variants := unsafe.Slice(cInputs, len(inputs))
+		for i := range variants {
+			err := UA_VariantClangValue(*inputs[i], &variants[i])
+			if err != nil {
+				logs.Error("ua client call method failed, convert input %d failed, %s", i, err.Error())
+				return nil, UA_STATUS_BADTYPEMISMATCH
+			}
+		}
+	}
+
+	var outputSize C.size_t
+	var cOutputs *C.UA_Variant
+	timeout := time.Until(deadline).Milliseconds()
+	if timeout <= 0 {
+		return nil, UA_STATUS_BADTIMEOUT
+	}
+	retval := C.UA_ClientCallMethod(client, objectID, methodID,
+		C.size_t(len(inputs)), cInputs, &outputSize, &cOutputs, C.UA_UInt32(timeout))
+	if retval != C.UA_STATUSCODE_GOOD {
+		return nil, uint32(retval)
+	}
+
+	outputs := make([]*NodeValue, 0)
+	if outputSize == 0 {
+		return outputs, UA_STATUS_GOOD
+	}
+	defer C.UA_VariantArray_delete(cOutputs, outputSize)
+
+	variants := unsafe.Slice(cOutputs, int(outputSize))
+	for i := range variants {
+		value, err := UA_VariantGolangValue(&variants[i])
+		if err != nil {
+			logs.Warning("ua client call method output %d convert failed, %s", i, err.Error())
+		}
+		outputs = append(outputs, value)
+	}
+	return outputs, UA_STATUS_GOOD
+}
+
+type clientEvents struct {
+	selects []string
+	handler EventHandler
+}
+
+var clientEventRegistry sync.Map
+
+//export UA_ClientEvent_golang
+func UA_ClientEvent_golang(handle C.UA_UInt64, size C.size_t, fields *C.UA_Variant) {
+	item, ok := clientEventRegistry.Load(uint64(handle))
+	if !ok || size == 0 {
+		return
+	}
+	events := item.(*clientEvents)
+
+	output := make([]EventField, 0)
+	for i, variant := range unsafe.Slice(fields, int(size)) {
+		field := EventField{Name: fmt.Sprintf("Field%d", i)}
+		if i < len(events.selects) {
+			field.Name = events.selects[i]
+		}
+
+		value, err := UA_VariantGolangValue(&variant)
+		if err == nil {
+			field.Value = value
+			field.Text = value.ToString()
+		} else {
+			cText := C.UA_VariantText(&variant)
+			field.Text = C.GoString(cText)
+			C.free(unsafe.Pointer(cText))
+		}
+		output = append(output, field)
+	}
+
+	events.handler(output)
+}
+
+// eventsReset drops the event subscription, the client subscriptions are removed by disconnect
+func (c *Client) eventsReset() {
+	if c.eventHandle != 0 {
+		clientEventRegistry.Delete(c.eventHandle)
+	}
+	c.eventSub = 0
+	c.eventHandle = 0
+}
+
+// SubscribeEvents monitors the events of the source server object with the select and where clauses
+func (c *Client) SubscribeEvents(interval time.Duration, selects []string, where []EventWhere, handler EventHandler) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(selects) == 0 {
+		return errors.New("ua client subscribe events failed, the select clause is empty")
+	}
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+
+	if c.eventSub != 0 {
+		C.UA_ClientUnsubscribe(client, C.UA_UInt32(c.eventSub))
+		c.eventsReset()
+	}
+
+	cSelects := make([]*C.char, len(selects))
+	for i, field := range selects {
+		cSelects[i] = C.CString(field)
+		defer C.free(unsafe.Pointer(cSelects[i]))
+	}
+
+	var cWhere *C.EventWhere
+	if len(where) > 0 {
+		cWhere = C.UA_EventWhere_alloc(C.size_t(len(where)))
+		if cWhere == nil {
+			return errors.New("ua client subscribe events failed, alloc where clause failed")
+		}
+		defer C.UA_EventWhere_delete(cWhere, C.size_t(len(where)))
+
+		items := unsafe.Slice(cWhere, len(where))
+		for i, term := range where {
+			items[i].op = C.UA_UInt32(term.Operator)
+			items[i].field = C.CString(term.Field)
+			items[i].typeNs = C.UA_UInt16(term.TypeNs)
+			items[i].typeID = C.UA_UInt32(term.TypeID)
+			if term.Operator == EVENT_OPERATOR_OFTYPE {
+				continue
+			}
+			err := UA_VariantClangValue(term.Value, &items[i].value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	handle := atomic.AddUint64(&clientCallbackHandle, 1)
+	clientEventRegistry.Store(handle, &clientEvents{selects: selects, handler: handler})
+
+	var subID C.UA_UInt32
+	retval := C.UA_ClientSubscribeEvents(client, C.UA_Double(float64(interval)/float64(time.Millisecond)),
+		C.size_t(len(selects)), &cSelects[0], C.size_t(len(where)), cWhere, C.UA_UInt64(handle), &subID)
+	if retval != C.UA_STATUSCODE_GOOD {
+		clientEventRegistry.Delete(handle)
+		return fmt.Errorf("ua client subscribe events failed, retval = 0x%x", uint32(retval))
+	}
+
+	c.eventSub = uint32(subID)
+	c.eventHandle = handle
+	return nil
+}
+
+func (c *Client) UnsubscribeEvents() {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.eventSub == 0 {
+		return
+	}
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+	C.UA_ClientUnsubscribe(client, C.UA_UInt32(c.eventSub))
+	c.eventsReset()
+}
+
+// Iterate processes the publish responses of the subscriptions
+func (c *Client) Iterate() error {
+	c.Lock()
+	defer c.Unlock()
+
+	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+	retval := C.UA_ClientIterate(client)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua client iterate failed, retval = 0x%x", uint32(retval))
+	}
+	return nil
+}
+
+// UA_Server //
+const (
+	SECURITY_POLICY_NONE                = C.SECURITY_POLICY_NONE
+	SECURITY_POLICY_BASIC128RSA15       = C.SECURITY_POLICY_BASIC128RSA15
+	SECURITY_POLICY_BASIC256            = C.SECURITY_POLICY_BASIC256
+	SECURITY_POLICY_BASIC256SHA256      = C.SECURITY_POLICY_BASIC256SHA256
+	SECURITY_POLICY_AES128SHA256RSAOAEP = C.SECURITY_POLICY_AES128SHA256RSAOAEP
+)
+
+type ServerSecurity struct {
+	Policies       uint32
+	Sign           bool
+	SignAndEncrypt bool
+	Certificate    []byte
+	PrivateKey     []byte
+	TrustList      [][]byte
+	Anonymous      bool
+	Users          map[string]string
+	Access         AccessHandler
+	Denied         DeniedHandler
+}
+
+type EndpointInfo struct {
+	Url        string
+	Policy     string
+	Mode       string
+	UserTokens []string
+	Level      uint8
+}
+
+func (e EndpointInfo) String() string {
+	return fmt.Sprintf("%s | %s | %s | %s", e.Url, e.Mode, e.Policy, strings.Join(e.UserTokens, ","))
+}
+
+type ServerInfo struct {
+	Name           string
+	ApplicationUri string
+	ProductUri     string
+	Type           string
+	DiscoveryUrls  []string
+}
+
+func (s ServerInfo) String() string {
+	return fmt.Sprintf("%s [%s] %s", s.Name, s.Type, strings.Join(s.DiscoveryUrls, ","))
+}
+
+var ErrDiscoveryNotSupported = errors.New("ua find servers on network is not supported, the open62541 library is built without discovery")
+
+func uaEndpointInfo(endpoint *C.UA_EndpointDescription) EndpointInfo {
+	info := EndpointInfo{
+		Url:        uaString(endpoint.endpointUrl),
+		Policy:     uaString(endpoint.securityPolicyUri),
+		UserTokens: make([]string, 0),
+		Level:      uint8(endpoint.securityLevel),
+	}
+	if index := bytes.LastIndexByte([]byte(info.Policy), '#'); index >= 0 {
+		info.Policy = info.Policy[index+1:]
+	}
+
+	switch endpoint.securityMode {
+	case C.UA_MESSAGESECURITYMODE_SIGN:
+		info.Mode = "Sign"
+	case C.UA_MESSAGESECURITYMODE_SIGNANDENCRYPT:
+		info.Mode = "SignAndEncrypt"
+	default:
+		info.Mode = "None"
+	}
+
+	tokens := unsafe.Slice(endpoint.userIdentityTokens, int(endpoint.userIdentityTokensSize))
+	for _, token := range tokens {
+		switch token.tokenType {
+		case C.UA_USERTOKENTYPE_ANONYMOUS:
+			info.UserTokens = append(info.UserTokens, "Anonymous")
+		case C.UA_USERTOKENTYPE_USERNAME:
+			info.UserTokens = append(info.UserTokens, "UserName")
+		case C.UA_USERTOKENTYPE_CERTIFICATE:
+			info.UserTokens = append(info.UserTokens, "Certificate")
+		default:
+			info.UserTokens = append(info.UserTokens, "IssuedToken")
+		}
+	}
+	return info
+}
+
+func uaServerInfo(server *C.UA_ApplicationDescription) ServerInfo {
+	info := ServerInfo{
+		Name:           uaString(server.applicationName.text),
+		ApplicationUri: uaString(server.applicationUri),
+		ProductUri:     uaString(server.productUri),
+		DiscoveryUrls:  make([]string, 0),
+	}
+	switch server.applicationType {
+	case C.UA_APPLICATIONTYPE_SERVER:
+		info.Type = "Server"
+	case C.UA_APPLICATIONTYPE_CLIENT:
+		info.Type = "Client"
+	case C.UA_APPLICATIONTYPE_CLIENTANDSERVER:
+		info.Type = "ClientAndServer"
+	default:
+		info.Type = "DiscoveryServer"
+	}
+	for _, url := range unsafe.Slice(server.discoveryUrls, int(server.discoveryUrlsSize)) {
+		info.DiscoveryUrls = append(info.DiscoveryUrls, uaString(url))
 	}
+	return info
+}
 
-	defer func() {
-		for _, cID := range cNodeIDs {
-			C.free(cID)
-		}
-	}()
+// FindServers lists the servers registered at the discovery server or the host
+func FindServers(url string) ([]ServerInfo, error) {
+	client := C.UA_DiscoveryClient_new(C.UA_Logger_golang, C.UA_LoggerWrapper)
+	if client == nil {
+		return nil, errors.New("ua discovery client create failed")
+	}
+	defer C.UA_Client_delete(client)
 
-	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+	cUrl := C.CString(url)
+	defer C.free(unsafe.Pointer(cUrl))
 
-	var request C.UA_ReadRequest
-	C.UA_ReadRequest_init(&request)
+	var size C.size_t
+	var servers *C.UA_ApplicationDescription
+	retval := C.UA_Client_findServers(client, cUrl, 0, nil, 0, nil, &size, &servers)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return nil, fmt.Errorf("ua find servers %s failed, retval = 0x%x", url, uint32(retval))
+	}
+	defer C.UA_ApplicationDescriptions_free(servers, size)
 
-	request.nodesToReadSize = C.size_t(len(nodes))
-	request.nodesToRead = cReadValueIDs
+	output := make([]ServerInfo, 0)
+	items := unsafe.Slice(servers, int(size))
+	for i := range items {
+		output = append(output, uaServerInfo(&items[i]))
+	}
+	return output, nil
+}
 
-	response := C.UA_Client_Service_read(client, request)
-	defer C.UA_ReadResponse_clear(&response)
+// FindServersOnNetwork needs the multicast discovery of the library, which is not built in
+func FindServersOnNetwork(url string) ([]ServerInfo, error) {
+	return nil, ErrDiscoveryNotSupported
+}
 
-	if C.UA_STATUSCODE_GOOD != response.responseHeader.serviceResult {
-		if C.UA_STATUSCODE_BADCONNECTIONCLOSED == response.responseHeader.serviceResult {
-			C.UA_Client_disconnect(client)
-		}
-		return nil, fmt.Errorf("ua client read nodes failed, retval = 0x%x", uint32(response.responseHeader.serviceResult))
+// GetEndpoints lists the endpoints of the server with the security mode, policy and user tokens
+func GetEndpoints(url string) ([]EndpointInfo, error) {
+	client := C.UA_DiscoveryClient_new(C.UA_Logger_golang, C.UA_LoggerWrapper)
+	if client == nil {
+		return nil, errors.New("ua discovery client create failed")
 	}
+	defer C.UA_Client_delete(client)
 
-	nodeValues := make([]*NodeValue, 0)
+	cUrl := C.CString(url)
+	defer C.free(unsafe.Pointer(cUrl))
 
-	for i := C.size_t(0); i < response.resultsSize; i++ {
-		variant := C.UA_ReadResponse_variant(&response, C.int(i))
-		nodeValue, err := UA_VariantGolangValue(variant)
-		if err != nil {
-			nodeValues = append(nodeValues, NewEmptyNodeValue())
-		} else {
-			nodeValues = append(nodeValues, nodeValue)
-		}
+	var size C.size_t
+	var endpoints *C.UA_EndpointDescription
+	retval := C.UA_Client_getEndpoints(client, cUrl, &size, &endpoints)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return nil, fmt.Errorf("ua get endpoints %s failed, retval = 0x%x", url, uint32(retval))
 	}
+	defer C.UA_EndpointDescriptions_free(endpoints, size)
 
-	return nodeValues, nil
+	output := make([]EndpointInfo, 0)
+	items := unsafe.Slice(endpoints, int(size))
+	for i := range items {
+		output = append(output, uaEndpointInfo(&items[i]))
+	}
+	return output, nil
 }
 
-func (c *Client) BrowseNode() ([]*NodeTree, error) {
-	cNodeTree := C.UA_NodeTree_root_init()
-	if cNodeTree == nil {
-		return nil, errors.New("ua client browse node failed, point is nil")
-	}
+func EncryptionSupported() bool {
+	return bool(C.UA_EncryptionSupported())
+}
 
-	defer C.UA_NodeTree_clear(cNodeTree)
+// DiscoveryMulticastSupported reports whether the library is built with the multicast discovery
+func DiscoveryMulticastSupported() bool {
+	return bool(C.UA_DiscoveryMulticastSupported())
+}
 
-	retval := C.UA_Browse_nodeTree((*C.UA_Client)(unsafe.Pointer(c.cli)), cNodeTree)
+func uaSecuritySet(cConfig *C.UA_ServerConfig, port int, security *ServerSecurity) error {
+	var cSecurity C.ServerSecurity
+	retval := C.UA_ServerSecurity_alloc(&cSecurity, C.size_t(len(security.TrustList)), C.size_t(len(security.Users)))
+	defer C.UA_ServerSecurity_clear(&cSecurity)
 	if retval != C.UA_STATUSCODE_GOOD {
-		return nil, fmt.Errorf("ua client browse node failed, retval = 0x%x", uint32(retval))
+		return fmt.Errorf("ua server security alloc failed, retval = 0x%x", uint32(retval))
 	}
 
-	return UA_NodeTreeExpand(cNodeTree).SubNodes, nil
-}
+	cSecurity.policies = C.UA_UInt32(security.Policies)
+	cSecurity.sign = C.UA_Boolean(security.Sign)
+	cSecurity.signAndEncrypt = C.UA_Boolean(security.SignAndEncrypt)
+	cSecurity.allowAnonymous = C.UA_Boolean(security.Anonymous)
 
-func (c *Client) WriteNode(node NodeInfo, value NodeValue) error {
-	cID := C.CString(node.NodeID)
-	defer C.free(unsafe.Pointer(cID))
+	if len(security.Certificate) > 0 {
+		cData := C.CBytes(security.Certificate)
+		C.UA_ServerSecurity_setBytes(&cSecurity.certificate, cData, C.size_t(len(security.Certificate)))
+		C.free(cData)
+	}
+	if len(security.PrivateKey) > 0 {
+		cData := C.CBytes(security.PrivateKey)
+		C.UA_ServerSecurity_setBytes(&cSecurity.privateKey, cData, C.size_t(len(security.PrivateKey)))
+		C.free(cData)
+	}
 
-	client := (*C.UA_Client)(unsafe.Pointer(c.cli))
+	for i, cert := range security.TrustList {
+		cData := C.CBytes(cert)
+		C.UA_ServerSecurity_setBytes(C.UA_ServerSecurity_trust(&cSecurity, C.size_t(i)), cData, C.size_t(len(cert)))
+		C.free(cData)
+	}
 
-	var variant C.UA_Variant
-	err := UA_VariantClangValue(value, &variant)
-	if err != nil {
-		return err
+	index := 0
+	for username, password := range security.Users {
+		cUsername := C.CString(username)
+		cPassword := C.CString(password)
+		C.UA_ServerSecurity_setLogin(&cSecurity, C.size_t(index), cUsername, cPassword)
+		C.free(unsafe.Pointer(cUsername))
+		C.free(unsafe.Pointer(cPassword))
+		index++
 	}
-	defer C.UA_Variant_clear(&variant)
 
-	retval := C.UA_Client_writeValueAttribute(client, C.UA_NODEID_STRING(C.UA_UInt16(node.NsIndex), cID), &variant)
+	retval = C.UA_ServerConfig_setSecurity(cConfig, C.UA_UInt16(port), &cSecurity)
+	if retval == C.UA_STATUSCODE_BADNOTSUPPORTED {
+		return errors.New("ua server security policy requires encryption support, the open62541 library is built without encryption")
+	}
 	if retval != C.UA_STATUSCODE_GOOD {
-		return fmt.Errorf("ua client write value failed, retval = 0x%x", uint32(retval))
+		return fmt.Errorf("ua server security config failed, retval = 0x%x", uint32(retval))
 	}
-
 	return nil
 }
 
-// UA_Server //
-func NewServer(addr string, port int) (*Server, error) {
+func NewServer(addr string, port int, security *ServerSecurity) (*Server, error) {
 	if err := ListenTest(addr, port); err != nil {
 		return nil, err
 	}
@@ -1355,13 +3487,28 @@ func NewServer(addr string, port int) (*Server, error) {
 	cAddr := C.CString(addr)
 	defer C.free(unsafe.Pointer(cAddr))
 
-	goServer := &Server{addr: addr, srv: uintptr(unsafe.Pointer(server)), namespace: make(map[string]uint32)}
+	goServer := &Server{addr: addr, srv: uintptr(unsafe.Pointer(server)), namespace: make(map[string]uint32),
+		tasks: make(chan func()), stopped: make(chan struct{})}
 	C.UA_Logger_init(&goServer.cLogger, C.UA_Logger_golang, C.UA_LoggerWrapper, nil)
 
 	cConfig := C.UA_Server_getConfig(server)
 	cConfig.logger = goServer.cLogger
 
-	C.UA_ServerConfig_setMinimal(cConfig, C.UA_UInt16(port), nil)
+	if security == nil {
+		C.UA_ServerConfig_setMinimal(cConfig, C.UA_UInt16(port), nil)
+	} else {
+		err := uaSecuritySet(cConfig, port, security)
+		if err != nil {
+			C.UA_Server_delete(server)
+			return nil, err
+		}
+		if security.Access != nil {
+			goServer.access = security.Access
+			goServer.denied = security.Denied
+			serverRegistry.Store(goServer.srv, goServer)
+			C.UA_ServerAccessControl_init(server)
+		}
+	}
 	C.UA_String_clear(&cConfig.customHostname)
 	cConfig.customHostname = C.UA_String_fromChars(cAddr)
 
@@ -1381,10 +3528,35 @@ func NewServer(addr string, port int) (*Server, error) {
 
 func (s *Server) serverRunningTask() {
 	s.Done()
+	defer close(s.stopped)
 
 	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
 	for s.running {
 		C.UA_Server_run_iterate(server, true)
+		s.runTasks()
+	}
+}
+
+func (s *Server) runTasks() {
+	for {
+		select {
+		case task := <-s.tasks:
+			task()
+		default:
+			return
+		}
+	}
+}
+
+// Do runs the task between two iterations of the server loop and waits for it, the server is built
+// without multithreading and its nodes must not be changed beside UA_Server_run_iterate
+func (s *Server) Do(task func()) {
+	done := make(chan struct{})
+	select {
+	case s.tasks <- func() { task(); close(done) }:
+		<-done
+	case <-s.stopped:
+		task()
 	}
 }
 
@@ -1392,7 +3564,70 @@ func (s *Server) CheckState() bool {
 	return s.running
 }
 
+func (s *Server) Endpoints() []EndpointInfo {
+	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
+
+	output := make([]EndpointInfo, 0)
+	for i := 0; ; i++ {
+		endpoint := C.UA_ServerEndpoint(server, C.size_t(i))
+		if endpoint == nil {
+			break
+		}
+
+		output = append(output, uaEndpointInfo(endpoint))
+	}
+	return output
+}
+
+//export UA_AccessControl_golang
+func UA_AccessControl_golang(server unsafe.Pointer, username *C.char, nsIndex C.UA_UInt16, nodeID *C.char, operation C.UA_UInt32) C.UA_Byte {
+	value, ok := serverRegistry.Load(uintptr(server))
+	if !ok {
+		return 0xFF
+	}
+	s := value.(*Server)
+
+	user, namespace, id := C.GoString(username), s.NameSpaceName(uint32(nsIndex)), C.GoString(nodeID)
+	permission := s.access(user, namespace, id)
+
+	if operation == C.ACCESS_OPERATION_BROWSE {
+		if permission.Browse {
+			return 1
+		}
+		s.Denied(user, namespace, id, "browse")
+		return 0
+	}
+
+	// open62541 asks the same user access level for the read and the write of the value,
+	// so the refused access of the level is reported whichever operation asked for it
+	level := uint8(0xFF)
+	if !permission.Read {
+		level &^= UA_ACCESSLEVEL_READ | UA_ACCESSLEVEL_HISTORYREAD
+		s.Denied(user, namespace, id, "read")
+	}
+	if !permission.Write {
+		level &^= UA_ACCESSLEVEL_WRITE | UA_ACCESSLEVEL_HISTORYWRITE
+		s.Denied(user, namespace, id, "write")
+	}
+	return C.UA_Byte(level)
+}
+
+func (s *Server) NameSpaceName(index uint32) string {
+	s.nsMutex.RLock()
+	defer s.nsMutex.RUnlock()
+
+	for name, value := range s.namespace {
+		if value == index {
+			return name
+		}
+	}
+	return ""
+}
+
 func (s *Server) AddNameSpace(name string) (uint32, error) {
+	s.nsMutex.Lock()
+	defer s.nsMutex.Unlock()
+
 	index, ok := s.namespace[name]
 	if ok {
 		return index, nil
@@ -1412,6 +3647,51 @@ func (s *Server) AddNameSpace(name string) (uint32, error) {
 	return uint32(cIndex), nil
 }
 
+// DeleteNameSpace deletes the object of the name space with all its nodes, the name space index is
+// kept by the server and the next AddNameSpace of the name adds the object again
+func (s *Server) DeleteNameSpace(name string) error {
+	s.nsMutex.Lock()
+	defer s.nsMutex.Unlock()
+
+	index, ok := s.namespace[name]
+	if !ok {
+		return nil
+	}
+	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	retval := C.UA_ServerDeleteTree(server, C.UA_NODEID_STRING(C.UA_UInt16(index), cName))
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua server delete name space failed, retval = 0x%x", uint32(retval))
+	}
+	delete(s.namespace, name)
+	logs.Info("ua server delete name space success, name: %s, index: %d", name, index)
+	return nil
+}
+
+func (s *Server) AddFolder(parent, current NodeInfo, name string) error {
+	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
+
+	cParentID := C.CString(parent.NodeID)
+	defer C.free(unsafe.Pointer(cParentID))
+
+	cCurrentID := C.CString(current.NodeID)
+	defer C.free(unsafe.Pointer(cCurrentID))
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	retval := C.UA_ServerAddFolder(server,
+		C.UA_UInt16(parent.NsIndex), cParentID,
+		C.UA_UInt16(current.NsIndex), cCurrentID, cName)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua server add folder failed, retval = 0x%x", uint32(retval))
+	}
+	return nil
+}
+
 func (s *Server) AddNode(parent, current NodeInfo, name string, value NodeValue) error {
 	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
 
@@ -1443,6 +3723,69 @@ func (s *Server) AddNode(parent, current NodeInfo, name string, value NodeValue)
 	return nil
 }
 
+func (s *Server) AddNodeMeta(parent, current NodeInfo, name string, value NodeValue, meta NodeMeta) error {
+	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
+
+	cParentID := C.CString(parent.NodeID)
+	defer C.free(unsafe.Pointer(cParentID))
+
+	cCurrentID := C.CString(current.NodeID)
+	defer C.free(unsafe.Pointer(cCurrentID))
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	var variant C.UA_Variant
+	err := UA_VariantClangValue(value, &variant)
+	if err != nil {
+		logs.Error("ua server add node failed, convert value to variant failed, error: %s", err.Error())
+		return err
+	}
+	defer C.UA_Variant_clear(&variant)
+
+	variableType := meta.Compatible(value)
+	if variableType != meta.Type {
+		logs.Warning("ua server add node %s, value type %d not match %s, use %s",
+			current.ToString(), value.Type, meta.Type.String(), variableType.String())
+	}
+
+	var cMeta C.NodeMeta
+	defer C.UA_NodeMeta_clear(&cMeta)
+
+	cMeta.displayName = C.CString(meta.DisplayName)
+	cMeta.description = C.CString(meta.Description)
+	cMeta.accessLevel = C.UA_Byte(meta.AccessLevel)
+
+	switch variableType {
+	case VARIABLE_ANALOG:
+		cMeta.typeDefinition = C.UA_NS0ID_ANALOGITEMTYPE
+		cMeta.hasRange = true
+		cMeta.rangeLow = C.UA_Double(meta.RangeLow)
+		cMeta.rangeHigh = C.UA_Double(meta.RangeHigh)
+		cMeta.hasUnits = C.UA_Boolean(meta.HasUnits)
+		cMeta.unitNamespace = C.CString(meta.UnitNamespace)
+		cMeta.unitID = C.UA_Int32(meta.UnitID)
+		cMeta.unitName = C.CString(meta.UnitName)
+		cMeta.unitDescription = C.CString(meta.UnitDescription)
+	case VARIABLE_TWOSTATE:
+		cMeta.typeDefinition = C.UA_NS0ID_TWOSTATEDISCRETETYPE
+		cMeta.trueState = C.CString(meta.TrueState)
+		cMeta.falseState = C.CString(meta.FalseState)
+	default:
+		cMeta.typeDefinition = C.UA_NS0ID_BASEDATAVARIABLETYPE
+	}
+
+	retval := C.UA_ServerAddVariableMeta(server,
+		C.UA_UInt16(parent.NsIndex), cParentID,
+		C.UA_UInt16(current.NsIndex), cCurrentID,
+		cName, &variant, &cMeta)
+
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua server add node failed, retval = 0x%x", uint32(retval))
+	}
+	return nil
+}
+
 func (s *Server) ReadNode(node NodeInfo) (*NodeValue, error) {
 	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
 
@@ -1481,12 +3824,251 @@ func (s *Server) WriteNode(node NodeInfo, value NodeValue) error {
 	return nil
 }
 
+func TimeToDatetime(t time.Time) C.UA_DateTime {
+	return C.UA_DateTime(t.UnixNano()/100 + 116444736000000000)
+}
+
+func datetimeToHistoryTime(datetime C.UA_DateTime) time.Time {
+	if datetime <= 0 {
+		return time.Time{}
+	}
+	return DatetimeToTime(uint64(datetime))
+}
+
+//export UA_HistoryRead_golang
+func UA_HistoryRead_golang(server unsafe.Pointer, nsIndex C.UA_UInt16, nodeID *C.char, startTime C.UA_DateTime,
+	endTime C.UA_DateTime, maxValues C.UA_UInt32, aggregate C.UA_UInt32, interval C.UA_Double,
+	continuationPoint unsafe.Pointer, continuationLength C.size_t, release C.UA_Boolean, result *C.HistoryResult) C.UA_StatusCode {
+
+	value, ok := serverRegistry.Load(uintptr(server))
+	if !ok || value.(*Server).history == nil {
+		return C.UA_STATUSCODE_BADHISTORYOPERATIONUNSUPPORTED
+	}
+	s := value.(*Server)
+
+	request := HistoryRequest{
+		Namespace: s.NameSpaceName(uint32(nsIndex)),
+		NodeID:    C.GoString(nodeID),
+		Start:     datetimeToHistoryTime(startTime),
+		End:       datetimeToHistoryTime(endTime),
+		MaxValues: uint32(maxValues),
+		Aggregate: uint32(aggregate),
+		Interval:  time.Duration(float64(interval) * float64(time.Millisecond)),
+		Release:   bool(release),
+	}
+	if continuationLength > 0 {
+		request.ContinuationPoint = C.GoBytes(continuationPoint, C.int(continuationLength))
+	}
+
+	response, status := s.history(request)
+	if status != UA_STATUS_GOOD || response == nil {
+		return C.UA_StatusCode(status)
+	}
+
+	for _, item := range response.Values {
+		var variant *C.UA_Variant
+		if item.Value != nil {
+			variant = new(C.UA_Variant)
+			err := UA_VariantClangValue(*item.Value, variant)
+			if err != nil {
+				logs.Error("ua server history read %s failed, %s", request.NodeID, err.Error())
+				return C.UA_STATUSCODE_BADINTERNALERROR
+			}
+		}
+		retval := C.UA_HistoryResult_append(result, variant, TimeToDatetime(item.Time), C.UA_StatusCode(item.Status))
+		if variant != nil {
+			C.UA_Variant_clear(variant)
+		}
+		if retval != C.UA_STATUSCODE_GOOD {
+			return retval
+		}
+	}
+
+	if len(response.ContinuationPoint) > 0 {
+		cData := C.CBytes(response.ContinuationPoint)
+		defer C.free(cData)
+		return C.UA_HistoryResult_continuation(result, cData, C.size_t(len(response.ContinuationPoint)))
+	}
+	return C.UA_STATUSCODE_GOOD
+}
+
+// EnableHistory installs the history read backend of the raw and the processed reads
+func (s *Server) EnableHistory(handler HistoryHandler) error {
+	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
+
+	retval := C.UA_ServerHistory_init(server)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua server history init failed, retval = 0x%x", uint32(retval))
+	}
+
+	s.history = handler
+	serverRegistry.Store(s.srv, s)
+	return nil
+}
+
+func (s *Server) SetHistorizing(node NodeInfo) error {
+	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
+
+	cID := C.CString(node.NodeID)
+	defer C.free(unsafe.Pointer(cID))
+
+	retval := C.UA_ServerHistory_historizing(server, C.UA_UInt16(node.NsIndex), cID)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua server set historizing failed, retval = 0x%x", uint32(retval))
+	}
+	return nil
+}
+
+//export UA_MethodCall_golang
+func UA_MethodCall_golang(server unsafe.Pointer, username *C.char, nsIndex C.UA_UInt16, nodeID *C.char,
+	inputSize C.size_t, input *C.UA_Variant, outputSize C.size_t, output *C.UA_Variant) C.UA_StatusCode {
+
+	value, ok := serverRegistry.Load(uintptr(server))
+	if !ok || value.(*Server).method == nil {
+		return C.UA_STATUSCODE_BADMETHODINVALID
+	}
+	s := value.(*Server)
+
+	inputs := make([]*NodeValue, 0)
+	if inputSize > 0 {
+		variants := unsafe.Slice(input, int(inputSize))
+		for i := range variants {
+			value, err := UA_VariantGolangValue(&variants[i])
+			if err != nil {
+				logs.Warning("ua server method call input %d convert failed, %s", i, err.Error())
+				return C.UA_STATUSCODE_BADTYPEMISMATCH
+			}
+			inputs = append(inputs, value)
+		}
+	}
+
+	outputs, status := s.method(C.GoString(username), s.NameSpaceName(uint32(nsIndex)), C.GoString(nodeID), inputs)
+	if status != UA_STATUS_GOOD {
+		return C.UA_StatusCode(status)
+	}
+
+	if outputSize > 0 {
+		variants := unsafe.Slice(output, int(outputSize))
+		for i := range variants {
+			if i >= len(outputs) || outputs[i] == nil {
+				continue
+			}
+			err := UA_VariantClangValue(*outputs[i], &variants[i])
+			if err != nil {
+				logs.Warning("ua server method call output %d convert failed, %s", i, err.Error())
+				return C.UA_STATUSCODE_BADINTERNALERROR
+			}
+		}
+	}
+	return C.UA_STATUSCODE_GOOD
+}
+
+// EnableMethods installs the handler of the pass-through methods added by AddMethod
+func (s *Server) EnableMethods(handler MethodHandler) {
+	s.method = handler
+	serverRegistry.Store(s.srv, s)
+}
+
+func (s *Server) AddMethod(parent, current NodeInfo, name string, inputs, outputs []MethodArgument) error {
+	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
+
+	var cArgs C.MethodArguments
+	retval := C.UA_MethodArguments_alloc(&cArgs, C.size_t(len(inputs)), C.size_t(len(outputs)))
+	defer C.UA_MethodArguments_clear(&cArgs)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua server add method failed, retval = 0x%x", uint32(retval))
+	}
+
+	fill := func(cList *C.MethodArgument, list []MethodArgument) {
+		if len(list) == 0 {
+			return
+		}
+		items := unsafe.Slice(cList, len(list))
+		for i, arg := range list {
+			item := &items[i]
+			item.name = C.CString(arg.Name)
+			item.description = C.CString(arg.Description)
+			item.dataType = C.UA_UInt32(arg.DataType)
+			item.valueRank = C.UA_Int32(arg.ValueRank)
+		}
+	}
+	fill(cArgs.inputs, inputs)
+	fill(cArgs.outputs, outputs)
+
+	cParentID := C.CString(parent.NodeID)
+	defer C.free(unsafe.Pointer(cParentID))
+
+	cID := C.CString(current.NodeID)
+	defer C.free(unsafe.Pointer(cID))
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	retval = C.UA_ServerAddMethod(server, C.UA_UInt16(parent.NsIndex), cParentID, C.UA_UInt16(current.NsIndex), cID, cName, &cArgs)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua server add method failed, retval = 0x%x", uint32(retval))
+	}
+	return nil
+}
+
+func (s *Server) EnableEvents(node NodeInfo) error {
+	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
+
+	cID := C.CString(node.NodeID)
+	defer C.free(unsafe.Pointer(cID))
+
+	retval := C.UA_ServerEnableEvents(server, C.UA_UInt16(node.NsIndex), cID)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua server enable events failed, retval = 0x%x", uint32(retval))
+	}
+	return nil
+}
+
+// TriggerEvent emits the event on the origin object, the unknown event type is replaced by BaseEventType
+func (s *Server) TriggerEvent(origin NodeInfo, event ServerEvent) error {
+	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
+
+	cID := C.CString(origin.NodeID)
+	defer C.free(unsafe.Pointer(cID))
+
+	cMessage := C.CString(event.Message)
+	defer C.free(unsafe.Pointer(cMessage))
+
+	cSourceName := C.CString(event.SourceName)
+	defer C.free(unsafe.Pointer(cSourceName))
+
+	retval := C.UA_ServerTriggerEvent(server, C.UA_UInt16(origin.NsIndex), cID, C.UA_UInt32(event.Type),
+		TimeToDatetime(event.Time), C.UA_UInt16(event.Severity), cMessage, cSourceName)
+	if retval != C.UA_STATUSCODE_GOOD {
+		return fmt.Errorf("ua server trigger event failed, retval = 0x%x", uint32(retval))
+	}
+	return nil
+}
+
+// Permission returns the access permission of the user, full access without access control
+func (s *Server) Permission(user string, namespace string, nodeID string) AccessPermission {
+	if s.access == nil {
+		return AccessPermission{Read: true, Write: true, Browse: true}
+	}
+	return s.access(user, namespace, nodeID)
+}
+
+// Denied reports the rejected operation of the user to the access control
+func (s *Server) Denied(user string, namespace string, nodeID string, operation string) {
+	if s.denied != nil {
+		s.denied(user, namespace, nodeID, operation)
+	}
+}
+
 func (s *Server) Close() {
 	server := (*C.UA_Server)(unsafe.Pointer(s.srv))
 
 	s.running = false
 	s.Wait()
+	<-s.stopped
 
 	C.UA_Server_run_shutdown(server)
 	C.UA_Server_delete(server)
+
+	serverRegistry.Delete(s.srv)
 }
@@ -250,6 +250,14 @@ UA_StatusCode UA_ArrayValueInit(ArrayValue *value, uint32_t uaType) {
   case UA_TYPES_BYTESTRING:
     value->data = UA_Array_new(0, &UA_TYPES[UA_TYPES_BYTESTRING]);
     break;
+  case UA_TYPES_GUID:
+  case UA_TYPES_NODEID:
+  case UA_TYPES_STATUSCODE:
+  case UA_TYPES_QUALIFIEDNAME:
+  case UA_TYPES_LOCALIZEDTEXT:
+  case UA_TYPES_EXTENSIONOBJECT:
+    value->data = UA_Array_new(0, &UA_TYPES[uaType]);
+    break;
   default:
     return UA_STATUSCODE_BADTYPEMISMATCH;
   }
@@ -341,145 +349,582 @@ void UA_VariantArrayValue(UA_Variant *variant, ArrayValue *value,
   UA_Variant_setArray(variant, value->data, value->length, &UA_TYPES[uaType]);
 }
 
+UA_StatusCode UA_VariantSetDimensions(UA_Variant *variant, UA_UInt32 *dims,
+                                      size_t size) {
+  UA_StatusCode retval =
+      UA_Array_copy(dims, size, (void **)&variant->arrayDimensions,
+                    &UA_TYPES[UA_TYPES_UINT32]);
+  if (retval == UA_STATUSCODE_GOOD) {
+    variant->arrayDimensionsSize = size;
+  }
+  return retval;
+}
+
+UA_UInt32 UA_VariantDimension(UA_Variant *variant, size_t index) {
+  return variant->arrayDimensions[index];
+}
+
+// 多维数组的节点声明维度, 维度数组引用 variant 不做拷贝
+static void ua_VariableAttributesShape(UA_VariableAttributes *attr,
+                                       UA_Variant *variant) {
+  if (variant->arrayDimensionsSize > 1) {
+    attr->valueRank = (UA_Int32)variant->arrayDimensionsSize;
+    attr->arrayDimensionsSize = variant->arrayDimensionsSize;
+    attr->arrayDimensions = variant->arrayDimensions;
+  }
+}
+
 //
 
-NodeTree *ua_NodeTree_init(NodeTree *parent, uint32_t level, uint32_t index,
-                           void *nodeID, size_t length) {
-  NodeTree *node = (NodeTree *)malloc(sizeof(NodeTree));
-  if (node == NULL) {
+static char *ua_String_chars(const UA_String *value) {
+  char *output = (char *)malloc(value->length + 1);
+  if (output == NULL) {
     return NULL;
   }
-  memset(node, 0, sizeof(NodeTree));
+  if (value->length > 0) {
+    memcpy(output, value->data, value->length);
+  }
+  output[value->length] = '\0';
+  return output;
+}
 
-  if (length) {
-    node->nodeID = (char *)malloc(length + 1);
-    if (node->nodeID == NULL) {
-      free(node);
-      return NULL;
-    }
-    memset(node->nodeID, '\0', length + 1);
-    memcpy(node->nodeID, nodeID, length);
+void *UA_VariantValueAt(UA_Variant *value, int index) {
+  return (char *)value->data + (size_t)index * value->type->memSize;
+}
+
+void UA_GuidText(const UA_Guid *guid, char *output) {
+  snprintf(output, UA_GUID_TEXT_LENGTH, UA_PRINTF_GUID_FORMAT,
+           UA_PRINTF_GUID_DATA(*guid));
+}
+
+// 标识符不复制, 字符串和字节串的内存由调用者释放
+UA_NodeId UA_NodeIdMake(UA_UInt16 nsIndex, char idType, char *identifier,
+                        size_t length) {
+  UA_NodeId nodeId = UA_NODEID_NULL;
+  switch (idType) {
+  case 'i':
+    nodeId =
+        UA_NODEID_NUMERIC(nsIndex, (UA_UInt32)strtoul(identifier, NULL, 10));
+    break;
+  case 'g':
+    nodeId.namespaceIndex = nsIndex;
+    nodeId.identifierType = UA_NODEIDTYPE_GUID;
+    UA_Guid_parse(&nodeId.identifier.guid, UA_STRING(identifier));
+    break;
+  case 'b':
+    nodeId.namespaceIndex = nsIndex;
+    nodeId.identifierType = UA_NODEIDTYPE_BYTESTRING;
+    nodeId.identifier.byteString.length = length;
+    nodeId.identifier.byteString.data = (UA_Byte *)identifier;
+    break;
+  default:
+    nodeId = UA_NODEID_STRING(nsIndex, identifier);
   }
+  return nodeId;
+}
 
-  node->level = level;
-  node->index = index;
-  node->parent = parent;
-  node->nodeLength = length;
+char *UA_NodeIdText(const UA_NodeId *nodeID) {
+  UA_String output = UA_STRING_NULL;
+  UA_NodeId_print(nodeID, &output);
+  char *text = ua_String_chars(&output);
+  UA_String_clear(&output);
+  return text;
+}
 
-  if (parent != NULL) {
-    if (parent->head == NULL) {
-      parent->head = node;
-    } else {
-      parent->tail->next = node;
-    }
-    parent->tail = node;
+const char *UA_StatusCodeText(UA_StatusCode code) {
+  return UA_StatusCode_name(code);
+}
+
+UA_StatusCode UA_ExtensionObjectEncoded(const UA_ExtensionObject *eo,
+                                        char **typeID, UA_ByteString *body) {
+  UA_StatusCode retval = UA_STATUSCODE_GOOD;
+  UA_ByteString_init(body);
+  *typeID = NULL;
+
+  switch (eo->encoding) {
+  case UA_EXTENSIONOBJECT_ENCODED_NOBODY:
+  case UA_EXTENSIONOBJECT_ENCODED_BYTESTRING:
+    *typeID = UA_NodeIdText(&eo->content.encoded.typeId);
+    retval = UA_ByteString_copy(&eo->content.encoded.body, body);
+    break;
+  case UA_EXTENSIONOBJECT_DECODED:
+  case UA_EXTENSIONOBJECT_DECODED_NODELETE:
+    *typeID = UA_NodeIdText(&eo->content.decoded.type->binaryEncodingId);
+    retval = UA_encodeBinary(eo->content.decoded.data, eo->content.decoded.type,
+                             body);
+    break;
+  default:
+    return UA_STATUSCODE_BADDATAENCODINGUNSUPPORTED;
   }
 
-  return node;
+  if (retval == UA_STATUSCODE_GOOD && *typeID == NULL) {
+    retval = UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  if (retval != UA_STATUSCODE_GOOD) {
+    free(*typeID);
+    *typeID = NULL;
+    UA_ByteString_clear(body);
+  }
+  return retval;
 }
 
-NodeTree *UA_NodeTree_root_init() {
-  NodeTree *node = (NodeTree *)malloc(sizeof(NodeTree));
-  if (node == NULL) {
+const UA_DataType *UA_ExtensionObjectDecoded(const UA_ExtensionObject *eo,
+                                             void **data) {
+  if (eo->encoding != UA_EXTENSIONOBJECT_DECODED &&
+      eo->encoding != UA_EXTENSIONOBJECT_DECODED_NODELETE) {
     return NULL;
   }
-  memset(node, 0, sizeof(NodeTree));
-  return node;
+  *data = eo->content.decoded.data;
+  return eo->content.decoded.type;
+}
+
+void UA_DataTypeInfoGet(const UA_DataType *type, DataTypeInfo *info) {
+  info->typeName = type->typeName;
+  info->memSize = type->memSize;
+  info->typeKind = type->typeKind;
+  info->membersSize = type->membersSize;
+}
+
+void UA_DataTypeMemberGet(const UA_DataType *type, size_t index,
+                          DataTypeMember *member) {
+  const UA_DataTypeMember *m = &type->members[index];
+  member->name = m->memberName;
+  member->type = m->memberType;
+  member->padding = m->padding;
+  member->isArray = m->isArray;
+  member->isOptional = m->isOptional;
+}
+
+void UA_VariantView(UA_Variant *variant, void *data, size_t length,
+                    const UA_DataType *type, UA_Boolean array) {
+  UA_Variant_init(variant);
+  if (array) {
+    UA_Variant_setArray(variant, length > 0 ? data : UA_EMPTY_ARRAY_SENTINEL,
+                        length, type);
+  } else {
+    UA_Variant_setScalar(variant, data, type);
+  }
+}
+
+static UA_StatusCode ua_Guid_make(char *value, UA_Guid *guid) {
+  return UA_Guid_parse(guid, UA_STRING(value));
+}
+
+static UA_StatusCode ua_ExtensionObject_make(char *typeID, void *body,
+                                             size_t length,
+                                             UA_ExtensionObject *eo) {
+  UA_ExtensionObject_init(eo);
+  UA_StatusCode retval =
+      UA_NodeId_parse(&eo->content.encoded.typeId, UA_STRING(typeID));
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  eo->encoding = length > 0 ? UA_EXTENSIONOBJECT_ENCODED_BYTESTRING
+                            : UA_EXTENSIONOBJECT_ENCODED_NOBODY;
+  eo->content.encoded.body.data = (UA_Byte *)body;
+  eo->content.encoded.body.length = length;
+  return UA_STATUSCODE_GOOD;
+}
+
+UA_StatusCode UA_VariantScalarValueGuid(UA_Variant *variant, char *value) {
+  UA_Guid guid;
+  UA_StatusCode retval = ua_Guid_make(value, &guid);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  return UA_Variant_setScalarCopy(variant, &guid, &UA_TYPES[UA_TYPES_GUID]);
+}
+
+UA_StatusCode UA_VariantScalarValueNodeId(UA_Variant *variant, char *value) {
+  UA_NodeId nodeID;
+  UA_StatusCode retval = UA_NodeId_parse(&nodeID, UA_STRING(value));
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  retval = UA_Variant_setScalarCopy(variant, &nodeID, &UA_TYPES[UA_TYPES_NODEID]);
+  UA_NodeId_clear(&nodeID);
+  return retval;
+}
+
+UA_StatusCode UA_VariantScalarValueStatusCode(UA_Variant *variant,
+                                              UA_StatusCode value) {
+  return UA_Variant_setScalarCopy(variant, &value,
+                                  &UA_TYPES[UA_TYPES_STATUSCODE]);
+}
+
+UA_StatusCode UA_VariantScalarValueQualifiedName(UA_Variant *variant,
+                                                 UA_UInt16 nsIndex,
+                                                 char *name) {
+  UA_QualifiedName value = UA_QUALIFIEDNAME(nsIndex, name);
+  return UA_Variant_setScalarCopy(variant, &value,
+                                  &UA_TYPES[UA_TYPES_QUALIFIEDNAME]);
+}
+
+UA_StatusCode UA_VariantScalarValueLocalizedText(UA_Variant *variant,
+                                                 char *locale, char *text) {
+  UA_LocalizedText value = UA_LOCALIZEDTEXT(locale, text);
+  return UA_Variant_setScalarCopy(variant, &value,
+                                  &UA_TYPES[UA_TYPES_LOCALIZEDTEXT]);
+}
+
+UA_StatusCode UA_VariantScalarValueExtensionObject(UA_Variant *variant,
+                                                   char *typeID, void *body,
+                                                   size_t length) {
+  UA_ExtensionObject eo;
+  UA_StatusCode retval = ua_ExtensionObject_make(typeID, body, length, &eo);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  retval = UA_Variant_setScalarCopy(variant, &eo,
+                                    &UA_TYPES[UA_TYPES_EXTENSIONOBJECT]);
+  UA_NodeId_clear(&eo.content.encoded.typeId);
+  return retval;
+}
+
+UA_StatusCode UA_ArrayValueAppendGuid(ArrayValue *array, char *value) {
+  UA_Guid guid;
+  UA_StatusCode retval = ua_Guid_make(value, &guid);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  return UA_Array_appendCopy(&array->data, &array->length, &guid,
+                             &UA_TYPES[UA_TYPES_GUID]);
+}
+
+UA_StatusCode UA_ArrayValueAppendNodeId(ArrayValue *array, char *value) {
+  UA_NodeId nodeID;
+  UA_StatusCode retval = UA_NodeId_parse(&nodeID, UA_STRING(value));
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  retval = UA_Array_appendCopy(&array->data, &array->length, &nodeID,
+                               &UA_TYPES[UA_TYPES_NODEID]);
+  UA_NodeId_clear(&nodeID);
+  return retval;
+}
+
+UA_StatusCode UA_ArrayValueAppendStatusCode(ArrayValue *array,
+                                            UA_StatusCode value) {
+  return UA_Array_appendCopy(&array->data, &array->length, &value,
+                             &UA_TYPES[UA_TYPES_STATUSCODE]);
+}
+
+UA_StatusCode UA_ArrayValueAppendQualifiedName(ArrayValue *array,
+                                               UA_UInt16 nsIndex, char *name) {
+  UA_QualifiedName value = UA_QUALIFIEDNAME(nsIndex, name);
+  return UA_Array_appendCopy(&array->data, &array->length, &value,
+                             &UA_TYPES[UA_TYPES_QUALIFIEDNAME]);
 }
 
-void UA_NodeTree_clear(NodeTree *node) {
-  NodeTree *cur = node->head;
-  while (cur) {
-    NodeTree *next = cur->next;
-    UA_NodeTree_clear(cur);
-    cur = next;
+UA_StatusCode UA_ArrayValueAppendLocalizedText(ArrayValue *array, char *locale,
+                                               char *text) {
+  UA_LocalizedText value = UA_LOCALIZEDTEXT(locale, text);
+  return UA_Array_appendCopy(&array->data, &array->length, &value,
+                             &UA_TYPES[UA_TYPES_LOCALIZEDTEXT]);
+}
+
+static UA_StatusCode ua_ClientReadItem(UA_Client *client,
+                                       UA_ReadValueId *item,
+                                       UA_Variant *value) {
+  UA_ReadRequest request;
+  UA_ReadRequest_init(&request);
+  request.nodesToRead = item;
+  request.nodesToReadSize = 1;
+
+  UA_ReadResponse response = UA_Client_Service_read(client, request);
+
+  UA_StatusCode retval = response.responseHeader.serviceResult;
+  if (retval == UA_STATUSCODE_GOOD && response.resultsSize != 1) {
+    retval = UA_STATUSCODE_BADUNEXPECTEDERROR;
+  }
+  if (retval == UA_STATUSCODE_GOOD && response.results[0].hasStatus) {
+    retval = response.results[0].status;
+  }
+  if (retval == UA_STATUSCODE_GOOD && !response.results[0].hasValue) {
+    retval = UA_STATUSCODE_BADNODATA;
+  }
+  if (retval == UA_STATUSCODE_GOOD) {
+    *value = response.results[0].value;
+    UA_Variant_init(&response.results[0].value);
   }
-  if (node->nodeID) {
-    memset(node->nodeID, 0, strlen(node->nodeID));
-    free(node->nodeID);
+  UA_ReadResponse_clear(&response);
+  return retval;
+}
+
+UA_StatusCode UA_ClientReadAttributeText(UA_Client *client, char *nodeID,
+                                         UA_UInt32 attributeID,
+                                         UA_Variant *value) {
+  UA_Variant_init(value);
+
+  UA_ReadValueId item;
+  UA_ReadValueId_init(&item);
+  UA_StatusCode retval = UA_NodeId_parse(&item.nodeId, UA_STRING(nodeID));
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
   }
-  memset(node, 0, sizeof(NodeTree));
-  free(node);
+  item.attributeId = attributeID;
+
+  retval = ua_ClientReadItem(client, &item, value);
+  UA_NodeId_clear(&item.nodeId);
+  return retval;
 }
 
-NodeTree *UA_NodeTree_next(NodeTree *node) { return node->next; }
+UA_StatusCode UA_ClientReadValueRange(UA_Client *client, UA_NodeId nodeId,
+                                      char *indexRange, UA_Variant *value) {
+  UA_Variant_init(value);
 
-NodeTree *UA_NodeTree_head(NodeTree *node) { return node->head; }
+  UA_ReadValueId item;
+  UA_ReadValueId_init(&item);
+  item.nodeId = nodeId;
+  item.attributeId = UA_ATTRIBUTEID_VALUE;
+  item.indexRange = UA_STRING(indexRange);
+
+  return ua_ClientReadItem(client, &item, value);
+}
 
-UA_StatusCode UA_Browse_nodeTreeLevel(UA_Client *client, UA_NodeId nodeId,
-                                      NodeTree *parent, uint32_t level) {
+char *UA_ClientBrowseFirst(UA_Client *client, char *nodeID,
+                           UA_UInt32 referenceType, UA_Boolean subtypes,
+                           UA_Boolean inverse, UA_StatusCode *status) {
   UA_BrowseRequest bReq;
   UA_BrowseRequest_init(&bReq);
-  bReq.requestedMaxReferencesPerNode = 0;
+  bReq.requestedMaxReferencesPerNode = 1;
   bReq.nodesToBrowse = UA_BrowseDescription_new();
   bReq.nodesToBrowseSize = 1;
 
-  UA_NodeId_copy(&nodeId, &bReq.nodesToBrowse[0].nodeId);
-  bReq.nodesToBrowse[0].resultMask = UA_BROWSERESULTMASK_ALL;
+  *status = UA_NodeId_parse(&bReq.nodesToBrowse[0].nodeId, UA_STRING(nodeID));
+  if (*status != UA_STATUSCODE_GOOD) {
+    UA_BrowseRequest_clear(&bReq);
+    return NULL;
+  }
+  bReq.nodesToBrowse[0].referenceTypeId = UA_NODEID_NUMERIC(0, referenceType);
+  bReq.nodesToBrowse[0].browseDirection =
+      inverse ? UA_BROWSEDIRECTION_INVERSE : UA_BROWSEDIRECTION_FORWARD;
+  bReq.nodesToBrowse[0].includeSubtypes = subtypes;
+  bReq.nodesToBrowse[0].resultMask = UA_BROWSERESULTMASK_NONE;
 
   UA_BrowseResponse bResp = UA_Client_Service_browse(client, bReq);
-  if (bResp.responseHeader.serviceResult != UA_STATUSCODE_GOOD) {
-    UA_BrowseResponse_clear(&bResp);
-    return bResp.responseHeader.serviceResult;
+  UA_BrowseRequest_clear(&bReq);
+
+  char *target = NULL;
+  *status = bResp.responseHeader.serviceResult;
+  if (*status == UA_STATUSCODE_GOOD && bResp.resultsSize == 1) {
+    *status = bResp.results[0].statusCode;
+    if (*status == UA_STATUSCODE_GOOD && bResp.results[0].referencesSize > 0) {
+      target = UA_NodeIdText(&bResp.results[0].references[0].nodeId.nodeId);
+    } else if (*status == UA_STATUSCODE_GOOD) {
+      *status = UA_STATUSCODE_BADNOTFOUND;
+    }
   }
+  UA_BrowseResponse_clear(&bResp);
+  return target;
+}
+
+UA_StatusCode UA_ArrayValueAppendExtensionObject(ArrayValue *array,
+                                                 char *typeID, void *body,
+                                                 size_t length) {
+  UA_ExtensionObject eo;
+  UA_StatusCode retval = ua_ExtensionObject_make(typeID, body, length, &eo);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  retval = UA_Array_appendCopy(&array->data, &array->length, &eo,
+                               &UA_TYPES[UA_TYPES_EXTENSIONOBJECT]);
+  UA_NodeId_clear(&eo.content.encoded.typeId);
+  return retval;
+}
 
-  for (int i = 0; i < bResp.resultsSize; i++) {
-    for (int j = 0; j < bResp.results[i].referencesSize; j++) {
-      NodeTree *node = NULL;
+//
 
-      UA_ReferenceDescription *ref = &(bResp.results[i].references[j]);
-      if ((ref->nodeClass == UA_NODECLASS_OBJECT ||
-           ref->nodeClass == UA_NODECLASS_VARIABLE ||
-           ref->nodeClass == UA_NODECLASS_METHOD)) {
-        if (ref->nodeId.nodeId.identifierType == UA_NODEIDTYPE_NUMERIC) {
-
-          node = ua_NodeTree_init(
-              parent, level, ref->nodeId.nodeId.namespaceIndex,
-              ref->browseName.name.data, ref->browseName.name.length);
-          if (node == NULL) {
-            return UA_STATUSCODE_BADOUTOFMEMORY;
-          }
-
-          UA_StatusCode retval = UA_Browse_nodeTreeLevel(
-              client,
-              UA_NODEID_NUMERIC(ref->nodeId.nodeId.namespaceIndex,
-                                ref->nodeId.nodeId.identifier.numeric),
-              node, level + 1);
-
-          if (retval != UA_STATUSCODE_GOOD) {
-            return retval;
-          }
-
-        } else if (ref->nodeId.nodeId.identifierType == UA_NODEIDTYPE_STRING) {
-
-          node =
-              ua_NodeTree_init(parent, level, ref->nodeId.nodeId.namespaceIndex,
-                               ref->nodeId.nodeId.identifier.string.data,
-                               ref->nodeId.nodeId.identifier.string.length);
-          if (node == NULL) {
-            return UA_STATUSCODE_BADOUTOFMEMORY;
-          }
-
-          UA_StatusCode retval = UA_Browse_nodeTreeLevel(
-              client,
-              UA_NODEID_STRING(ref->nodeId.nodeId.namespaceIndex, node->nodeID),
-              node, level + 1);
-          if (retval != UA_STATUSCODE_GOOD) {
-            return retval;
-          }
+static UA_StatusCode ua_BrowseItems_append(BrowseItem **items, size_t *size,
+                                           const UA_BrowseResult *result) {
+  if (result->referencesSize == 0) {
+    return UA_STATUSCODE_GOOD;
+  }
+  BrowseItem *output = (BrowseItem *)realloc(
+      *items, (*size + result->referencesSize) * sizeof(BrowseItem));
+  if (output == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  *items = output;
+
+  for (size_t i = 0; i < result->referencesSize; i++) {
+    const UA_ReferenceDescription *ref = &result->references[i];
+    // 其他服务器上的节点不浏览
+    if (ref->nodeId.serverIndex != 0) {
+      continue;
+    }
+    BrowseItem *item = &output[*size];
+    memset(item, 0, sizeof(BrowseItem));
+    UA_NodeId_copy(&ref->nodeId.nodeId, &item->id);
+    item->nodeID = UA_NodeIdText(&ref->nodeId.nodeId);
+    item->nsIndex = ref->nodeId.nodeId.namespaceIndex;
+    item->browseName = ua_String_chars(&ref->browseName.name);
+    item->displayName = ua_String_chars(&ref->displayName.text);
+    item->nodeClass = ref->nodeClass;
+    (*size)++;
+  }
+  return UA_STATUSCODE_GOOD;
+}
+
+static void ua_BrowseItems_attributes(UA_Client *client, BrowseItem *items,
+                                      size_t size, size_t chunk) {
+  size_t *index = (size_t *)malloc(size * sizeof(size_t));
+  if (index == NULL) {
+    return;
+  }
+  size_t count = 0;
+  for (size_t i = 0; i < size; i++) {
+    if (items[i].nodeClass == UA_NODECLASS_VARIABLE) {
+      index[count++] = i;
+    }
+  }
+  if (chunk == 0 || chunk > count) {
+    chunk = count;
+  }
+
+  // 每个变量读 DataType 和 AccessLevel 两个属性
+  for (size_t begin = 0; begin < count; begin += chunk) {
+    size_t end = begin + chunk < count ? begin + chunk : count;
+    UA_ReadRequest request;
+    UA_ReadRequest_init(&request);
+    request.nodesToReadSize = (end - begin) * 2;
+    request.nodesToRead = (UA_ReadValueId *)UA_Array_new(
+        request.nodesToReadSize, &UA_TYPES[UA_TYPES_READVALUEID]);
+    if (request.nodesToRead == NULL) {
+      break;
+    }
+    for (size_t i = begin; i < end; i++) {
+      UA_ReadValueId *ids = &request.nodesToRead[(i - begin) * 2];
+      UA_NodeId_copy(&items[index[i]].id, &ids[0].nodeId);
+      ids[0].attributeId = UA_ATTRIBUTEID_DATATYPE;
+      UA_NodeId_copy(&items[index[i]].id, &ids[1].nodeId);
+      ids[1].attributeId = UA_ATTRIBUTEID_ACCESSLEVEL;
+    }
+
+    UA_ReadResponse response = UA_Client_Service_read(client, request);
+    UA_ReadRequest_clear(&request);
+    if (response.responseHeader.serviceResult == UA_STATUSCODE_GOOD &&
+        response.resultsSize == (end - begin) * 2) {
+      for (size_t i = begin; i < end; i++) {
+        BrowseItem *item = &items[index[i]];
+        UA_DataValue *values = &response.results[(i - begin) * 2];
+        if (values[0].hasValue &&
+            UA_Variant_hasScalarType(&values[0].value,
+                                     &UA_TYPES[UA_TYPES_NODEID])) {
+          item->dataType = UA_NodeIdText((UA_NodeId *)values[0].value.data);
+        }
+        if (values[1].hasValue &&
+            UA_Variant_hasScalarType(&values[1].value,
+                                     &UA_TYPES[UA_TYPES_BYTE])) {
+          item->accessLevel = *(UA_Byte *)values[1].value.data;
         }
       }
     }
+    UA_ReadResponse_clear(&response);
+  }
+  free(index);
+}
+
+UA_StatusCode UA_ClientBrowseChildren(UA_Client *client, char *nodeID,
+                                      size_t chunk, BrowseItem **items,
+                                      size_t *size) {
+  *items = NULL;
+  *size = 0;
+
+  UA_BrowseRequest bReq;
+  UA_BrowseRequest_init(&bReq);
+  bReq.requestedMaxReferencesPerNode = UA_BROWSE_MAX_REFERENCES;
+  bReq.nodesToBrowse = UA_BrowseDescription_new();
+  bReq.nodesToBrowseSize = 1;
+
+  UA_StatusCode retval =
+      UA_NodeId_parse(&bReq.nodesToBrowse[0].nodeId, UA_STRING(nodeID));
+  if (retval != UA_STATUSCODE_GOOD) {
+    UA_BrowseRequest_clear(&bReq);
+    return retval;
+  }
+  bReq.nodesToBrowse[0].referenceTypeId =
+      UA_NODEID_NUMERIC(0, UA_NS0ID_HIERARCHICALREFERENCES);
+  bReq.nodesToBrowse[0].includeSubtypes = true;
+  bReq.nodesToBrowse[0].browseDirection = UA_BROWSEDIRECTION_FORWARD;
+  bReq.nodesToBrowse[0].nodeClassMask =
+      UA_NODECLASS_OBJECT | UA_NODECLASS_VARIABLE | UA_NODECLASS_METHOD;
+  bReq.nodesToBrowse[0].resultMask = UA_BROWSERESULTMASK_ALL;
+
+  UA_BrowseResponse bResp = UA_Client_Service_browse(client, bReq);
+  UA_BrowseRequest_clear(&bReq);
+
+  retval = bResp.responseHeader.serviceResult;
+  if (retval == UA_STATUSCODE_GOOD && bResp.resultsSize != 1) {
+    retval = UA_STATUSCODE_BADUNEXPECTEDERROR;
+  }
+  if (retval == UA_STATUSCODE_GOOD) {
+    retval = bResp.results[0].statusCode;
+  }
+  if (retval == UA_STATUSCODE_GOOD) {
+    retval = ua_BrowseItems_append(items, size, &bResp.results[0]);
   }
 
+  UA_ByteString point = UA_BYTESTRING_NULL;
+  if (retval == UA_STATUSCODE_GOOD) {
+    UA_ByteString_copy(&bResp.results[0].continuationPoint, &point);
+  }
   UA_BrowseResponse_clear(&bResp);
 
+  // 按 continuation point 继续浏览，超过页数限制时释放
+  for (uint32_t page = 1; point.length > 0; page++) {
+    UA_BrowseNextRequest nReq;
+    UA_BrowseNextRequest_init(&nReq);
+    nReq.releaseContinuationPoints =
+        retval != UA_STATUSCODE_GOOD || page >= UA_BROWSE_MAX_PAGES;
+    nReq.continuationPoints = &point;
+    nReq.continuationPointsSize = 1;
+
+    UA_BrowseNextResponse nResp = UA_Client_Service_browseNext(client, nReq);
+    UA_Boolean release = nReq.releaseContinuationPoints;
+    UA_ByteString_clear(&point);
+    if (release) {
+      UA_BrowseNextResponse_clear(&nResp);
+      break;
+    }
+
+    retval = nResp.responseHeader.serviceResult;
+    if (retval == UA_STATUSCODE_GOOD && nResp.resultsSize != 1) {
+      retval = UA_STATUSCODE_BADUNEXPECTEDERROR;
+    }
+    if (retval == UA_STATUSCODE_GOOD) {
+      retval = nResp.results[0].statusCode;
+    }
+    if (retval == UA_STATUSCODE_GOOD) {
+      retval = ua_BrowseItems_append(items, size, &nResp.results[0]);
+      UA_ByteString_copy(&nResp.results[0].continuationPoint, &point);
+    }
+    UA_BrowseNextResponse_clear(&nResp);
+  }
+
+  if (retval != UA_STATUSCODE_GOOD) {
+    UA_BrowseItems_free(*items, *size);
+    *items = NULL;
+    *size = 0;
+    return retval;
+  }
+
+  ua_BrowseItems_attributes(client, *items, *size, chunk);
   return UA_STATUSCODE_GOOD;
 }
 
-UA_StatusCode UA_Browse_nodeTree(UA_Client *client, NodeTree *root) {
-  return UA_Browse_nodeTreeLevel(
-      client, UA_NODEID_NUMERIC(0, UA_NS0ID_OBJECTSFOLDER), root, 1);
+void UA_BrowseItems_free(BrowseItem *items, size_t size) {
+  if (items == NULL) {
+    return;
+  }
+  for (size_t i = 0; i < size; i++) {
+    UA_NodeId_clear(&items[i].id);
+    free(items[i].nodeID);
+    free(items[i].browseName);
+    free(items[i].displayName);
+    free(items[i].dataType);
+  }
+  free(items);
 }
 
 UA_StatusCode UA_VariantValueWrite(UA_Client *client, uint32_t nsIndex,
@@ -517,13 +962,206 @@ UA_ReadValueId *UA_ReadValueID_alloc(int number) {
 
 void UA_ReadValueID_free(UA_ReadValueId *readValueId) { UA_free(readValueId); }
 
-void UA_ReadValueID_string(UA_ReadValueId *readValueId, int index,
-                           UA_UInt16 nsIndex, char *chars,
-                           UA_UInt32 attributeId) {
-  readValueId[index].nodeId = UA_NODEID_STRING(nsIndex, chars);
+void UA_ReadValueID_set(UA_ReadValueId *readValueId, int index,
+                        UA_NodeId nodeId, UA_UInt32 attributeId) {
+  readValueId[index].nodeId = nodeId;
   readValueId[index].attributeId = attributeId;
 }
 
+void UA_ReadValueID_range(UA_ReadValueId *readValueId, int index,
+                          char *indexRange) {
+  readValueId[index].indexRange = UA_STRING(indexRange);
+}
+
+// 分块请求的上下文, 异步回调按块保存响应
+typedef struct {
+  size_t pending;
+  void *responses;
+  UA_StatusCode *status;
+  UA_Boolean write;
+} ChunkContext;
+
+typedef struct {
+  ChunkContext *context;
+  size_t index;
+} ChunkRequest;
+
+static void ua_ChunkResponse(UA_Client *client, void *userdata,
+                             UA_UInt32 requestId, void *response) {
+  ChunkRequest *request = (ChunkRequest *)userdata;
+  ChunkContext *context = request->context;
+  if (context->write) {
+    UA_WriteResponse *wr = (UA_WriteResponse *)response;
+    ((UA_WriteResponse *)context->responses)[request->index] = *wr;
+    context->status[request->index] = wr->responseHeader.serviceResult;
+    UA_WriteResponse_init(wr);
+  } else {
+    UA_ReadResponse *rr = (UA_ReadResponse *)response;
+    ((UA_ReadResponse *)context->responses)[request->index] = *rr;
+    context->status[request->index] = rr->responseHeader.serviceResult;
+    UA_ReadResponse_init(rr);
+  }
+  context->pending--;
+}
+
+static void ua_ChunkReadCallback(UA_Client *client, void *userdata,
+                                 UA_UInt32 requestId, UA_ReadResponse *rr) {
+  ua_ChunkResponse(client, userdata, requestId, rr);
+}
+
+static void ua_ChunkWriteCallback(UA_Client *client, void *userdata,
+                                  UA_UInt32 requestId, UA_WriteResponse *wr) {
+  ua_ChunkResponse(client, userdata, requestId, wr);
+}
+
+// 按 chunkSize 拆分请求, 最多 pipeline 个请求同时等待响应, status
+// 返回每个块的服务结果, responses 由调用者按块释放
+static UA_StatusCode ua_ClientChunks(UA_Client *client, void *items,
+                                     size_t size, size_t chunkSize,
+                                     size_t pipeline, UA_Boolean write,
+                                     void *responses, UA_StatusCode *status) {
+  size_t chunks = (size + chunkSize - 1) / chunkSize;
+  ChunkRequest *requests =
+      (ChunkRequest *)calloc(chunks > 0 ? chunks : 1, sizeof(ChunkRequest));
+  if (requests == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+
+  ChunkContext context = {0, responses, status, write};
+  UA_StatusCode retval = UA_STATUSCODE_GOOD;
+  size_t next = 0;
+
+  while (next < chunks || context.pending > 0) {
+    while (next < chunks && context.pending < pipeline) {
+      size_t offset = next * chunkSize;
+      size_t count = size - offset < chunkSize ? size - offset : chunkSize;
+      requests[next].context = &context;
+      requests[next].index = next;
+
+      UA_StatusCode sent;
+      if (write) {
+        UA_WriteRequest request;
+        UA_WriteRequest_init(&request);
+        request.nodesToWrite = (UA_WriteValue *)items + offset;
+        request.nodesToWriteSize = count;
+        sent = UA_Client_sendAsyncWriteRequest(
+            client, &request, ua_ChunkWriteCallback, &requests[next], NULL);
+      } else {
+        UA_ReadRequest request;
+        UA_ReadRequest_init(&request);
+        request.nodesToRead = (UA_ReadValueId *)items + offset;
+        request.nodesToReadSize = count;
+        sent = UA_Client_sendAsyncReadRequest(
+            client, &request, ua_ChunkReadCallback, &requests[next], NULL);
+      }
+      if (sent == UA_STATUSCODE_GOOD) {
+        context.pending++;
+      } else {
+        status[next] = sent;
+      }
+      next++;
+    }
+
+    if (context.pending == 0) {
+      continue;
+    }
+    retval = UA_Client_run_iterate(client, 100);
+    if (retval != UA_STATUSCODE_GOOD) {
+      // 断开连接时等待中的请求会以错误状态回调, 上下文不会被悬空引用
+      UA_Client_disconnect(client);
+      for (; next < chunks; next++) {
+        status[next] = retval;
+      }
+      break;
+    }
+  }
+
+  free(requests);
+  return retval;
+}
+
+UA_StatusCode UA_ClientReadChunks(UA_Client *client, UA_ReadValueId *items,
+                                  size_t size, size_t chunkSize,
+                                  size_t pipeline, UA_ReadResponse *responses,
+                                  UA_StatusCode *status) {
+  return ua_ClientChunks(client, items, size, chunkSize, pipeline, false,
+                         responses, status);
+}
+
+UA_StatusCode UA_ClientWriteChunks(UA_Client *client, UA_WriteValue *items,
+                                   size_t size, size_t chunkSize,
+                                   size_t pipeline, UA_WriteResponse *responses,
+                                   UA_StatusCode *status) {
+  return ua_ClientChunks(client, items, size, chunkSize, pipeline, true,
+                         responses, status);
+}
+
+UA_ReadResponse *UA_ReadResponse_alloc(size_t size) {
+  UA_ReadResponse *responses =
+      (UA_ReadResponse *)UA_Array_new(size, &UA_TYPES[UA_TYPES_READRESPONSE]);
+  return responses;
+}
+
+void UA_ReadResponse_free(UA_ReadResponse *responses, size_t size) {
+  UA_Array_delete(responses, size, &UA_TYPES[UA_TYPES_READRESPONSE]);
+}
+
+UA_DataValue *UA_ReadResponse_result(UA_ReadResponse *responses, size_t chunk,
+                                     size_t index) {
+  if (index >= responses[chunk].resultsSize) {
+    return NULL;
+  }
+  return &responses[chunk].results[index];
+}
+
+UA_StatusCode UA_DataValueStatus(UA_DataValue *value) {
+  if (value->hasStatus) {
+    return value->status;
+  }
+  if (!value->hasValue) {
+    return UA_STATUSCODE_BADNODATA;
+  }
+  return UA_STATUSCODE_GOOD;
+}
+
+UA_WriteValue *UA_WriteValue_alloc(size_t size) {
+  return (UA_WriteValue *)UA_Array_new(size, &UA_TYPES[UA_TYPES_WRITEVALUE]);
+}
+
+// 节点 ID 字符串由调用者释放, 值从 variant 移入
+void UA_WriteValue_set(UA_WriteValue *items, size_t index, UA_NodeId nodeId,
+                       UA_Variant *value) {
+  items[index].nodeId = nodeId;
+  items[index].attributeId = UA_ATTRIBUTEID_VALUE;
+  items[index].value.hasValue = true;
+  items[index].value.value = *value;
+  UA_Variant_init(value);
+}
+
+void UA_WriteValue_free(UA_WriteValue *items, size_t size) {
+  for (size_t i = 0; i < size; i++) {
+    UA_NodeId_init(&items[i].nodeId);
+  }
+  UA_Array_delete(items, size, &UA_TYPES[UA_TYPES_WRITEVALUE]);
+}
+
+UA_WriteResponse *UA_WriteResponse_alloc(size_t size) {
+  return (UA_WriteResponse *)UA_Array_new(size,
+                                          &UA_TYPES[UA_TYPES_WRITERESPONSE]);
+}
+
+void UA_WriteResponse_free(UA_WriteResponse *responses, size_t size) {
+  UA_Array_delete(responses, size, &UA_TYPES[UA_TYPES_WRITERESPONSE]);
+}
+
+UA_StatusCode UA_WriteResponse_result(UA_WriteResponse *responses,
+                                      size_t chunk, size_t index) {
+  if (index >= responses[chunk].resultsSize) {
+    return UA_STATUSCODE_BADUNEXPECTEDERROR;
+  }
+  return responses[chunk].results[index];
+}
+
 UA_Variant *UA_ReadResponse_variant(UA_ReadResponse *response, int index) {
   return &response->results[index].value;
 }
@@ -603,6 +1241,7 @@ UA_StatusCode UA_ServerAddVariable(UA_Server *server, UA_UInt16 parentNsIndex,
   /*变量节点的属性*/
   UA_VariableAttributes attr = UA_VariableAttributes_default;
   memcpy(&attr.value, variant, sizeof(UA_Variant));
+  ua_VariableAttributesShape(&attr, variant);
 
   // 节点在用户接口显示的名字(本地化)
   attr.description = UA_LOCALIZEDTEXT("en-US", displayName);
@@ -639,4 +1278,1440 @@ UA_StatusCode UA_ServerAddVariable(UA_Server *server, UA_UInt16 parentNsIndex,
       server, integerNodeId, parentNodeId, parentReferenceNodeId, integerName,
       UA_NODEID_NUMERIC(0, UA_NS0ID_BASEDATAVARIABLETYPE), attr, NULL,
       &outNodeID);
-}
\ No newline at end of file
+}
+static char *ua_String_dup(const UA_String *str) {
+  char *output = (char *)malloc(str->length + 1);
+  if (output == NULL) {
+    return NULL;
+  }
+  memset(output, '\0', str->length + 1);
+  if (str->length > 0) {
+    memcpy(output, str->data, str->length);
+  }
+  return output;
+}
+
+static UA_Boolean ua_String_equalChars(const UA_String *str,
+                                       const char *chars) {
+  UA_String value = UA_STRING((char *)chars);
+  return UA_String_equal(str, &value);
+}
+
+static void ua_NodeMeta_readProperty(UA_Client *client,
+                                     UA_ReferenceDescription *ref,
+                                     NodeMeta *meta) {
+  UA_Variant value;
+  UA_Variant_init(&value);
+  if (UA_Client_readValueAttribute(client, ref->nodeId.nodeId, &value) !=
+      UA_STATUSCODE_GOOD) {
+    return;
+  }
+
+  const UA_String *name = &ref->browseName.name;
+  if (ua_String_equalChars(name, "EURange") &&
+      UA_Variant_hasScalarType(&value, &UA_TYPES[UA_TYPES_RANGE])) {
+    UA_Range *range = (UA_Range *)value.data;
+    meta->hasRange = true;
+    meta->rangeLow = range->low;
+    meta->rangeHigh = range->high;
+  } else if (ua_String_equalChars(name, "EngineeringUnits") &&
+             UA_Variant_hasScalarType(&value,
+                                      &UA_TYPES[UA_TYPES_EUINFORMATION])) {
+    UA_EUInformation *units = (UA_EUInformation *)value.data;
+    meta->hasUnits = true;
+    meta->unitNamespace = ua_String_dup(&units->namespaceUri);
+    meta->unitID = units->unitId;
+    meta->unitName = ua_String_dup(&units->displayName.text);
+    meta->unitDescription = ua_String_dup(&units->description.text);
+  } else if (ua_String_equalChars(name, "TrueState") &&
+             UA_Variant_hasScalarType(&value,
+                                      &UA_TYPES[UA_TYPES_LOCALIZEDTEXT])) {
+    meta->trueState = ua_String_dup(&((UA_LocalizedText *)value.data)->text);
+  } else if (ua_String_equalChars(name, "FalseState") &&
+             UA_Variant_hasScalarType(&value,
+                                      &UA_TYPES[UA_TYPES_LOCALIZEDTEXT])) {
+    meta->falseState = ua_String_dup(&((UA_LocalizedText *)value.data)->text);
+  }
+
+  UA_Variant_clear(&value);
+}
+
+UA_StatusCode UA_ClientReadNodeMeta(UA_Client *client, UA_NodeId nodeId,
+                                    NodeMeta *meta) {
+
+  memset(meta, 0, sizeof(NodeMeta));
+
+  UA_LocalizedText text;
+  UA_LocalizedText_init(&text);
+  UA_StatusCode retval =
+      UA_Client_readDisplayNameAttribute(client, nodeId, &text);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  meta->displayName = ua_String_dup(&text.text);
+  UA_LocalizedText_clear(&text);
+
+  if (UA_Client_readDescriptionAttribute(client, nodeId, &text) ==
+      UA_STATUSCODE_GOOD) {
+    meta->description = ua_String_dup(&text.text);
+    UA_LocalizedText_clear(&text);
+  }
+
+  UA_Client_readAccessLevelAttribute(client, nodeId, &meta->accessLevel);
+
+  UA_BrowseRequest bReq;
+  UA_BrowseRequest_init(&bReq);
+  bReq.requestedMaxReferencesPerNode = 0;
+  bReq.nodesToBrowse = UA_BrowseDescription_new();
+  bReq.nodesToBrowseSize = 1;
+  UA_NodeId_copy(&nodeId, &bReq.nodesToBrowse[0].nodeId);
+  bReq.nodesToBrowse[0].browseDirection = UA_BROWSEDIRECTION_FORWARD;
+  bReq.nodesToBrowse[0].includeSubtypes = true;
+  bReq.nodesToBrowse[0].resultMask = UA_BROWSERESULTMASK_ALL;
+
+  UA_BrowseResponse bResp = UA_Client_Service_browse(client, bReq);
+  UA_BrowseRequest_clear(&bReq);
+
+  if (bResp.responseHeader.serviceResult != UA_STATUSCODE_GOOD) {
+    retval = bResp.responseHeader.serviceResult;
+    UA_BrowseResponse_clear(&bResp);
+    return retval;
+  }
+
+  UA_NodeId hasTypeDefinition =
+      UA_NODEID_NUMERIC(0, UA_NS0ID_HASTYPEDEFINITION);
+  UA_NodeId hasProperty = UA_NODEID_NUMERIC(0, UA_NS0ID_HASPROPERTY);
+
+  for (size_t i = 0; i < bResp.resultsSize; i++) {
+    for (size_t j = 0; j < bResp.results[i].referencesSize; j++) {
+      UA_ReferenceDescription *ref = &(bResp.results[i].references[j]);
+      if (UA_NodeId_equal(&ref->referenceTypeId, &hasTypeDefinition) &&
+          ref->nodeId.nodeId.namespaceIndex == 0 &&
+          ref->nodeId.nodeId.identifierType == UA_NODEIDTYPE_NUMERIC) {
+        meta->typeDefinition = ref->nodeId.nodeId.identifier.numeric;
+      } else if (UA_NodeId_equal(&ref->referenceTypeId, &hasProperty)) {
+        ua_NodeMeta_readProperty(client, ref, meta);
+      }
+    }
+  }
+
+  UA_BrowseResponse_clear(&bResp);
+  return UA_STATUSCODE_GOOD;
+}
+
+void UA_NodeMeta_clear(NodeMeta *meta) {
+  free(meta->displayName);
+  free(meta->description);
+  free(meta->unitNamespace);
+  free(meta->unitName);
+  free(meta->unitDescription);
+  free(meta->trueState);
+  free(meta->falseState);
+  memset(meta, 0, sizeof(NodeMeta));
+}
+
+static UA_StatusCode ua_ServerAddProperty(UA_Server *server,
+                                          UA_NodeId parentNodeId,
+                                          UA_UInt16 nsIndex, char *parentID,
+                                          char *name, void *value,
+                                          const UA_DataType *type) {
+  size_t length = strlen(parentID) + strlen(name) + 2;
+  char *nodeID = (char *)malloc(length);
+  if (nodeID == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  snprintf(nodeID, length, "%s.%s", parentID, name);
+
+  UA_VariableAttributes attr = UA_VariableAttributes_default;
+  UA_Variant_setScalar(&attr.value, value, type);
+  attr.displayName = UA_LOCALIZEDTEXT("", name);
+  attr.dataType = type->typeId;
+  attr.accessLevel = UA_ACCESSLEVELMASK_READ;
+
+  UA_StatusCode retval = UA_Server_addVariableNode(
+      server, UA_NODEID_STRING(nsIndex, nodeID), parentNodeId,
+      UA_NODEID_NUMERIC(0, UA_NS0ID_HASPROPERTY), UA_QUALIFIEDNAME(0, name),
+      UA_NODEID_NUMERIC(0, UA_NS0ID_PROPERTYTYPE), attr, NULL, NULL);
+
+  free(nodeID);
+  return retval;
+}
+
+UA_StatusCode UA_ServerAddVariableMeta(UA_Server *server,
+                                       UA_UInt16 parentNsIndex,
+                                       char *parentNodeID, UA_UInt16 aNsIndex,
+                                       char *aNodeID, char *browseName,
+                                       UA_Variant *variant, NodeMeta *meta) {
+  UA_VariableAttributes attr = UA_VariableAttributes_default;
+  memcpy(&attr.value, variant, sizeof(UA_Variant));
+  ua_VariableAttributesShape(&attr, variant);
+
+  char *displayName = browseName;
+  if (meta->displayName != NULL && strlen(meta->displayName) > 0) {
+    displayName = meta->displayName;
+  }
+  char *description = displayName;
+  if (meta->description != NULL && strlen(meta->description) > 0) {
+    description = meta->description;
+  }
+
+  attr.displayName = UA_LOCALIZEDTEXT("en-US", displayName);
+  attr.description = UA_LOCALIZEDTEXT("en-US", description);
+  attr.dataType = variant->type->typeId;
+  attr.accessLevel = UA_ACCESSLEVELMASK_READ | UA_ACCESSLEVELMASK_WRITE;
+  if (meta->accessLevel != 0) {
+    attr.accessLevel = meta->accessLevel;
+  }
+
+  UA_NodeId typeDefinition =
+      UA_NODEID_NUMERIC(0, UA_NS0ID_BASEDATAVARIABLETYPE);
+  if (meta->typeDefinition == UA_NS0ID_ANALOGITEMTYPE ||
+      meta->typeDefinition == UA_NS0ID_TWOSTATEDISCRETETYPE) {
+    typeDefinition = UA_NODEID_NUMERIC(0, meta->typeDefinition);
+  }
+
+  UA_NodeId nodeId = UA_NODEID_STRING(aNsIndex, aNodeID);
+  UA_StatusCode retval = UA_Server_addNode_begin(
+      server, UA_NODECLASS_VARIABLE, nodeId,
+      UA_NODEID_STRING(parentNsIndex, parentNodeID),
+      UA_NODEID_NUMERIC(0, UA_NS0ID_ORGANIZES),
+      UA_QUALIFIEDNAME(aNsIndex, browseName), typeDefinition, &attr,
+      &UA_TYPES[UA_TYPES_VARIABLEATTRIBUTES], NULL, NULL);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+
+  // 优先添加属性节点, 类型定义中的同名必选属性不会被重复创建
+  if (meta->typeDefinition == UA_NS0ID_ANALOGITEMTYPE) {
+    UA_Range range;
+    range.low = meta->rangeLow;
+    range.high = meta->rangeHigh;
+    retval = ua_ServerAddProperty(server, nodeId, aNsIndex, aNodeID, "EURange",
+                                  &range, &UA_TYPES[UA_TYPES_RANGE]);
+    if (retval == UA_STATUSCODE_GOOD && meta->hasUnits) {
+      UA_EUInformation units;
+      UA_EUInformation_init(&units);
+      units.namespaceUri = UA_STRING(meta->unitNamespace);
+      units.unitId = meta->unitID;
+      units.displayName = UA_LOCALIZEDTEXT("en-US", meta->unitName);
+      units.description = UA_LOCALIZEDTEXT("en-US", meta->unitDescription);
+      retval = ua_ServerAddProperty(server, nodeId, aNsIndex, aNodeID,
+                                    "EngineeringUnits", &units,
+                                    &UA_TYPES[UA_TYPES_EUINFORMATION]);
+    }
+  } else if (meta->typeDefinition == UA_NS0ID_TWOSTATEDISCRETETYPE) {
+    UA_LocalizedText trueState = UA_LOCALIZEDTEXT("en-US", meta->trueState);
+    UA_LocalizedText falseState = UA_LOCALIZEDTEXT("en-US", meta->falseState);
+    retval = ua_ServerAddProperty(server, nodeId, aNsIndex, aNodeID,
+                                  "TrueState", &trueState,
+                                  &UA_TYPES[UA_TYPES_LOCALIZEDTEXT]);
+    if (retval == UA_STATUSCODE_GOOD) {
+      retval = ua_ServerAddProperty(server, nodeId, aNsIndex, aNodeID,
+                                    "FalseState", &falseState,
+                                    &UA_TYPES[UA_TYPES_LOCALIZEDTEXT]);
+    }
+  }
+
+  if (retval != UA_STATUSCODE_GOOD) {
+    UA_Server_deleteNode(server, nodeId, true);
+    return retval;
+  }
+
+  return UA_Server_addNode_finish(server, nodeId);
+}
+
+UA_StatusCode UA_ServerAddFolder(UA_Server *server, UA_UInt16 parentNsIndex,
+                                 char *parentNodeID, UA_UInt16 aNsIndex,
+                                 char *aNodeID, char *browseName) {
+  UA_ObjectAttributes attr = UA_ObjectAttributes_default;
+  attr.displayName = UA_LOCALIZEDTEXT("en-US", browseName);
+
+  return UA_Server_addObjectNode(
+      server, UA_NODEID_STRING(aNsIndex, aNodeID),
+      UA_NODEID_STRING(parentNsIndex, parentNodeID),
+      UA_NODEID_NUMERIC(0, UA_NS0ID_ORGANIZES),
+      UA_QUALIFIEDNAME(aNsIndex, browseName),
+      UA_NODEID_NUMERIC(0, UA_NS0ID_FOLDERTYPE), attr, NULL, NULL);
+}
+
+// 递归删除节点及其子节点，只删除同一命名空间的子节点
+UA_StatusCode UA_ServerDeleteTree(UA_Server *server, UA_NodeId nodeId) {
+  UA_BrowseDescription bd;
+  UA_BrowseDescription_init(&bd);
+  bd.nodeId = nodeId;
+  bd.browseDirection = UA_BROWSEDIRECTION_FORWARD;
+  bd.referenceTypeId = UA_NODEID_NUMERIC(0, UA_NS0ID_HIERARCHICALREFERENCES);
+  bd.includeSubtypes = true;
+  bd.resultMask = UA_BROWSERESULTMASK_NONE;
+
+  UA_BrowseResult br = UA_Server_browse(server, 0, &bd);
+  for (size_t i = 0; i < br.referencesSize; i++) {
+    UA_ExpandedNodeId *target = &br.references[i].nodeId;
+    if (target->serverIndex != 0 ||
+        target->nodeId.namespaceIndex != nodeId.namespaceIndex) {
+      continue;
+    }
+    UA_ServerDeleteTree(server, target->nodeId);
+  }
+  UA_BrowseResult_clear(&br);
+
+  return UA_Server_deleteNode(server, nodeId, true);
+}
+
+UA_Boolean UA_EncryptionSupported(void) {
+#ifdef UA_ENABLE_ENCRYPTION
+  return true;
+#else
+  return false;
+#endif
+}
+
+UA_Boolean UA_DiscoveryMulticastSupported(void) {
+#ifdef UA_ENABLE_DISCOVERY_MULTICAST
+  return true;
+#else
+  return false;
+#endif
+}
+
+UA_StatusCode UA_ServerSecurity_alloc(ServerSecurity *security,
+                                      size_t trustListSize,
+                                      size_t loginsSize) {
+  memset(security, 0, sizeof(ServerSecurity));
+  if (trustListSize > 0) {
+    security->trustList = (UA_ByteString *)UA_Array_new(
+        trustListSize, &UA_TYPES[UA_TYPES_BYTESTRING]);
+    if (security->trustList == NULL) {
+      return UA_STATUSCODE_BADOUTOFMEMORY;
+    }
+    security->trustListSize = trustListSize;
+  }
+  if (loginsSize > 0) {
+    security->logins = (UA_UsernamePasswordLogin *)UA_calloc(
+        loginsSize, sizeof(UA_UsernamePasswordLogin));
+    if (security->logins == NULL) {
+      return UA_STATUSCODE_BADOUTOFMEMORY;
+    }
+    security->loginsSize = loginsSize;
+  }
+  return UA_STATUSCODE_GOOD;
+}
+
+void UA_ServerSecurity_setBytes(UA_ByteString *bytes, void *data,
+                                size_t length) {
+  UA_ByteString_clear(bytes);
+  if (UA_ByteString_allocBuffer(bytes, length) == UA_STATUSCODE_GOOD) {
+    memcpy(bytes->data, data, length);
+  }
+}
+
+UA_ByteString *UA_ServerSecurity_trust(ServerSecurity *security,
+                                       size_t index) {
+  if (index >= security->trustListSize) {
+    return NULL;
+  }
+  return &security->trustList[index];
+}
+
+void UA_ServerSecurity_setLogin(ServerSecurity *security, size_t index,
+                                char *username, char *password) {
+  if (index >= security->loginsSize) {
+    return;
+  }
+  security->logins[index].username = UA_STRING_ALLOC(username);
+  security->logins[index].password = UA_STRING_ALLOC(password);
+}
+
+void UA_ServerSecurity_clear(ServerSecurity *security) {
+  UA_ByteString_clear(&security->certificate);
+  UA_ByteString_clear(&security->privateKey);
+  UA_Array_delete(security->trustList, security->trustListSize,
+                  &UA_TYPES[UA_TYPES_BYTESTRING]);
+  for (size_t i = 0; i < security->loginsSize; i++) {
+    UA_String_clear(&security->logins[i].username);
+    UA_String_clear(&security->logins[i].password);
+  }
+  UA_free(security->logins);
+  memset(security, 0, sizeof(ServerSecurity));
+}
+
+static void ua_ServerConfig_removeNoneEndpoints(UA_ServerConfig *config) {
+  size_t count = 0;
+  for (size_t i = 0; i < config->endpointsSize; i++) {
+    if (config->endpoints[i].securityMode == UA_MESSAGESECURITYMODE_NONE) {
+      UA_EndpointDescription_clear(&config->endpoints[i]);
+      continue;
+    }
+    if (i != count) {
+      config->endpoints[count] = config->endpoints[i];
+    }
+    count++;
+  }
+  config->endpointsSize = count;
+  if (count == 0) {
+    UA_free(config->endpoints);
+    config->endpoints = NULL;
+  }
+}
+
+UA_StatusCode UA_ServerConfig_setSecurity(UA_ServerConfig *config,
+                                          UA_UInt16 port,
+                                          const ServerSecurity *security) {
+  const UA_ByteString *certificate = NULL;
+  if (security->certificate.length > 0) {
+    certificate = &security->certificate;
+  }
+
+  UA_StatusCode retval = UA_ServerConfig_setMinimal(config, port, certificate);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+
+  if (security->policies & ~SECURITY_POLICY_NONE) {
+#ifdef UA_ENABLE_ENCRYPTION
+    if (certificate == NULL || security->privateKey.length == 0) {
+      return UA_STATUSCODE_BADCERTIFICATEINVALID;
+    }
+
+    config->certificateVerification.clear(&config->certificateVerification);
+    retval = UA_CertificateVerification_Trustlist(
+        &config->certificateVerification, security->trustList,
+        security->trustListSize, NULL, 0, NULL, 0);
+    if (retval != UA_STATUSCODE_GOOD) {
+      return retval;
+    }
+
+    const UA_ByteString *privateKey = &security->privateKey;
+    if (security->policies & SECURITY_POLICY_BASIC128RSA15) {
+      retval |= UA_ServerConfig_addSecurityPolicyBasic128Rsa15(
+          config, certificate, privateKey);
+    }
+    if (security->policies & SECURITY_POLICY_BASIC256) {
+      retval |= UA_ServerConfig_addSecurityPolicyBasic256(config, certificate,
+                                                          privateKey);
+    }
+    if (security->policies & SECURITY_POLICY_BASIC256SHA256) {
+      retval |= UA_ServerConfig_addSecurityPolicyBasic256Sha256(
+          config, certificate, privateKey);
+    }
+    if (security->policies & SECURITY_POLICY_AES128SHA256RSAOAEP) {
+      retval |= UA_ServerConfig_addSecurityPolicyAes128Sha256RsaOaep(
+          config, certificate, privateKey);
+    }
+    if (retval != UA_STATUSCODE_GOOD) {
+      return retval;
+    }
+
+    for (size_t i = 0; i < config->securityPoliciesSize; i++) {
+      UA_SecurityPolicy *policy = &config->securityPolicies[i];
+      if (UA_String_equal(&policy->policyUri, &UA_SECURITY_POLICY_NONE_URI)) {
+        continue;
+      }
+      if (security->sign) {
+        retval |= UA_ServerConfig_addEndpoint(config, policy->policyUri,
+                                              UA_MESSAGESECURITYMODE_SIGN);
+      }
+      if (security->signAndEncrypt) {
+        retval |= UA_ServerConfig_addEndpoint(
+            config, policy->policyUri, UA_MESSAGESECURITYMODE_SIGNANDENCRYPT);
+      }
+    }
+    if (retval != UA_STATUSCODE_GOOD) {
+      return retval;
+    }
+#else
+    return UA_STATUSCODE_BADNOTSUPPORTED;
+#endif
+  }
+
+  if (!(security->policies & SECURITY_POLICY_NONE)) {
+    ua_ServerConfig_removeNoneEndpoints(config);
+  }
+  if (config->endpointsSize == 0) {
+    return UA_STATUSCODE_BADCONFIGURATIONERROR;
+  }
+
+  // username and password are encrypted with the strongest policy
+  const UA_String *userTokenPolicyUri =
+      &config->securityPolicies[config->securityPoliciesSize - 1].policyUri;
+  if (security->loginsSize > 0 &&
+      UA_String_equal(userTokenPolicyUri, &UA_SECURITY_POLICY_NONE_URI)) {
+    return UA_STATUSCODE_BADSECURITYPOLICYREJECTED;
+  }
+
+  // no x509 user tokens, the default access control would move the
+  // certificate verification of the secure channel into its context
+  return UA_AccessControl_default(config, security->allowAnonymous, NULL,
+                                  userTokenPolicyUri, security->loginsSize,
+                                  security->logins);
+}
+
+UA_EndpointDescription *UA_ServerEndpoint(UA_Server *server, size_t index) {
+  UA_ServerConfig *config = UA_Server_getConfig(server);
+  if (index >= config->endpointsSize) {
+    return NULL;
+  }
+  return &config->endpoints[index];
+}
+
+UA_Client *UA_DiscoveryClient_new(void *context, void *log) {
+  UA_Client *client = UA_Client_new();
+  if (client == NULL) {
+    return NULL;
+  }
+  UA_ClientConfig *config = UA_Client_getConfig(client);
+  UA_Logger_init(&config->logger, context, log, NULL);
+  UA_ClientConfig_setDefault(config);
+  return client;
+}
+
+void UA_ApplicationDescriptions_free(UA_ApplicationDescription *servers,
+                                     size_t size) {
+  UA_Array_delete(servers, size, &UA_TYPES[UA_TYPES_APPLICATIONDESCRIPTION]);
+}
+
+void UA_EndpointDescriptions_free(UA_EndpointDescription *endpoints,
+                                  size_t size) {
+  UA_Array_delete(endpoints, size, &UA_TYPES[UA_TYPES_ENDPOINTDESCRIPTION]);
+}
+
+static UA_Byte ua_AccessControl_check(UA_Server *server, void *sessionContext,
+                                      const UA_NodeId *nodeId,
+                                      UA_UInt32 operation) {
+  // standard namespace and non string nodes are not managed by the proxy
+  if (nodeId == NULL || nodeId->namespaceIndex == 0 ||
+      nodeId->identifierType != UA_NODEIDTYPE_STRING) {
+    return 0xFF;
+  }
+
+  // the default access control keeps the user name as session context
+  UA_String anonymous = UA_STRING_NULL;
+  const UA_String *name = &anonymous;
+  if (sessionContext != NULL) {
+    name = (const UA_ByteString *)sessionContext;
+  }
+
+  char *username = ua_String_chars(name);
+  char *nodeID = ua_String_chars(&nodeId->identifier.string);
+
+  UA_Byte retval = 0;
+  if (username != NULL && nodeID != NULL) {
+    retval = UA_AccessControl_golang(server, username, nodeId->namespaceIndex,
+                                     nodeID, operation);
+  }
+
+  free(username);
+  free(nodeID);
+  return retval;
+}
+
+static UA_Byte ua_getUserAccessLevel(UA_Server *server, UA_AccessControl *ac,
+                                     const UA_NodeId *sessionId,
+                                     void *sessionContext,
+                                     const UA_NodeId *nodeId,
+                                     void *nodeContext) {
+  return ua_AccessControl_check(server, sessionContext, nodeId,
+                                ACCESS_OPERATION_LEVEL);
+}
+
+static UA_Boolean ua_allowBrowseNode(UA_Server *server, UA_AccessControl *ac,
+                                     const UA_NodeId *sessionId,
+                                     void *sessionContext,
+                                     const UA_NodeId *nodeId,
+                                     void *nodeContext) {
+  return ua_AccessControl_check(server, sessionContext, nodeId,
+                                ACCESS_OPERATION_BROWSE) != 0;
+}
+
+void UA_ServerAccessControl_init(UA_Server *server) {
+  UA_ServerConfig *config = UA_Server_getConfig(server);
+  config->accessControl.getUserAccessLevel = ua_getUserAccessLevel;
+  config->accessControl.allowBrowseNode = ua_allowBrowseNode;
+}
+
+static UA_StatusCode ua_History_readNode(UA_Server *server,
+                                         const UA_HistoryReadValueId *item,
+                                         UA_DateTime startTime,
+                                         UA_DateTime endTime,
+                                         UA_UInt32 maxValues,
+                                         UA_UInt32 aggregate,
+                                         UA_Double interval, UA_Boolean release,
+                                         UA_HistoryReadResult *output,
+                                         UA_HistoryData *data) {
+  if (item->nodeId.identifierType != UA_NODEIDTYPE_STRING) {
+    return UA_STATUSCODE_BADNODEIDUNKNOWN;
+  }
+
+  char *nodeID = ua_String_chars(&item->nodeId.identifier.string);
+  if (nodeID == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+
+  HistoryResult result = {data, &output->continuationPoint};
+  UA_StatusCode retval = UA_HistoryRead_golang(
+      server, item->nodeId.namespaceIndex, nodeID, startTime, endTime,
+      maxValues, aggregate, interval, item->continuationPoint.data,
+      item->continuationPoint.length, release, &result);
+
+  free(nodeID);
+  return retval;
+}
+
+static void
+ua_History_readRaw(UA_Server *server, void *hdbContext,
+                   const UA_NodeId *sessionId, void *sessionContext,
+                   const UA_RequestHeader *requestHeader,
+                   const UA_ReadRawModifiedDetails *historyReadDetails,
+                   UA_TimestampsToReturn timestampsToReturn,
+                   UA_Boolean releaseContinuationPoints, size_t nodesToReadSize,
+                   const UA_HistoryReadValueId *nodesToRead,
+                   UA_HistoryReadResponse *response,
+                   UA_HistoryData *const *const historyData) {
+  for (size_t i = 0; i < nodesToReadSize; i++) {
+    response->results[i].statusCode = ua_History_readNode(
+        server, &nodesToRead[i], historyReadDetails->startTime,
+        historyReadDetails->endTime, historyReadDetails->numValuesPerNode, 0,
+        0, releaseContinuationPoints, &response->results[i], historyData[i]);
+  }
+}
+
+static void
+ua_History_readProcessed(UA_Server *server, void *hdbContext,
+                         const UA_NodeId *sessionId, void *sessionContext,
+                         const UA_RequestHeader *requestHeader,
+                         const UA_ReadProcessedDetails *historyReadDetails,
+                         UA_TimestampsToReturn timestampsToReturn,
+                         UA_Boolean releaseContinuationPoints,
+                         size_t nodesToReadSize,
+                         const UA_HistoryReadValueId *nodesToRead,
+                         UA_HistoryReadResponse *response,
+                         UA_HistoryData *const *const historyData) {
+  for (size_t i = 0; i < nodesToReadSize; i++) {
+    // the aggregate list has one entry for each node to read
+    if (i >= historyReadDetails->aggregateTypeSize ||
+        historyReadDetails->aggregateType[i].identifierType !=
+            UA_NODEIDTYPE_NUMERIC) {
+      response->results[i].statusCode = UA_STATUSCODE_BADAGGREGATENOTSUPPORTED;
+      continue;
+    }
+    response->results[i].statusCode = ua_History_readNode(
+        server, &nodesToRead[i], historyReadDetails->startTime,
+        historyReadDetails->endTime, 0,
+        historyReadDetails->aggregateType[i].identifier.numeric,
+        historyReadDetails->processingInterval, releaseContinuationPoints,
+        &response->results[i], historyData[i]);
+  }
+}
+
+UA_StatusCode UA_ServerHistory_init(UA_Server *server) {
+  UA_ServerConfig *config = UA_Server_getConfig(server);
+  if (config->historyDatabase.clear != NULL) {
+    config->historyDatabase.clear(&config->historyDatabase);
+  }
+  memset(&config->historyDatabase, 0, sizeof(UA_HistoryDatabase));
+
+  // the service requires a context, the callbacks get the server directly
+  config->historyDatabase.context = server;
+  config->historyDatabase.readRaw = ua_History_readRaw;
+  config->historyDatabase.readProcessed = ua_History_readProcessed;
+  config->accessHistoryDataCapability = true;
+  return UA_STATUSCODE_GOOD;
+}
+
+UA_StatusCode UA_ServerHistory_historizing(UA_Server *server,
+                                           UA_UInt16 nsIndex, char *nodeID) {
+  UA_NodeId nodeId = UA_NODEID_STRING(nsIndex, nodeID);
+
+  UA_Byte accessLevel = 0;
+  UA_StatusCode retval = UA_Server_readAccessLevel(server, nodeId, &accessLevel);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+
+  accessLevel |= UA_ACCESSLEVELMASK_HISTORYREAD;
+  retval = UA_Server_writeAccessLevel(server, nodeId, accessLevel);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+
+  return UA_Server_writeHistorizing(server, nodeId, true);
+}
+
+UA_StatusCode UA_HistoryResult_append(HistoryResult *result, UA_Variant *value,
+                                      UA_DateTime sourceTimestamp,
+                                      UA_StatusCode status) {
+  UA_HistoryData *data = (UA_HistoryData *)result->data;
+
+  UA_DataValue dataValue;
+  UA_DataValue_init(&dataValue);
+  if (value != NULL) {
+    UA_StatusCode retval = UA_Variant_copy(value, &dataValue.value);
+    if (retval != UA_STATUSCODE_GOOD) {
+      return retval;
+    }
+    dataValue.hasValue = true;
+  }
+  dataValue.sourceTimestamp = sourceTimestamp;
+  dataValue.hasSourceTimestamp = true;
+  dataValue.status = status;
+  dataValue.hasStatus = (status != UA_STATUSCODE_GOOD);
+
+  UA_StatusCode retval =
+      UA_Array_append((void **)&data->dataValues, &data->dataValuesSize,
+                      &dataValue, &UA_TYPES[UA_TYPES_DATAVALUE]);
+  if (retval != UA_STATUSCODE_GOOD) {
+    UA_DataValue_clear(&dataValue);
+  }
+  return retval;
+}
+
+UA_StatusCode UA_HistoryResult_continuation(HistoryResult *result, void *data,
+                                            size_t length) {
+  UA_ByteString *continuationPoint = (UA_ByteString *)result->continuationPoint;
+  UA_ByteString_clear(continuationPoint);
+  if (length == 0) {
+    return UA_STATUSCODE_GOOD;
+  }
+
+  UA_StatusCode retval = UA_ByteString_allocBuffer(continuationPoint, length);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  memcpy(continuationPoint->data, data, length);
+  return UA_STATUSCODE_GOOD;
+}
+
+static UA_Boolean ua_Client_historyData(UA_UInt64 handle,
+                                        const UA_ExtensionObject *data) {
+  if (data->encoding < UA_EXTENSIONOBJECT_DECODED ||
+      data->content.decoded.type != &UA_TYPES[UA_TYPES_HISTORYDATA]) {
+    return false;
+  }
+
+  UA_HistoryData *historyData = (UA_HistoryData *)data->content.decoded.data;
+  for (size_t i = 0; i < historyData->dataValuesSize; i++) {
+    UA_DataValue *dataValue = &historyData->dataValues[i];
+    UA_DateTime timestamp = dataValue->hasSourceTimestamp
+                                ? dataValue->sourceTimestamp
+                                : dataValue->serverTimestamp;
+    UA_StatusCode status =
+        dataValue->hasStatus ? dataValue->status : UA_STATUSCODE_GOOD;
+    UA_Variant *value = dataValue->hasValue ? &dataValue->value : NULL;
+    if (!UA_ClientHistoryValue_golang(handle, value, timestamp, status)) {
+      return false;
+    }
+  }
+  return true;
+}
+
+static UA_StatusCode ua_Client_historyRead(UA_Client *client,
+                                           UA_HistoryReadRequest *request,
+                                           UA_HistoryReadResponse *response) {
+  __UA_Client_Service(client, request,
+                      &UA_TYPES[UA_TYPES_HISTORYREADREQUEST], response,
+                      &UA_TYPES[UA_TYPES_HISTORYREADRESPONSE]);
+
+  UA_StatusCode retval = response->responseHeader.serviceResult;
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  if (response->resultsSize != request->nodesToReadSize) {
+    return UA_STATUSCODE_BADUNEXPECTEDERROR;
+  }
+  return response->results[0].statusCode;
+}
+
+UA_StatusCode UA_ClientHistoryReadRaw(UA_Client *client, UA_NodeId nodeId,
+                                      UA_DateTime startTime,
+                                      UA_DateTime endTime, UA_UInt32 maxValues,
+                                      UA_UInt64 handle) {
+  UA_ReadRawModifiedDetails details;
+  UA_ReadRawModifiedDetails_init(&details);
+  details.startTime = startTime;
+  details.endTime = endTime;
+  details.numValuesPerNode = maxValues;
+
+  UA_HistoryReadValueId item;
+  UA_HistoryReadValueId_init(&item);
+  item.nodeId = nodeId;
+
+  UA_HistoryReadRequest request;
+  UA_HistoryReadRequest_init(&request);
+  UA_ExtensionObject_setValue(&request.historyReadDetails, &details,
+                              &UA_TYPES[UA_TYPES_READRAWMODIFIEDDETAILS]);
+  request.timestampsToReturn = UA_TIMESTAMPSTORETURN_BOTH;
+  request.nodesToRead = &item;
+  request.nodesToReadSize = 1;
+
+  // every page continues from the continuation point of the previous one
+  UA_ByteString continuationPoint = UA_BYTESTRING_NULL;
+  UA_StatusCode retval = UA_STATUSCODE_GOOD;
+  UA_Boolean more = true;
+  while (more) {
+    item.continuationPoint = continuationPoint;
+
+    UA_HistoryReadResponse response;
+    UA_HistoryReadResponse_init(&response);
+    retval = ua_Client_historyRead(client, &request, &response);
+    UA_ByteString_clear(&continuationPoint);
+    if (UA_StatusCode_isBad(retval)) {
+      UA_HistoryReadResponse_clear(&response);
+      break;
+    }
+    retval = UA_STATUSCODE_GOOD;
+
+    UA_HistoryReadResult *result = &response.results[0];
+    more = ua_Client_historyData(handle, &result->historyData) &&
+           result->continuationPoint.length > 0;
+    continuationPoint = result->continuationPoint;
+    UA_ByteString_init(&result->continuationPoint);
+    UA_HistoryReadResponse_clear(&response);
+  }
+
+  // the server keeps the continuation point until it is released
+  if (continuationPoint.length > 0) {
+    item.continuationPoint = continuationPoint;
+    request.releaseContinuationPoints = true;
+
+    UA_HistoryReadResponse response;
+    UA_HistoryReadResponse_init(&response);
+    ua_Client_historyRead(client, &request, &response);
+    UA_HistoryReadResponse_clear(&response);
+    UA_ByteString_clear(&continuationPoint);
+  }
+  return retval;
+}
+
+static void ua_MethodArgument_read(const UA_Variant *value,
+                                   MethodArgument **output, size_t *size) {
+  if (!UA_Variant_hasArrayType(value, &UA_TYPES[UA_TYPES_ARGUMENT]) ||
+      value->arrayLength == 0) {
+    return;
+  }
+
+  *output = (MethodArgument *)calloc(value->arrayLength, sizeof(MethodArgument));
+  if (*output == NULL) {
+    return;
+  }
+  *size = value->arrayLength;
+
+  UA_Argument *arguments = (UA_Argument *)value->data;
+  for (size_t i = 0; i < value->arrayLength; i++) {
+    (*output)[i].name = ua_String_dup(&arguments[i].name);
+    (*output)[i].description = ua_String_dup(&arguments[i].description.text);
+    (*output)[i].valueRank = arguments[i].valueRank;
+    if (arguments[i].dataType.namespaceIndex == 0 &&
+        arguments[i].dataType.identifierType == UA_NODEIDTYPE_NUMERIC) {
+      (*output)[i].dataType = arguments[i].dataType.identifier.numeric;
+    } else {
+      (*output)[i].dataType = UA_NS0ID_BASEDATATYPE;
+    }
+  }
+}
+
+UA_StatusCode UA_ClientReadMethodArguments(UA_Client *client,
+                                           UA_NodeId nodeId,
+                                           MethodArguments *args) {
+
+  memset(args, 0, sizeof(MethodArguments));
+
+  UA_NodeClass nodeClass = UA_NODECLASS_UNSPECIFIED;
+  UA_StatusCode retval =
+      UA_Client_readNodeClassAttribute(client, nodeId, &nodeClass);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+  if (nodeClass != UA_NODECLASS_METHOD) {
+    return UA_STATUSCODE_BADNODECLASSINVALID;
+  }
+
+  UA_BrowseRequest bReq;
+  UA_BrowseRequest_init(&bReq);
+  bReq.requestedMaxReferencesPerNode = 0;
+  bReq.nodesToBrowse = UA_BrowseDescription_new();
+  bReq.nodesToBrowseSize = 1;
+  UA_NodeId_copy(&nodeId, &bReq.nodesToBrowse[0].nodeId);
+  bReq.nodesToBrowse[0].referenceTypeId =
+      UA_NODEID_NUMERIC(0, UA_NS0ID_HASPROPERTY);
+  bReq.nodesToBrowse[0].browseDirection = UA_BROWSEDIRECTION_FORWARD;
+  bReq.nodesToBrowse[0].includeSubtypes = true;
+  bReq.nodesToBrowse[0].resultMask = UA_BROWSERESULTMASK_ALL;
+
+  UA_BrowseResponse bResp = UA_Client_Service_browse(client, bReq);
+  UA_BrowseRequest_clear(&bReq);
+
+  if (bResp.responseHeader.serviceResult != UA_STATUSCODE_GOOD) {
+    retval = bResp.responseHeader.serviceResult;
+    UA_BrowseResponse_clear(&bResp);
+    return retval;
+  }
+
+  for (size_t i = 0; i < bResp.resultsSize; i++) {
+    for (size_t j = 0; j < bResp.results[i].referencesSize; j++) {
+      UA_ReferenceDescription *ref = &(bResp.results[i].references[j]);
+      const UA_String *name = &ref->browseName.name;
+      if (!ua_String_equalChars(name, "InputArguments") &&
+          !ua_String_equalChars(name, "OutputArguments")) {
+        continue;
+      }
+
+      UA_Variant value;
+      UA_Variant_init(&value);
+      if (UA_Client_readValueAttribute(client, ref->nodeId.nodeId, &value) !=
+          UA_STATUSCODE_GOOD) {
+        continue;
+      }
+      if (ua_String_equalChars(name, "InputArguments")) {
+        ua_MethodArgument_read(&value, &args->inputs, &args->inputSize);
+      } else {
+        ua_MethodArgument_read(&value, &args->outputs, &args->outputSize);
+      }
+      UA_Variant_clear(&value);
+    }
+  }
+
+  UA_BrowseResponse_clear(&bResp);
+  return UA_STATUSCODE_GOOD;
+}
+
+UA_StatusCode UA_MethodArguments_alloc(MethodArguments *args,
+                                       size_t inputSize, size_t outputSize) {
+  memset(args, 0, sizeof(MethodArguments));
+  if (inputSize > 0) {
+    args->inputs = (MethodArgument *)calloc(inputSize, sizeof(MethodArgument));
+    if (args->inputs == NULL) {
+      return UA_STATUSCODE_BADOUTOFMEMORY;
+    }
+    args->inputSize = inputSize;
+  }
+  if (outputSize > 0) {
+    args->outputs =
+        (MethodArgument *)calloc(outputSize, sizeof(MethodArgument));
+    if (args->outputs == NULL) {
+      return UA_STATUSCODE_BADOUTOFMEMORY;
+    }
+    args->outputSize = outputSize;
+  }
+  return UA_STATUSCODE_GOOD;
+}
+
+void UA_MethodArguments_clear(MethodArguments *args) {
+  for (size_t i = 0; i < args->inputSize; i++) {
+    free(args->inputs[i].name);
+    free(args->inputs[i].description);
+  }
+  for (size_t i = 0; i < args->outputSize; i++) {
+    free(args->outputs[i].name);
+    free(args->outputs[i].description);
+  }
+  free(args->inputs);
+  free(args->outputs);
+  memset(args, 0, sizeof(MethodArguments));
+}
+
+typedef struct {
+  UA_Boolean done;
+  UA_Boolean abandoned;
+  UA_StatusCode status;
+  size_t outputSize;
+  UA_Variant *output;
+} ua_CallContext;
+
+static void ua_Client_callDone(UA_Client *client, void *userdata,
+                               UA_UInt32 requestId, UA_CallResponse *response) {
+  ua_CallContext *context = (ua_CallContext *)userdata;
+  if (context->abandoned) {
+    UA_free(context);
+    return;
+  }
+  context->done = true;
+
+  context->status = response->responseHeader.serviceResult;
+  if (context->status == UA_STATUSCODE_GOOD) {
+    if (response->resultsSize == 1) {
+      context->status = response->results[0].statusCode;
+    } else {
+      context->status = UA_STATUSCODE_BADUNEXPECTEDERROR;
+    }
+  }
+  if (context->status != UA_STATUSCODE_GOOD) {
+    return;
+  }
+
+  // move the output arguments, the response is cleared after the callback
+  context->output = response->results[0].outputArguments;
+  context->outputSize = response->results[0].outputArgumentsSize;
+  response->results[0].outputArguments = NULL;
+  response->results[0].outputArgumentsSize = 0;
+}
+
+UA_StatusCode UA_ClientCallMethod(UA_Client *client, UA_NodeId objectId,
+                                  UA_NodeId methodId, size_t inputSize,
+                                  UA_Variant *input, size_t *outputSize,
+                                  UA_Variant **output, UA_UInt32 timeout) {
+#ifdef UA_ENABLE_METHODCALLS
+  // the call runs in the server loop, so it is sent asynchronously and the
+  // wait ends after the timeout without closing the secure channel as the
+  // timeout of the synchronous service does
+  ua_CallContext *context =
+      (ua_CallContext *)UA_calloc(1, sizeof(ua_CallContext));
+  if (context == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+
+  UA_StatusCode retval =
+      UA_Client_call_async(client, objectId, methodId, inputSize, input,
+                           ua_Client_callDone, context, NULL);
+  if (retval != UA_STATUSCODE_GOOD) {
+    UA_free(context);
+    return retval;
+  }
+
+  UA_DateTime deadline =
+      UA_DateTime_nowMonotonic() + (UA_DateTime)timeout * UA_DATETIME_MSEC;
+  while (!context->done) {
+    UA_DateTime now = UA_DateTime_nowMonotonic();
+    if (now >= deadline) {
+      retval = UA_STATUSCODE_BADTIMEOUT;
+      break;
+    }
+    retval = UA_Client_run_iterate(
+        client, (UA_UInt32)((deadline - now) / UA_DATETIME_MSEC) + 1);
+    if (retval != UA_STATUSCODE_GOOD) {
+      break;
+    }
+  }
+
+  if (!context->done) {
+    // the late response or the disconnect frees the context
+    context->abandoned = true;
+    return retval;
+  }
+
+  retval = context->status;
+  *outputSize = context->outputSize;
+  *output = context->output;
+  UA_free(context);
+  return retval;
+#else
+  return UA_STATUSCODE_BADNOTSUPPORTED;
+#endif
+}
+
+UA_Variant *UA_VariantArray_alloc(size_t size) {
+  return (UA_Variant *)UA_Array_new(size, &UA_TYPES[UA_TYPES_VARIANT]);
+}
+
+void UA_VariantArray_delete(UA_Variant *array, size_t size) {
+  UA_Array_delete(array, size, &UA_TYPES[UA_TYPES_VARIANT]);
+}
+
+#ifdef UA_ENABLE_METHODCALLS
+static UA_StatusCode
+ua_Method_callback(UA_Server *server, const UA_NodeId *sessionId,
+                   void *sessionContext, const UA_NodeId *methodId,
+                   void *methodContext, const UA_NodeId *objectId,
+                   void *objectContext, size_t inputSize,
+                   const UA_Variant *input, size_t outputSize,
+                   UA_Variant *output) {
+  if (methodId->identifierType != UA_NODEIDTYPE_STRING) {
+    return UA_STATUSCODE_BADMETHODINVALID;
+  }
+
+  // the default access control keeps the user name as session context
+  UA_String anonymous = UA_STRING_NULL;
+  const UA_String *name =
+      sessionContext != NULL ? (const UA_String *)sessionContext : &anonymous;
+
+  char *username = ua_String_chars(name);
+  char *nodeID = ua_String_chars(&methodId->identifier.string);
+
+  UA_StatusCode retval = UA_STATUSCODE_BADOUTOFMEMORY;
+  if (username != NULL && nodeID != NULL) {
+    retval = UA_MethodCall_golang(server, username, methodId->namespaceIndex,
+                                  nodeID, inputSize, (UA_Variant *)input,
+                                  outputSize, output);
+  }
+
+  free(username);
+  free(nodeID);
+  return retval;
+}
+#endif
+
+static UA_Argument *ua_MethodArgument_build(MethodArgument *args, size_t size) {
+  if (size == 0) {
+    return NULL;
+  }
+  UA_Argument *output =
+      (UA_Argument *)UA_Array_new(size, &UA_TYPES[UA_TYPES_ARGUMENT]);
+  if (output == NULL) {
+    return NULL;
+  }
+  for (size_t i = 0; i < size; i++) {
+    output[i].name = UA_STRING_ALLOC(args[i].name ? args[i].name : "");
+    output[i].description =
+        UA_LOCALIZEDTEXT_ALLOC("", args[i].description ? args[i].description : "");
+    output[i].dataType = UA_NODEID_NUMERIC(0, args[i].dataType);
+    output[i].valueRank = args[i].valueRank;
+  }
+  return output;
+}
+
+UA_StatusCode UA_ServerAddMethod(UA_Server *server, UA_UInt16 parentNsIndex,
+                                 char *parentNodeID, UA_UInt16 aNsIndex,
+                                 char *aNodeID, char *browseName,
+                                 MethodArguments *args) {
+#ifdef UA_ENABLE_METHODCALLS
+  UA_Argument *inputs = ua_MethodArgument_build(args->inputs, args->inputSize);
+  UA_Argument *outputs =
+      ua_MethodArgument_build(args->outputs, args->outputSize);
+
+  if ((args->inputSize > 0 && inputs == NULL) ||
+      (args->outputSize > 0 && outputs == NULL)) {
+    if (inputs != NULL) {
+      UA_Array_delete(inputs, args->inputSize, &UA_TYPES[UA_TYPES_ARGUMENT]);
+    }
+    if (outputs != NULL) {
+      UA_Array_delete(outputs, args->outputSize, &UA_TYPES[UA_TYPES_ARGUMENT]);
+    }
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+
+  UA_MethodAttributes attr = UA_MethodAttributes_default;
+  attr.displayName = UA_LOCALIZEDTEXT("", browseName);
+  attr.executable = true;
+  attr.userExecutable = true;
+
+  UA_StatusCode retval = UA_Server_addMethodNode(
+      server, UA_NODEID_STRING(aNsIndex, aNodeID),
+      UA_NODEID_STRING(parentNsIndex, parentNodeID),
+      UA_NODEID_NUMERIC(0, UA_NS0ID_HASCOMPONENT),
+      UA_QUALIFIEDNAME(aNsIndex, browseName), attr, ua_Method_callback,
+      args->inputSize, inputs, args->outputSize, outputs, NULL, NULL);
+
+  UA_Array_delete(inputs, args->inputSize, &UA_TYPES[UA_TYPES_ARGUMENT]);
+  UA_Array_delete(outputs, args->outputSize, &UA_TYPES[UA_TYPES_ARGUMENT]);
+  return retval;
+#else
+  return UA_STATUSCODE_BADNOTSUPPORTED;
+#endif
+}
+
+EventWhere *UA_EventWhere_alloc(size_t size) {
+  return (EventWhere *)calloc(size, sizeof(EventWhere));
+}
+
+void UA_EventWhere_delete(EventWhere *where, size_t size) {
+  if (where == NULL) {
+    return;
+  }
+  for (size_t i = 0; i < size; i++) {
+    free(where[i].field);
+    UA_Variant_clear(&where[i].value);
+  }
+  free(where);
+}
+
+static void ua_Client_eventCallback(UA_Client *client, UA_UInt32 subId,
+                                    void *subContext, UA_UInt32 monId,
+                                    void *monContext, size_t nEventFields,
+                                    UA_Variant *eventFields) {
+  UA_ClientEvent_golang((UA_UInt64)(uintptr_t)monContext, nEventFields,
+                        eventFields);
+}
+
+static UA_StatusCode ua_SimpleAttributeOperand_init(UA_SimpleAttributeOperand *operand,
+                                                    const char *path) {
+  UA_SimpleAttributeOperand_init(operand);
+  operand->typeDefinitionId = UA_NODEID_NUMERIC(0, UA_NS0ID_BASEEVENTTYPE);
+  operand->attributeId = UA_ATTRIBUTEID_VALUE;
+
+  // the browse path is separated by '/', the names are in namespace 0
+  size_t size = 1;
+  for (const char *c = path; *c != '\0'; c++) {
+    if (*c == '/') {
+      size++;
+    }
+  }
+
+  operand->browsePath =
+      (UA_QualifiedName *)UA_Array_new(size, &UA_TYPES[UA_TYPES_QUALIFIEDNAME]);
+  if (operand->browsePath == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  operand->browsePathSize = size;
+
+  const char *begin = path;
+  for (size_t i = 0; i < size; i++) {
+    const char *end = strchr(begin, '/');
+    size_t length = end != NULL ? (size_t)(end - begin) : strlen(begin);
+    UA_String name = {length, (UA_Byte *)begin};
+    operand->browsePath[i].namespaceIndex = 0;
+    UA_StatusCode retval = UA_String_copy(&name, &operand->browsePath[i].name);
+    if (retval != UA_STATUSCODE_GOOD) {
+      return retval;
+    }
+    begin = end != NULL ? end + 1 : begin + length;
+  }
+  return UA_STATUSCODE_GOOD;
+}
+
+static UA_StatusCode ua_ContentFilterElement_term(UA_ContentFilterElement *element,
+                                                  EventWhere *where) {
+  element->filterOperator = (UA_FilterOperator)where->op;
+
+  if (where->op == UA_FILTEROPERATOR_OFTYPE) {
+    element->filterOperands = UA_ExtensionObject_new();
+    if (element->filterOperands == NULL) {
+      return UA_STATUSCODE_BADOUTOFMEMORY;
+    }
+    element->filterOperandsSize = 1;
+
+    UA_LiteralOperand *literal = UA_LiteralOperand_new();
+    if (literal == NULL) {
+      return UA_STATUSCODE_BADOUTOFMEMORY;
+    }
+    UA_NodeId typeId = UA_NODEID_NUMERIC(where->typeNs, where->typeID);
+    UA_Variant_setScalarCopy(&literal->value, &typeId,
+                             &UA_TYPES[UA_TYPES_NODEID]);
+    UA_ExtensionObject_setValue(&element->filterOperands[0], literal,
+                                &UA_TYPES[UA_TYPES_LITERALOPERAND]);
+    return UA_STATUSCODE_GOOD;
+  }
+
+  element->filterOperands = (UA_ExtensionObject *)UA_Array_new(
+      2, &UA_TYPES[UA_TYPES_EXTENSIONOBJECT]);
+  if (element->filterOperands == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  element->filterOperandsSize = 2;
+
+  UA_SimpleAttributeOperand *attribute = UA_SimpleAttributeOperand_new();
+  if (attribute == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  UA_ExtensionObject_setValue(&element->filterOperands[0], attribute,
+                              &UA_TYPES[UA_TYPES_SIMPLEATTRIBUTEOPERAND]);
+  UA_StatusCode retval = ua_SimpleAttributeOperand_init(attribute, where->field);
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+
+  UA_LiteralOperand *literal = UA_LiteralOperand_new();
+  if (literal == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  UA_ExtensionObject_setValue(&element->filterOperands[1], literal,
+                              &UA_TYPES[UA_TYPES_LITERALOPERAND]);
+  return UA_Variant_copy(&where->value, &literal->value);
+}
+
+static UA_StatusCode ua_ContentFilterElement_and(UA_ContentFilterElement *element,
+                                                 UA_UInt32 left,
+                                                 UA_UInt32 right) {
+  element->filterOperator = UA_FILTEROPERATOR_AND;
+  element->filterOperands = (UA_ExtensionObject *)UA_Array_new(
+      2, &UA_TYPES[UA_TYPES_EXTENSIONOBJECT]);
+  if (element->filterOperands == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  element->filterOperandsSize = 2;
+
+  UA_UInt32 indexes[2] = {left, right};
+  for (size_t i = 0; i < 2; i++) {
+    UA_ElementOperand *operand = UA_ElementOperand_new();
+    if (operand == NULL) {
+      return UA_STATUSCODE_BADOUTOFMEMORY;
+    }
+    operand->index = indexes[i];
+    UA_ExtensionObject_setValue(&element->filterOperands[i], operand,
+                                &UA_TYPES[UA_TYPES_ELEMENTOPERAND]);
+  }
+  return UA_STATUSCODE_GOOD;
+}
+
+static UA_StatusCode ua_EventFilter_init(UA_EventFilter *filter,
+                                         size_t selectSize, char **selects,
+                                         size_t whereSize, EventWhere *where) {
+  UA_EventFilter_init(filter);
+
+  filter->selectClauses = (UA_SimpleAttributeOperand *)UA_Array_new(
+      selectSize, &UA_TYPES[UA_TYPES_SIMPLEATTRIBUTEOPERAND]);
+  if (filter->selectClauses == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  filter->selectClausesSize = selectSize;
+
+  for (size_t i = 0; i < selectSize; i++) {
+    UA_StatusCode retval =
+        ua_SimpleAttributeOperand_init(&filter->selectClauses[i], selects[i]);
+    if (retval != UA_STATUSCODE_GOOD) {
+      return retval;
+    }
+  }
+
+  if (whereSize == 0) {
+    return UA_STATUSCODE_GOOD;
+  }
+
+  // elements 0..n-2 are the And operators, elements n-1..2n-2 are the terms
+  size_t size = 2 * whereSize - 1;
+  filter->whereClause.elements = (UA_ContentFilterElement *)UA_Array_new(
+      size, &UA_TYPES[UA_TYPES_CONTENTFILTERELEMENT]);
+  if (filter->whereClause.elements == NULL) {
+    return UA_STATUSCODE_BADOUTOFMEMORY;
+  }
+  filter->whereClause.elementsSize = size;
+
+  for (size_t i = 0; i + 1 < whereSize; i++) {
+    UA_UInt32 right = (i + 2 == whereSize) ? (UA_UInt32)(2 * whereSize - 2)
+                                           : (UA_UInt32)(i + 1);
+    UA_StatusCode retval = ua_ContentFilterElement_and(
+        &filter->whereClause.elements[i], (UA_UInt32)(whereSize - 1 + i), right);
+    if (retval != UA_STATUSCODE_GOOD) {
+      return retval;
+    }
+  }
+  for (size_t i = 0; i < whereSize; i++) {
+    UA_StatusCode retval = ua_ContentFilterElement_term(
+        &filter->whereClause.elements[whereSize - 1 + i], &where[i]);
+    if (retval != UA_STATUSCODE_GOOD) {
+      return retval;
+    }
+  }
+  return UA_STATUSCODE_GOOD;
+}
+
+UA_StatusCode UA_ClientSubscribeEvents(UA_Client *client, UA_Double interval,
+                                       size_t selectSize, char **selects,
+                                       size_t whereSize, EventWhere *where,
+                                       UA_UInt64 handle, UA_UInt32 *subId) {
+  UA_EventFilter filter;
+  UA_StatusCode retval =
+      ua_EventFilter_init(&filter, selectSize, selects, whereSize, where);
+  if (retval != UA_STATUSCODE_GOOD) {
+    UA_EventFilter_clear(&filter);
+    return retval;
+  }
+
+  UA_CreateSubscriptionRequest request = UA_CreateSubscriptionRequest_default();
+  request.requestedPublishingInterval = interval;
+  UA_CreateSubscriptionResponse response =
+      UA_Client_Subscriptions_create(client, request, NULL, NULL, NULL);
+  retval = response.responseHeader.serviceResult;
+  *subId = response.subscriptionId;
+  UA_CreateSubscriptionResponse_clear(&response);
+  if (retval != UA_STATUSCODE_GOOD) {
+    UA_EventFilter_clear(&filter);
+    return retval;
+  }
+
+  UA_MonitoredItemCreateRequest item;
+  UA_MonitoredItemCreateRequest_init(&item);
+  item.itemToMonitor.nodeId = UA_NODEID_NUMERIC(0, UA_NS0ID_SERVER);
+  item.itemToMonitor.attributeId = UA_ATTRIBUTEID_EVENTNOTIFIER;
+  item.monitoringMode = UA_MONITORINGMODE_REPORTING;
+  item.requestedParameters.samplingInterval = 0;
+  item.requestedParameters.discardOldest = true;
+  item.requestedParameters.queueSize = 100;
+  item.requestedParameters.filter.encoding = UA_EXTENSIONOBJECT_DECODED;
+  item.requestedParameters.filter.content.decoded.data = &filter;
+  item.requestedParameters.filter.content.decoded.type =
+      &UA_TYPES[UA_TYPES_EVENTFILTER];
+
+  UA_MonitoredItemCreateResult result = UA_Client_MonitoredItems_createEvent(
+      client, *subId, UA_TIMESTAMPSTORETURN_BOTH, item,
+      (void *)(uintptr_t)handle, ua_Client_eventCallback, NULL);
+  retval = result.statusCode;
+  UA_MonitoredItemCreateResult_clear(&result);
+  UA_EventFilter_clear(&filter);
+
+  if (retval != UA_STATUSCODE_GOOD) {
+    UA_Client_Subscriptions_deleteSingle(client, *subId);
+  }
+  return retval;
+}
+
+UA_StatusCode UA_ClientUnsubscribe(UA_Client *client, UA_UInt32 subId) {
+  return UA_Client_Subscriptions_deleteSingle(client, subId);
+}
+
+UA_StatusCode UA_ClientIterate(UA_Client *client) {
+  return UA_Client_run_iterate(client, 0);
+}
+
+char *UA_VariantText(const UA_Variant *value) {
+  UA_String output = UA_STRING_NULL;
+
+  if (UA_Variant_isEmpty(value)) {
+    return ua_String_chars(&output);
+  }
+
+  if (UA_Variant_hasScalarType(value, &UA_TYPES[UA_TYPES_LOCALIZEDTEXT])) {
+    return ua_String_chars(&((UA_LocalizedText *)value->data)->text);
+  }
+
+  if (UA_Variant_hasScalarType(value, &UA_TYPES[UA_TYPES_STRING])) {
+    return ua_String_chars((UA_String *)value->data);
+  }
+
+  if (UA_Variant_hasScalarType(value, &UA_TYPES[UA_TYPES_NODEID])) {
+    UA_NodeId_print((UA_NodeId *)value->data, &output);
+  } else if (UA_Variant_isScalar(value)) {
+    UA_print(value->data, value->type, &output);
+  } else {
+    UA_print(value, &UA_TYPES[UA_TYPES_VARIANT], &output);
+  }
+
+  char *text = ua_String_chars(&output);
+  UA_String_clear(&output);
+  return text;
+}
+
+UA_StatusCode UA_ServerEnableEvents(UA_Server *server, UA_UInt16 nsIndex,
+                                    char *nodeID) {
+  return UA_Server_writeEventNotifier(server, UA_NODEID_STRING(nsIndex, nodeID),
+                                      UA_EVENTNOTIFIER_SUBSCRIBE_TO_EVENT);
+}
+
+UA_StatusCode UA_ServerTriggerEvent(UA_Server *server, UA_UInt16 nsIndex,
+                                    char *nodeID, UA_UInt32 eventType,
+                                    UA_DateTime time, UA_UInt16 severity,
+                                    char *message, char *sourceName) {
+#ifdef UA_ENABLE_SUBSCRIPTIONS_EVENTS
+  UA_NodeId eventId;
+  UA_StatusCode retval = UA_Server_createEvent(
+      server, UA_NODEID_NUMERIC(0, eventType), &eventId);
+  if (retval != UA_STATUSCODE_GOOD && eventType != UA_NS0ID_BASEEVENTTYPE) {
+    // the source event type is not known by the proxy, use the base type
+    retval = UA_Server_createEvent(
+        server, UA_NODEID_NUMERIC(0, UA_NS0ID_BASEEVENTTYPE), &eventId);
+  }
+  if (retval != UA_STATUSCODE_GOOD) {
+    return retval;
+  }
+
+  UA_Server_writeObjectProperty_scalar(server, eventId,
+                                       UA_QUALIFIEDNAME(0, "Time"), &time,
+                                       &UA_TYPES[UA_TYPES_DATETIME]);
+  UA_Server_writeObjectProperty_scalar(server, eventId,
+                                       UA_QUALIFIEDNAME(0, "Severity"),
+                                       &severity, &UA_TYPES[UA_TYPES_UINT16]);
+
+  UA_LocalizedText text = UA_LOCALIZEDTEXT("", message);
+  UA_Server_writeObjectProperty_scalar(server, eventId,
+                                       UA_QUALIFIEDNAME(0, "Message"), &text,
+                                       &UA_TYPES[UA_TYPES_LOCALIZEDTEXT]);
+
+  UA_String name = UA_STRING(sourceName);
+  UA_Server_writeObjectProperty_scalar(server, eventId,
+                                       UA_QUALIFIEDNAME(0, "SourceName"), &name,
+                                       &UA_TYPES[UA_TYPES_STRING]);
+
+  return UA_Server_triggerEvent(server, eventId,
+                                UA_NODEID_STRING(nsIndex, nodeID), NULL, true);
+#else
+  return UA_STATUSCODE_BADNOTSUPPORTED;
+#endif
+}
@@ -5,18 +5,39 @@
 #include <stdio.h>
 #include <stdlib.h>
 
-typedef struct nodeTree {
-  uint32_t level;
-  uint32_t index;
-  char *nodeID;
-  uint32_t nodeLength;
-
-  struct nodeTree *parent;
-  struct nodeTree *next;
+#define UA_BROWSE_MAX_REFERENCES 1000
+#define UA_BROWSE_MAX_PAGES 100
 
-  struct nodeTree *head;
-  struct nodeTree *tail;
-} NodeTree;
+typedef struct browseItem {
+  UA_NodeId id;
+  char *nodeID;
+  uint16_t nsIndex;
+  char *browseName;
+  char *displayName;
+  uint32_t nodeClass;
+  char *dataType;
+  uint8_t accessLevel;
+} BrowseItem;
+
+typedef struct nodeMeta {
+  char *displayName;
+  char *description;
+  UA_Byte accessLevel;
+  UA_UInt32 typeDefinition;
+
+  UA_Boolean hasRange;
+  UA_Double rangeLow;
+  UA_Double rangeHigh;
+
+  UA_Boolean hasUnits;
+  char *unitNamespace;
+  UA_Int32 unitID;
+  char *unitName;
+  char *unitDescription;
+
+  char *trueState;
+  char *falseState;
+} NodeMeta;
 
 extern UA_StatusCode UA_VariantType(UA_Variant *variant, UA_UInt32 *ptype);
 
@@ -144,28 +165,164 @@ extern UA_StatusCode UA_ArrayValueAppendByteString(ArrayValue *array,
 extern void UA_VariantArrayValue(UA_Variant *variant, ArrayValue *value,
                                  uint32_t uaType);
 
+extern UA_StatusCode UA_VariantSetDimensions(UA_Variant *variant,
+                                             UA_UInt32 *dims, size_t size);
+
+extern UA_UInt32 UA_VariantDimension(UA_Variant *variant, size_t index);
+
 //
-extern UA_StatusCode UA_Browse_nodeTree(UA_Client *client, NodeTree *root);
+#define UA_GUID_TEXT_LENGTH 37
 
-extern UA_StatusCode UA_VariantValueWrite(UA_Client *client, uint32_t nsIndex,
-                                          char *nodeID, UA_Variant *variant);
+typedef struct {
+  const char *typeName;
+  UA_UInt32 memSize;
+  UA_UInt32 typeKind;
+  UA_UInt32 membersSize;
+} DataTypeInfo;
+
+typedef struct {
+  const char *name;
+  const UA_DataType *type;
+  UA_Byte padding;
+  UA_Boolean isArray;
+  UA_Boolean isOptional;
+} DataTypeMember;
+
+extern void *UA_VariantValueAt(UA_Variant *value, int index);
+
+extern void UA_GuidText(const UA_Guid *guid, char *output);
+
+extern UA_NodeId UA_NodeIdMake(UA_UInt16 nsIndex, char idType,
+                               char *identifier, size_t length);
+
+extern char *UA_NodeIdText(const UA_NodeId *nodeID);
+
+extern const char *UA_StatusCodeText(UA_StatusCode code);
+
+extern UA_StatusCode UA_ExtensionObjectEncoded(const UA_ExtensionObject *eo,
+                                               char **typeID,
+                                               UA_ByteString *body);
+
+extern const UA_DataType *UA_ExtensionObjectDecoded(const UA_ExtensionObject *eo,
+                                                    void **data);
+
+extern void UA_DataTypeInfoGet(const UA_DataType *type, DataTypeInfo *info);
+
+extern void UA_DataTypeMemberGet(const UA_DataType *type, size_t index,
+                                 DataTypeMember *member);
 
-// node tree init and view functions
-extern NodeTree *UA_NodeTree_root_init(void);
+extern void UA_VariantView(UA_Variant *variant, void *data, size_t length,
+                           const UA_DataType *type, UA_Boolean array);
 
-extern void UA_NodeTree_clear(NodeTree *nodeTree);
+extern UA_StatusCode UA_VariantScalarValueGuid(UA_Variant *variant,
+                                               char *value);
 
-extern NodeTree *UA_NodeTree_next(NodeTree *nodeTree);
+extern UA_StatusCode UA_VariantScalarValueNodeId(UA_Variant *variant,
+                                                 char *value);
+
+extern UA_StatusCode UA_VariantScalarValueStatusCode(UA_Variant *variant,
+                                                     UA_StatusCode value);
+
+extern UA_StatusCode UA_VariantScalarValueQualifiedName(UA_Variant *variant,
+                                                        UA_UInt16 nsIndex,
+                                                        char *name);
+
+extern UA_StatusCode UA_VariantScalarValueLocalizedText(UA_Variant *variant,
+                                                        char *locale,
+                                                        char *text);
+
+extern UA_StatusCode UA_VariantScalarValueExtensionObject(UA_Variant *variant,
+                                                          char *typeID,
+                                                          void *body,
+                                                          size_t length);
+
+extern UA_StatusCode UA_ArrayValueAppendGuid(ArrayValue *array, char *value);
+
+extern UA_StatusCode UA_ArrayValueAppendNodeId(ArrayValue *array, char *value);
+
+extern UA_StatusCode UA_ArrayValueAppendStatusCode(ArrayValue *array,
+                                                   UA_StatusCode value);
+
+extern UA_StatusCode UA_ArrayValueAppendQualifiedName(ArrayValue *array,
+                                                      UA_UInt16 nsIndex,
+                                                      char *name);
 
-extern NodeTree *UA_NodeTree_head(NodeTree *nodeTree);
+extern UA_StatusCode UA_ArrayValueAppendLocalizedText(ArrayValue *array,
+                                                      char *locale, char *text);
+
+extern UA_StatusCode UA_ClientReadAttributeText(UA_Client *client,
+                                                char *nodeID,
+                                                UA_UInt32 attributeID,
+                                                UA_Variant *value);
+
+extern UA_StatusCode UA_ClientReadValueRange(UA_Client *client,
+                                             UA_NodeId nodeId,
+                                             char *indexRange,
+                                             UA_Variant *value);
+
+extern char *UA_ClientBrowseFirst(UA_Client *client, char *nodeID,
+                                  UA_UInt32 referenceType, UA_Boolean subtypes,
+                                  UA_Boolean inverse, UA_StatusCode *status);
+
+extern UA_StatusCode UA_ArrayValueAppendExtensionObject(ArrayValue *array,
+                                                        char *typeID,
+                                                        void *body,
+                                                        size_t length);
+
+//
+extern UA_StatusCode UA_ClientBrowseChildren(UA_Client *client, char *nodeID,
+                                             size_t chunk, BrowseItem **items,
+                                             size_t *size);
+
+extern void UA_BrowseItems_free(BrowseItem *items, size_t size);
+
+extern UA_StatusCode UA_VariantValueWrite(UA_Client *client, uint32_t nsIndex,
+                                          char *nodeID, UA_Variant *variant);
 
 extern UA_ReadValueId *UA_ReadValueID_alloc(int number);
 
 extern void UA_ReadValueID_free(UA_ReadValueId *readValueId);
 
-extern void UA_ReadValueID_string(UA_ReadValueId *readValueId, int index,
-                                  UA_UInt16 nsIndex, char *chars,
-                                  UA_UInt32 attributeId);
+extern void UA_ReadValueID_set(UA_ReadValueId *readValueId, int index,
+                               UA_NodeId nodeId, UA_UInt32 attributeId);
+
+extern void UA_ReadValueID_range(UA_ReadValueId *readValueId, int index,
+                                 char *indexRange);
+
+extern UA_StatusCode UA_ClientReadChunks(UA_Client *client,
+                                         UA_ReadValueId *items, size_t size,
+                                         size_t chunkSize, size_t pipeline,
+                                         UA_ReadResponse *responses,
+                                         UA_StatusCode *status);
+
+extern UA_StatusCode UA_ClientWriteChunks(UA_Client *client,
+                                          UA_WriteValue *items, size_t size,
+                                          size_t chunkSize, size_t pipeline,
+                                          UA_WriteResponse *responses,
+                                          UA_StatusCode *status);
+
+extern UA_ReadResponse *UA_ReadResponse_alloc(size_t size);
+
+extern void UA_ReadResponse_free(UA_ReadResponse *responses, size_t size);
+
+extern UA_DataValue *UA_ReadResponse_result(UA_ReadResponse *responses,
+                                            size_t chunk, size_t index);
+
+extern UA_StatusCode UA_DataValueStatus(UA_DataValue *value);
+
+extern UA_WriteValue *UA_WriteValue_alloc(size_t size);
+
+extern void UA_WriteValue_set(UA_WriteValue *items, size_t index,
+                              UA_NodeId nodeId, UA_Variant *value);
+
+extern void UA_WriteValue_free(UA_WriteValue *items, size_t size);
+
+extern UA_WriteResponse *UA_WriteResponse_alloc(size_t size);
+
+extern void UA_WriteResponse_free(UA_WriteResponse *responses, size_t size);
+
+extern UA_StatusCode UA_WriteResponse_result(UA_WriteResponse *responses,
+                                             size_t chunk, size_t index);
 
 extern UA_Variant *UA_ReadResponse_variant(UA_ReadResponse *response,
                                            int index);
@@ -207,4 +364,217 @@ UA_ServerAddVariable(UA_Server *server, UA_UInt16 parentNsIndex,
                      char *parentNodeID, UA_UInt16 aNsIndex, char *aNodeID,
                      char *displayName, UA_Variant *variant);
 
+extern UA_StatusCode
+UA_ServerAddVariableMeta(UA_Server *server, UA_UInt16 parentNsIndex,
+                         char *parentNodeID, UA_UInt16 aNsIndex, char *aNodeID,
+                         char *browseName, UA_Variant *variant,
+                         NodeMeta *meta);
+
+extern UA_StatusCode UA_ServerAddFolder(UA_Server *server,
+                                        UA_UInt16 parentNsIndex,
+                                        char *parentNodeID, UA_UInt16 aNsIndex,
+                                        char *aNodeID, char *browseName);
+
+extern UA_StatusCode UA_ServerDeleteTree(UA_Server *server, UA_NodeId nodeId);
+
+// method call pass-through functions, only ns0 numeric data types are kept
+typedef struct {
+  char *name;
+  char *description;
+  UA_UInt32 dataType;
+  UA_Int32 valueRank;
+} MethodArgument;
+
+typedef struct {
+  size_t inputSize;
+  MethodArgument *inputs;
+  size_t outputSize;
+  MethodArgument *outputs;
+} MethodArguments;
+
+extern UA_StatusCode UA_MethodCall_golang(void *server, char *username,
+                                          UA_UInt16 nsIndex, char *nodeID,
+                                          size_t inputSize,
+                                          UA_Variant *input,
+                                          size_t outputSize,
+                                          UA_Variant *output);
+
+extern UA_StatusCode UA_ClientReadMethodArguments(UA_Client *client,
+                                                  UA_NodeId nodeId,
+                                                  MethodArguments *args);
+
+extern void UA_MethodArguments_clear(MethodArguments *args);
+
+extern UA_StatusCode UA_MethodArguments_alloc(MethodArguments *args,
+                                              size_t inputSize,
+                                              size_t outputSize);
+
+extern UA_StatusCode UA_ClientCallMethod(UA_Client *client,
+                                         UA_NodeId objectId,
+                                         UA_NodeId methodId, size_t inputSize,
+                                         UA_Variant *input, size_t *outputSize,
+                                         UA_Variant **output,
+                                         UA_UInt32 timeout);
+
+extern UA_Variant *UA_VariantArray_alloc(size_t size);
+
+extern void UA_VariantArray_delete(UA_Variant *array, size_t size);
+
+extern UA_StatusCode UA_ServerAddMethod(UA_Server *server,
+                                        UA_UInt16 parentNsIndex,
+                                        char *parentNodeID, UA_UInt16 aNsIndex,
+                                        char *aNodeID, char *browseName,
+                                        MethodArguments *args);
+
+// event subscription functions, the where terms are joined by And
+typedef struct {
+  UA_UInt32 op;
+  char *field;
+  UA_UInt16 typeNs;
+  UA_UInt32 typeID;
+  UA_Variant value;
+} EventWhere;
+
+extern void UA_ClientEvent_golang(UA_UInt64 handle, size_t size,
+                                  UA_Variant *fields);
+
+extern EventWhere *UA_EventWhere_alloc(size_t size);
+
+extern void UA_EventWhere_delete(EventWhere *where, size_t size);
+
+extern UA_StatusCode UA_ClientSubscribeEvents(UA_Client *client,
+                                              UA_Double interval,
+                                              size_t selectSize,
+                                              char **selects,
+                                              size_t whereSize,
+                                              EventWhere *where,
+                                              UA_UInt64 handle,
+                                              UA_UInt32 *subId);
+
+extern UA_StatusCode UA_ClientUnsubscribe(UA_Client *client, UA_UInt32 subId);
+
+extern UA_StatusCode UA_ClientIterate(UA_Client *client);
+
+extern char *UA_VariantText(const UA_Variant *value);
+
+extern UA_StatusCode UA_ServerEnableEvents(UA_Server *server,
+                                           UA_UInt16 nsIndex, char *nodeID);
+
+extern UA_StatusCode UA_ServerTriggerEvent(UA_Server *server,
+                                           UA_UInt16 nsIndex, char *nodeID,
+                                           UA_UInt32 eventType,
+                                           UA_DateTime time,
+                                           UA_UInt16 severity, char *message,
+                                           char *sourceName);
+
+// server security functions
+#define SECURITY_POLICY_NONE 0x01
+#define SECURITY_POLICY_BASIC128RSA15 0x02
+#define SECURITY_POLICY_BASIC256 0x04
+#define SECURITY_POLICY_BASIC256SHA256 0x08
+#define SECURITY_POLICY_AES128SHA256RSAOAEP 0x10
+
+typedef struct {
+  UA_UInt32 policies;
+  UA_Boolean sign;
+  UA_Boolean signAndEncrypt;
+  UA_ByteString certificate;
+  UA_ByteString privateKey;
+  UA_ByteString *trustList;
+  size_t trustListSize;
+  UA_Boolean allowAnonymous;
+  UA_UsernamePasswordLogin *logins;
+  size_t loginsSize;
+} ServerSecurity;
+
+extern UA_Boolean UA_EncryptionSupported(void);
+
+extern UA_Boolean UA_DiscoveryMulticastSupported(void);
+
+extern UA_StatusCode UA_ServerSecurity_alloc(ServerSecurity *security,
+                                             size_t trustListSize,
+                                             size_t loginsSize);
+
+extern void UA_ServerSecurity_setBytes(UA_ByteString *bytes, void *data,
+                                       size_t length);
+
+extern UA_ByteString *UA_ServerSecurity_trust(ServerSecurity *security,
+                                              size_t index);
+
+extern void UA_ServerSecurity_setLogin(ServerSecurity *security, size_t index,
+                                       char *username, char *password);
+
+extern void UA_ServerSecurity_clear(ServerSecurity *security);
+
+extern UA_StatusCode UA_ServerConfig_setSecurity(UA_ServerConfig *config,
+                                                 UA_UInt16 port,
+                                                 const ServerSecurity *security);
+
+extern UA_Client *UA_DiscoveryClient_new(void *context, void *log);
+
+extern void UA_ApplicationDescriptions_free(UA_ApplicationDescription *servers,
+                                            size_t size);
+
+extern void UA_EndpointDescriptions_free(UA_EndpointDescription *endpoints,
+                                         size_t size);
+
+extern UA_EndpointDescription *UA_ServerEndpoint(UA_Server *server,
+                                                 size_t index);
+
+// access control wrapper functions
+#define ACCESS_OPERATION_BROWSE 0
+#define ACCESS_OPERATION_LEVEL 1
+
+extern UA_Byte UA_AccessControl_golang(void *server, char *username,
+                                       UA_UInt16 nsIndex, char *nodeID,
+                                       UA_UInt32 operation);
+
+extern void UA_ServerAccessControl_init(UA_Server *server);
+
+// history read wrapper functions, data points to UA_HistoryData and
+// continuationPoint to UA_ByteString, they are opaque without historizing
+typedef struct {
+  void *data;
+  void *continuationPoint;
+} HistoryResult;
+
+extern UA_StatusCode UA_HistoryRead_golang(
+    void *server, UA_UInt16 nsIndex, char *nodeID, UA_DateTime startTime,
+    UA_DateTime endTime, UA_UInt32 maxValues, UA_UInt32 aggregate,
+    UA_Double interval, void *continuationPoint, size_t continuationLength,
+    UA_Boolean release, HistoryResult *result);
+
+extern UA_Boolean UA_ClientHistoryValue_golang(UA_UInt64 handle,
+                                               UA_Variant *value,
+                                               UA_DateTime timestamp,
+                                               UA_StatusCode status);
+
+extern UA_StatusCode UA_ClientHistoryReadRaw(UA_Client *client,
+                                             UA_NodeId nodeId,
+                                             UA_DateTime startTime,
+                                             UA_DateTime endTime,
+                                             UA_UInt32 maxValues,
+                                             UA_UInt64 handle);
+
+extern UA_StatusCode UA_ServerHistory_init(UA_Server *server);
+
+extern UA_StatusCode UA_ServerHistory_historizing(UA_Server *server,
+                                                  UA_UInt16 nsIndex,
+                                                  char *nodeID);
+
+extern UA_StatusCode UA_HistoryResult_append(HistoryResult *result,
+                                             UA_Variant *value,
+                                             UA_DateTime sourceTimestamp,
+                                             UA_StatusCode status);
+
+extern UA_StatusCode UA_HistoryResult_continuation(HistoryResult *result,
+                                                   void *data, size_t length);
+
+// node meta read functions
+extern UA_StatusCode UA_ClientReadNodeMeta(UA_Client *client,
+                                           UA_NodeId nodeId,
+                                           NodeMeta *meta);
+
+extern void UA_NodeMeta_clear(NodeMeta *meta);
+
 #endif
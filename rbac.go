@@ -0,0 +1,464 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+var fullPermission = AccessPermission{Read: true, Write: true, Browse: true}
+
+func (p AccessPermission) String() string {
+	output := ""
+	if p.Read {
+		output += "r"
+	}
+	if p.Write {
+		output += "w"
+	}
+	if p.Browse {
+		output += "b"
+	}
+	return output
+}
+
+func AccessPermissionParse(text string) (AccessPermission, error) {
+	var permission AccessPermission
+	for _, c := range strings.ToLower(strings.TrimSpace(text)) {
+		switch c {
+		case 'r':
+			permission.Read = true
+		case 'w':
+			permission.Write = true
+		case 'b':
+			permission.Browse = true
+		case '-', ' ', ',':
+		default:
+			return permission, fmt.Errorf("permission %q is invalid, use r/w/b", text)
+		}
+	}
+	return permission, nil
+}
+
+// Permission resolves node rule first, then namespace (client) rule, then the role default
+func (r *RoleConfig) Permission(namespace string, nodeID string) AccessPermission {
+	for _, rule := range r.Rules {
+		if rule.Target == nodeID {
+			return rule.AccessPermission
+		}
+	}
+	for _, rule := range r.Rules {
+		if rule.Target == namespace {
+			return rule.AccessPermission
+		}
+	}
+	return r.Default
+}
+
+type AccessControl struct {
+	sync.Mutex
+
+	roles       map[string]RoleConfig
+	users       map[string]string
+	anonymous   string
+	defaultRole string
+	denied      map[string]time.Time
+}
+
+func NewAccessControl(cfg ServerConfig) *AccessControl {
+	if len(cfg.Roles) == 0 {
+		return nil
+	}
+
+	access := &AccessControl{
+		roles:       make(map[string]RoleConfig),
+		users:       make(map[string]string),
+		anonymous:   cfg.Security.AnonymousRole,
+		defaultRole: cfg.Security.DefaultRole,
+		denied:      make(map[string]time.Time),
+	}
+	for _, role := range cfg.Roles {
+		access.roles[role.Name] = role
+	}
+	for _, user := range cfg.Security.Users {
+		access.users[user.UserName] = user.Role
+	}
+
+	logs.Info("access control init with %d roles, %d users, default role %q", len(access.roles), len(access.users), access.defaultRole)
+	return access
+}
+
+// role returns the role of the user, the user without role gets the default role
+func (a *AccessControl) role(user string) string {
+	role := a.anonymous
+	if user != "" {
+		role = a.users[user]
+	}
+	if role == "" {
+		role = a.defaultRole
+	}
+	return role
+}
+
+// Denied logs the rejected operation, the same user, operation and node is logged once in 10 minutes
+func (a *AccessControl) Denied(user string, namespace string, nodeID string, operation string) {
+	role := a.role(user)
+	if role == "" {
+		role = "(none)"
+	} else if _, ok := a.roles[role]; !ok {
+		role += "(not exist)"
+	}
+	if user == "" {
+		user = "anonymous"
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s", user, operation, namespace, nodeID)
+	now := time.Now()
+
+	a.Lock()
+	last, ok := a.denied[key]
+	if ok && now.Sub(last) < 10*time.Minute {
+		a.Unlock()
+		return
+	}
+	a.denied[key] = now
+	a.Unlock()
+
+	logs.Warning("access control denied %s, user: %s, role: %s, node: %s:%s",
+		operation, user, role, namespace, nodeID)
+}
+
+// Permission returns the permission of the user role, the user without any role has no access
+func (a *AccessControl) Permission(user string, namespace string, nodeID string) AccessPermission {
+	if namespace == "" {
+		return fullPermission
+	}
+
+	roleConfig, ok := a.roles[a.role(user)]
+	if !ok {
+		return AccessPermission{}
+	}
+	return roleConfig.Permission(namespace, nodeID)
+}
+
+// SecurityLoad loads the server security and attaches the role based access control
+func (c *ServerConfig) SecurityLoad() (*ServerSecurity, error) {
+	security, err := c.Security.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	access := NewAccessControl(*c)
+	if access == nil {
+		return security, nil
+	}
+
+	if security == nil {
+		security = &ServerSecurity{
+			Policies:  SECURITY_POLICY_NONE,
+			Anonymous: true,
+			Users:     make(map[string]string),
+		}
+	}
+	security.Access = access.Permission
+	security.Denied = access.Denied
+	return security, nil
+}
+
+func rolesToText(role RoleConfig) string {
+	lines := make([]string, 0)
+	for _, rule := range role.Rules {
+		lines = append(lines, rule.Target+" = "+rule.AccessPermission.String())
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+func rolesFromText(text string) ([]RoleRule, error) {
+	rules := make([]RoleRule, 0)
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("line %d: the format is target = rwb", i+1)
+		}
+		permission, err := AccessPermissionParse(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+		}
+		rules = append(rules, RoleRule{Target: strings.TrimSpace(parts[0]), AccessPermission: permission})
+	}
+	return rules, nil
+}
+
+func userRolesToText(users []ServerUser) string {
+	lines := make([]string, 0)
+	for _, user := range users {
+		lines = append(lines, user.UserName+" = "+user.Role)
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+func ServerRoleDialog(from walk.Form, config *ServerConfig) {
+	var dlg *walk.Dialog
+	var roleBox *walk.ComboBox
+	var nameLine, anonymousLine, defaultLine *walk.LineEdit
+	var readCB, writeCB, browseCB *walk.CheckBox
+	var rulesEdit, usersEdit *walk.TextEdit
+	var acceptPB, cancelPB *walk.PushButton
+
+	roles := make([]RoleConfig, len(config.Roles))
+	copy(roles, config.Roles)
+	current := -1
+
+	// save the widgets into the current role before switching
+	roleSave := func() error {
+		if current < 0 || current >= len(roles) {
+			return nil
+		}
+		rules, err := rolesFromText(rulesEdit.Text())
+		if err != nil {
+			return err
+		}
+		roles[current].Default = AccessPermission{Read: readCB.Checked(), Write: writeCB.Checked(), Browse: browseCB.Checked()}
+		roles[current].Rules = rules
+		return nil
+	}
+
+	roleShow := func(index int) {
+		current = index
+		if index < 0 || index >= len(roles) {
+			readCB.SetChecked(false)
+			writeCB.SetChecked(false)
+			browseCB.SetChecked(false)
+			rulesEdit.SetText("")
+			return
+		}
+		readCB.SetChecked(roles[index].Default.Read)
+		writeCB.SetChecked(roles[index].Default.Write)
+		browseCB.SetChecked(roles[index].Default.Browse)
+		rulesEdit.SetText(rolesToText(roles[index]))
+	}
+
+	roleModel := func() []string {
+		names := make([]string, 0)
+		for _, role := range roles {
+			names = append(names, role.Name)
+		}
+		return names
+	}
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Server Role Settings",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 550, Height: 500},
+		Size:          Size{Width: 550, Height: 500},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					Label{
+						Text: "Role:",
+					},
+					ComboBox{
+						AssignTo: &roleBox,
+						Model:    roleModel(),
+						OnCurrentIndexChanged: func() {
+							if err := roleSave(); err != nil {
+								ErrorBoxAction(dlg, "Role rules is invalid, "+err.Error())
+								roleBox.SetCurrentIndex(current)
+								return
+							}
+							roleShow(roleBox.CurrentIndex())
+						},
+					},
+					LineEdit{
+						AssignTo: &nameLine,
+					},
+					PushButton{
+						Text: "Add Role",
+						OnClicked: func() {
+							name := strings.TrimSpace(nameLine.Text())
+							if name == "" {
+								ErrorBoxAction(dlg, "The role name cannot be empty!")
+								return
+							}
+							for _, role := range roles {
+								if role.Name == name {
+									ErrorBoxAction(dlg, "The role name already exist!")
+									return
+								}
+							}
+							if err := roleSave(); err != nil {
+								ErrorBoxAction(dlg, "Role rules is invalid, "+err.Error())
+								return
+							}
+							roles = append(roles, RoleConfig{Name: name, Default: AccessPermission{Read: true, Browse: true}, Rules: make([]RoleRule, 0)})
+							current = -1
+							roleBox.SetModel(roleModel())
+							roleBox.SetCurrentIndex(len(roles) - 1)
+							nameLine.SetText("")
+						},
+					},
+					PushButton{
+						Text: "Delete Role",
+						OnClicked: func() {
+							index := roleBox.CurrentIndex()
+							if index < 0 || index >= len(roles) {
+								return
+							}
+							roles = append(roles[:index], roles[index+1:]...)
+							current = -1
+							roleBox.SetModel(roleModel())
+							roleShow(-1)
+						},
+					},
+				},
+			},
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					Label{
+						Text: "Default Permission:",
+					},
+					CheckBox{
+						AssignTo: &readCB,
+						Text:     "Read",
+					},
+					CheckBox{
+						AssignTo: &writeCB,
+						Text:     "Write",
+					},
+					CheckBox{
+						AssignTo: &browseCB,
+						Text:     "Browse",
+					},
+					HSpacer{},
+				},
+			},
+			Label{
+				Text: "Rules (client name or server node name = rwb, one per line):",
+			},
+			TextEdit{
+				AssignTo: &rulesEdit,
+				VScroll:  true,
+			},
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					Label{
+						Text: "Anonymous Role:",
+					},
+					LineEdit{
+						AssignTo:    &anonymousLine,
+						Text:        config.Security.AnonymousRole,
+						ToolTipText: "Empty means the default role",
+					},
+					Label{
+						Text: "Default Role:",
+					},
+					LineEdit{
+						AssignTo:    &defaultLine,
+						Text:        config.Security.DefaultRole,
+						ToolTipText: "The role of the users without role, empty means no access",
+					},
+				},
+			},
+			Label{
+				Text: "User Roles (username = role, empty role means the default role):",
+			},
+			TextEdit{
+				AssignTo: &usersEdit,
+				Text:     userRolesToText(config.Security.Users),
+				VScroll:  true,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							if err := roleSave(); err != nil {
+								ErrorBoxAction(dlg, "Role rules is invalid, "+err.Error())
+								return
+							}
+
+							names := make(map[string]bool)
+							for _, role := range roles {
+								names[role.Name] = true
+							}
+
+							anonymous := strings.TrimSpace(anonymousLine.Text())
+							if anonymous != "" && !names[anonymous] {
+								ErrorBoxAction(dlg, "The anonymous role "+anonymous+" not exist!")
+								return
+							}
+
+							defaultRole := strings.TrimSpace(defaultLine.Text())
+							if defaultRole != "" && !names[defaultRole] {
+								ErrorBoxAction(dlg, "The default role "+defaultRole+" not exist!")
+								return
+							}
+
+							userRoles := make(map[string]string)
+							for i, line := range strings.Split(usersEdit.Text(), "\n") {
+								line = strings.TrimSpace(line)
+								if line == "" {
+									continue
+								}
+								parts := strings.SplitN(line, "=", 2)
+								if len(parts) != 2 {
+									ErrorBoxAction(dlg, fmt.Sprintf("User roles line %d: the format is username = role", i+1))
+									return
+								}
+								role := strings.TrimSpace(parts[1])
+								if role != "" && !names[role] {
+									ErrorBoxAction(dlg, "The role "+role+" not exist!")
+									return
+								}
+								userRoles[strings.TrimSpace(parts[0])] = role
+							}
+
+							for i, user := range config.Security.Users {
+								config.Security.Users[i].Role = userRoles[user.UserName]
+							}
+							config.Security.AnonymousRole = anonymous
+							config.Security.DefaultRole = defaultRole
+							config.Roles = roles
+
+							dlg.Accept()
+							logs.Info("server role dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("server role dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("ServerRoleDialog: %s", err.Error())
+	}
+}
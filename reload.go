@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/astaxie/beego/logs"
+)
+
+var ErrReloadRestart = errors.New("the change needs a restart of the service")
+
+func reloadEqual(a, b interface{}) bool {
+	x, err1 := json.Marshal(a)
+	y, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(x) == string(y)
+}
+
+// ReloadRestart returns the settings whose change needs a restart, the empty result is reloadable
+func ReloadRestart(old, config Config) string {
+	if !reloadEqual(old.Datastore, config.Datastore) {
+		return "datastore"
+	}
+	if !reloadEqual(old.Alarm, config.Alarm) {
+		return "alarm publish"
+	}
+
+	// the node and the method mappings are reloaded by client
+	oldServer, server := old.Server, config.Server
+	oldServer.NodeList, server.NodeList = nil, nil
+	oldServer.Methods, server.Methods = nil, nil
+	if !reloadEqual(oldServer, server) {
+		return "server"
+	}
+	return ""
+}
+
+func reloadServerNodes(config Config, name string) ([]ServerNodeInfo, []ServerMethodInfo) {
+	nodes := make([]ServerNodeInfo, 0)
+	for _, node := range config.Server.NodeList {
+		if node.ClientName == name {
+			nodes = append(nodes, node)
+		}
+	}
+	methods := make([]ServerMethodInfo, 0)
+	for _, method := range config.Server.Methods {
+		if method.ClientName == name {
+			methods = append(methods, method)
+		}
+	}
+	return nodes, methods
+}
+
+// ReloadClients returns the names of the clients which are added, removed or changed
+func ReloadClients(old, config Config) []string {
+	names := make([]string, 0)
+	for _, name := range append(old.ClientNames(), config.ClientNames(old.ClientNames()...)...) {
+		before, after := old.ClientConfig(name), config.ClientConfig(name)
+		if !before.Enable && !after.Enable {
+			continue
+		}
+
+		oldNodes, oldMethods := reloadServerNodes(old, name)
+		nodes, methods := reloadServerNodes(config, name)
+		if reloadEqual(before, after) && reloadEqual(oldNodes, nodes) && reloadEqual(oldMethods, methods) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// ReloadRetry adds the clients whose last reload failed, their config is kept but they are not running
+func ReloadRetry(names []string, failed map[string]bool) []string {
+	output := append([]string{}, names...)
+	for name := range failed {
+		retry := true
+		for _, item := range names {
+			if item == name {
+				retry = false
+				break
+			}
+		}
+		if retry {
+			output = append(output, name)
+		}
+	}
+	return output
+}
+
+// Reload applies the config to the running service by client, the other clients keep running
+func (opc *OpcuaServer) Reload(config Config) error {
+	opc.reload.Lock()
+	defer opc.reload.Unlock()
+
+	config = config.Clone()
+	if part := ReloadRestart(opc.cfg, config); part != "" {
+		return fmt.Errorf("%w, the %s settings changed", ErrReloadRestart, part)
+	}
+
+	names := ReloadRetry(ReloadClients(opc.cfg, config), opc.reloadFailed)
+	opc.reloadFailed = make(map[string]bool)
+
+	opc.Lock()
+	opc.cfg = config
+	opc.Unlock()
+
+	failed := make([]string, 0)
+	for _, name := range names {
+		opc.clientStop(name)
+
+		cfg := config.ClientConfig(name)
+		opc.alarms.Reset(cfg)
+		if !cfg.Enable {
+			logs.Info("opcua client %s removed by reload", name)
+			continue
+		}
+
+		err := opc.clientReload(cfg)
+		if err != nil {
+			logs.Error("opcua client %s reload failed, %s", name, err.Error())
+			opc.clientStop(name)
+			opc.reloadFailed[name] = true
+			failed = append(failed, fmt.Sprintf("%s: %s", name, err.Error()))
+			continue
+		}
+		logs.Info("opcua client %s reload success", name)
+	}
+
+	if opc.db != nil && len(names) > 0 {
+		err := opc.commonDataInit(opc.db)
+		if err != nil {
+			failed = append(failed, err.Error())
+		}
+		err = opc.db.TableExpired(true)
+		if err != nil {
+			logs.Warning("table expired enable failed, %s", err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("reload failed, %s", strings.Join(failed, "; "))
+	}
+	logs.Info("opcua server reload %d clients success", len(names))
+	return nil
+}
+
+// clientReload initializes the table and the proxy nodes of the client and starts its worker
+func (opc *OpcuaServer) clientReload(cfg ClientConfig) error {
+	if opc.db != nil {
+		err := opc.clientDataInit(opc.db, cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	cli, err := NewClient(cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	opc.Lock()
+	opc.clients[cfg.Name] = cli
+	opc.Unlock()
+
+	if opc.server != nil {
+		// the proxy nodes are added in the server loop, the source reads of the client pause it
+		opc.server.Do(func() {
+			err = opc.serverInit(cli, cfg.Name)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	opc.clientStart(cli, cfg.Name)
+	return nil
+}
+
+// clientStop stops the worker of the client and removes its proxy nodes
+func (opc *OpcuaServer) clientStop(name string) {
+	opc.workers.Stop(name)
+
+	opc.Lock()
+	cli, ok := opc.clients[name]
+	delete(opc.clients, name)
+	for key := range opc.serverCache {
+		if strings.HasPrefix(key, historyKey(name, "")) {
+			delete(opc.serverCache, key)
+		}
+	}
+	for key := range opc.methodCache {
+		if strings.HasPrefix(key, historyKey(name, "")) {
+			delete(opc.methodCache, key)
+		}
+	}
+	delete(opc.alarmCache, name)
+	delete(opc.eventCache, name)
+	opc.Unlock()
+
+	if opc.history != nil {
+		opc.history.Remove(name)
+	}
+
+	if opc.server != nil {
+		var err error
+		opc.server.Do(func() {
+			err = opc.server.DeleteNameSpace(name)
+		})
+		if err != nil {
+			logs.Warning("opcua server delete client %s nodes failed, %s", name, err.Error())
+		}
+	}
+
+	if ok {
+		cli.Close()
+	}
+}
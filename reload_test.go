@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func reloadTestConfig() Config {
+	node := NodeInfo{NsIndex: 2, NodeID: "temp"}
+	return Config{
+		Clients: []ClientConfig{
+			{Enable: true, Name: "plc1", Endpoint: "opc.tcp://plc1:4840", NodeList: []NodeInfo{node}},
+			{Enable: true, Name: "plc2", Endpoint: "opc.tcp://plc2:4840", NodeList: []NodeInfo{node}},
+			{Enable: false, Name: "plc3", Endpoint: "opc.tcp://plc3:4840"},
+		},
+		Server: ServerConfig{
+			Enable: true,
+			Port:   4840,
+			NodeList: []ServerNodeInfo{
+				{ClientName: "plc1", ClientNode: node, ServerName: "plc1.temp"},
+				{ClientName: "plc2", ClientNode: node, ServerName: "plc2.temp"},
+			},
+		},
+		Datastore: DataStoreConfig{Enable: true, Address: "127.0.0.1", Port: 3306},
+	}
+}
+
+func TestReloadClients(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(c *Config)
+		names  []string
+	}{
+		{"unchanged", func(c *Config) {}, []string{}},
+		{"client changed", func(c *Config) { c.Clients[1].Timeout = 10 }, []string{"plc2"}},
+		{"client added", func(c *Config) {
+			c.Clients = append(c.Clients, ClientConfig{Enable: true, Name: "plc4"})
+		}, []string{"plc4"}},
+		{"disabled client added", func(c *Config) {
+			c.Clients = append(c.Clients, ClientConfig{Enable: false, Name: "plc4"})
+		}, []string{}},
+		{"client removed", func(c *Config) { c.Clients = c.Clients[1:] }, []string{"plc1"}},
+		{"client disabled", func(c *Config) { c.Clients[0].Enable = false }, []string{"plc1"}},
+		{"client enabled", func(c *Config) { c.Clients[2].Enable = true }, []string{"plc3"}},
+		{"disabled client changed", func(c *Config) { c.Clients[2].Timeout = 10 }, []string{}},
+		{"server node changed", func(c *Config) { c.Server.NodeList[0].ServerName = "plc1.t1" }, []string{"plc1"}},
+		{"server method added", func(c *Config) {
+			c.Server.Methods = append(c.Server.Methods, ServerMethodInfo{ClientName: "plc2", ServerName: "plc2.reset"})
+		}, []string{"plc2"}},
+	}
+	for _, c := range cases {
+		old, config := reloadTestConfig(), reloadTestConfig()
+		c.change(&config)
+		names := ReloadClients(old, config)
+		if !reflect.DeepEqual(names, c.names) {
+			t.Errorf("%s: clients %v, want %v", c.name, names, c.names)
+		}
+	}
+}
+
+func TestReloadRestart(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(c *Config)
+		part   string
+	}{
+		{"unchanged", func(c *Config) {}, ""},
+		{"client changed", func(c *Config) { c.Clients[0].Timeout = 10 }, ""},
+		{"server node changed", func(c *Config) { c.Server.NodeList = c.Server.NodeList[1:] }, ""},
+		{"server method added", func(c *Config) {
+			c.Server.Methods = append(c.Server.Methods, ServerMethodInfo{ClientName: "plc1"})
+		}, ""},
+		{"datastore changed", func(c *Config) { c.Datastore.Port = 3307 }, "datastore"},
+		{"alarm publish changed", func(c *Config) { c.Alarm.Logfile = true }, "alarm publish"},
+		{"server port changed", func(c *Config) { c.Server.Port = 4841 }, "server"},
+		{"server history changed", func(c *Config) { c.Server.History = true }, "server"},
+	}
+	for _, c := range cases {
+		old, config := reloadTestConfig(), reloadTestConfig()
+		c.change(&config)
+		part := ReloadRestart(old, config)
+		if part != c.part {
+			t.Errorf("%s: restart %q, want %q", c.name, part, c.part)
+		}
+	}
+}
+
+func TestReloadRetry(t *testing.T) {
+	names := ReloadRetry([]string{"plc1"}, map[string]bool{"plc1": true, "plc2": true})
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"plc1", "plc2"}) {
+		t.Fatalf("retry clients %v, want [plc1 plc2]", names)
+	}
+
+	names = ReloadRetry([]string{"plc1"}, map[string]bool{})
+	if !reflect.DeepEqual(names, []string{"plc1"}) {
+		t.Fatalf("retry clients %v, want [plc1]", names)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/astaxie/beego/logs"
+)
+
+// the secret field values:
+// enc:<base64>      the value sealed by the secret key
+// ${NAME}           the value of the environment variable
+// ${file:<path>}    the trimmed content of the file
+// others            the plain text, it is sealed by the next save
+const (
+	SECRET_SEALED = "enc:"
+	SECRET_FILE   = "file:"
+	SECRET_MASK   = "******"
+)
+
+// the key file path of the environment overrides the machine bound key
+const SECRET_KEY_ENV = "OPCUA_GATEWAY_KEY_FILE"
+
+const CRYPTPROTECT_LOCAL_MACHINE = 0x4
+
+var (
+	secretKey     []byte
+	secretKeyLock sync.Mutex
+
+	secretValues     = make(map[string]bool)
+	secretValuesLock sync.RWMutex
+
+	// the sealed values by the plain text, the same plain text is saved as the same sealed value
+	// so the unchanged config is not a new history version
+	secretSealed     = make(map[string]string)
+	secretSealedLock sync.Mutex
+)
+
+func secretKeyPath() string {
+	return filepath.Join(ConfigDirGet(), "secret.key")
+}
+
+type dataBlob struct {
+	size uint32
+	data *byte
+}
+
+func newDataBlob(body []byte) *dataBlob {
+	if len(body) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{size: uint32(len(body)), data: &body[0]}
+}
+
+func (b *dataBlob) bytes() []byte {
+	return append([]byte{}, unsafe.Slice(b.data, b.size)...)
+}
+
+var (
+	crypt32            = syscall.NewLazyDLL("crypt32.dll")
+	cryptProtectData   = crypt32.NewProc("CryptProtectData")
+	cryptUnprotectData = crypt32.NewProc("CryptUnprotectData")
+	localFree          = syscall.NewLazyDLL("kernel32.dll").NewProc("LocalFree")
+)
+
+// dpapi protects the body with the key of the local machine, the other machine can not unprotect it
+func dpapi(body []byte, protect bool) ([]byte, error) {
+	var out dataBlob
+	proc := cryptUnprotectData
+	if protect {
+		proc = cryptProtectData
+	}
+	ret, _, err := proc.Call(uintptr(unsafe.Pointer(newDataBlob(body))), 0, 0, 0, 0,
+		CRYPTPROTECT_LOCAL_MACHINE, uintptr(unsafe.Pointer(&out)))
+	if ret == 0 {
+		return nil, fmt.Errorf("%s failed, %s", proc.Name, err.Error())
+	}
+	defer localFree.Call(uintptr(unsafe.Pointer(out.data)))
+	return out.bytes(), nil
+}
+
+// secretKeyGet returns the key of the environment key file, or the machine bound key which
+// is created at the first seal
+func secretKeyGet(create bool) ([]byte, error) {
+	secretKeyLock.Lock()
+	defer secretKeyLock.Unlock()
+
+	if secretKey != nil {
+		return secretKey, nil
+	}
+
+	if path := os.Getenv(SECRET_KEY_ENV); path != "" {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read secret key file %s failed, %s", path, err.Error())
+		}
+		if len(strings.TrimSpace(string(body))) == 0 {
+			return nil, fmt.Errorf("secret key file %s is empty", path)
+		}
+		key := sha256.Sum256([]byte(strings.TrimSpace(string(body))))
+		secretKey = key[:]
+		return secretKey, nil
+	}
+
+	path := secretKeyPath()
+	body, err := os.ReadFile(path)
+	if err == nil {
+		key, err := dpapi(body, false)
+		if err != nil {
+			return nil, fmt.Errorf("secret key %s is not bound to this machine, %s", path, err.Error())
+		}
+		secretKey = key
+		return secretKey, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, fmt.Errorf("read secret key %s failed, %s", path, err.Error())
+	}
+
+	key := make([]byte, 32)
+	if _, err = rand.Read(key); err != nil {
+		return nil, err
+	}
+	body, err = dpapi(key, true)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(path, body, 0600); err != nil {
+		return nil, fmt.Errorf("write secret key %s failed, %s", path, err.Error())
+	}
+	logs.Info("secret key %s created", path)
+
+	secretKey = key
+	return secretKey, nil
+}
+
+func secretCipher(create bool) (cipher.AEAD, error) {
+	key, err := secretKeyGet(create)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// secretReference returns the reference of the ${...} value
+func secretReference(value string) (string, bool) {
+	if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
+		return value[2 : len(value)-1], true
+	}
+	return "", false
+}
+
+// SecretSeal encrypts the plain text value, the sealed and the reference values are kept
+func SecretSeal(value string) (string, error) {
+	if value == "" || strings.HasPrefix(value, SECRET_SEALED) {
+		return value, nil
+	}
+	if _, ok := secretReference(value); ok {
+		return value, nil
+	}
+
+	secretSealedLock.Lock()
+	defer secretSealedLock.Unlock()
+
+	if sealed, ok := secretSealed[value]; ok {
+		return sealed, nil
+	}
+
+	aead, err := secretCipher(true)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	SecretRegister(value)
+
+	body := aead.Seal(nonce, nonce, []byte(value), nil)
+	sealed := SECRET_SEALED + base64.StdEncoding.EncodeToString(body)
+	secretSealed[value] = sealed
+	return sealed, nil
+}
+
+// SecretResolve returns the plain text of the sealed, the reference or the plain text value
+func SecretResolve(value string) (string, error) {
+	var plain string
+
+	if strings.HasPrefix(value, SECRET_SEALED) {
+		body, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, SECRET_SEALED))
+		if err != nil {
+			return "", fmt.Errorf("sealed secret is broken, %s", err.Error())
+		}
+		aead, err := secretCipher(false)
+		if err != nil {
+			return "", err
+		}
+		if len(body) < aead.NonceSize() {
+			return "", errors.New("sealed secret is broken")
+		}
+		text, err := aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], nil)
+		if err != nil {
+			return "", errors.New("sealed secret can not be opened by the secret key")
+		}
+		plain = string(text)
+	} else if name, ok := secretReference(value); ok {
+		if path, ok := strings.CutPrefix(name, SECRET_FILE); ok {
+			body, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("read secret file %s failed, %s", path, err.Error())
+			}
+			plain = strings.TrimSpace(string(body))
+		} else {
+			text, ok := os.LookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("secret environment variable %s is not set", name)
+			}
+			plain = text
+		}
+	} else {
+		plain = value
+	}
+
+	SecretRegister(plain)
+	return plain, nil
+}
+
+// SecretRedact masks the secret value, the reference is not secret and it is kept
+func SecretRedact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if _, ok := secretReference(value); ok {
+		return value
+	}
+	return SECRET_MASK
+}
+
+// SecretRegister adds the plain text to the values masked in the log lines
+func SecretRegister(plain string) {
+	if plain == "" {
+		return
+	}
+	secretValuesLock.Lock()
+	secretValues[plain] = true
+	secretValuesLock.Unlock()
+}
+
+// SecretMask replaces the registered secret values of the text
+func SecretMask(text string) string {
+	secretValuesLock.RLock()
+	defer secretValuesLock.RUnlock()
+
+	for plain := range secretValues {
+		text = strings.ReplaceAll(text, plain, SECRET_MASK)
+	}
+	return text
+}
+
+// secretFields returns the secret fields of the config by the json path
+func (c *Config) secretFields() map[string]*string {
+	fields := map[string]*string{
+		"datastore.password": &c.Datastore.PassWord,
+	}
+	for i := range c.Server.Security.Users {
+		fields[fmt.Sprintf("server.security.users[%d].password", i)] = &c.Server.Security.Users[i].PassWord
+	}
+	return fields
+}
+
+// SecretSeal encrypts the plain text secrets of the config before they are saved
+func (c *Config) SecretSeal() error {
+	for path, field := range c.secretFields() {
+		value, err := SecretSeal(*field)
+		if err != nil {
+			return fmt.Errorf("%s seal failed, %s", path, err.Error())
+		}
+		*field = value
+	}
+	return nil
+}
+
+// Redacted returns the copy of the config with the masked secrets for the logs and the exports
+func (c Config) Redacted() Config {
+	clone := c.Clone()
+	for _, field := range clone.secretFields() {
+		*field = SecretRedact(*field)
+	}
+	return clone
+}
+
+const LOG_ADAPTER_REDACT = "redact"
+
+// redactWriter is the file log adapter which masks the secret values of every line
+type redactWriter struct {
+	file *logs.BeeLogger
+}
+
+func (w *redactWriter) Init(config string) error {
+	w.file = logs.NewLogger()
+	return w.file.SetLogger(logs.AdapterFile, config)
+}
+
+func (w *redactWriter) WriteMsg(when time.Time, msg string, level int) error {
+	_, err := w.file.Write([]byte(SecretMask(msg)))
+	return err
+}
+
+func (w *redactWriter) Destroy() {
+	w.file.Close()
+}
+
+func (w *redactWriter) Flush() {
+	w.file.Flush()
+}
+
+func init() {
+	logs.Register(LOG_ADAPTER_REDACT, func() logs.Logger {
+		return &redactWriter{}
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// secretTestKey uses the key file of the environment, the machine bound key is not touched
+func secretTestKey(t *testing.T, key string) {
+	path := filepath.Join(t.TempDir(), "secret.key")
+	if err := os.WriteFile(path, []byte(key), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(SECRET_KEY_ENV, path)
+
+	reset := func() {
+		secretKeyLock.Lock()
+		secretKey = nil
+		secretKeyLock.Unlock()
+		secretSealedLock.Lock()
+		secretSealed = make(map[string]string)
+		secretSealedLock.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestSecretSealResolve(t *testing.T) {
+	secretTestKey(t, "test key")
+
+	sealed, err := SecretSeal("p@ss, word")
+	if err != nil {
+		t.Fatalf("seal failed, %s", err.Error())
+	}
+	if !strings.HasPrefix(sealed, SECRET_SEALED) || strings.Contains(sealed, "word") {
+		t.Fatalf("sealed value %s is not sealed", sealed)
+	}
+	if again, _ := SecretSeal("p@ss, word"); again != sealed {
+		t.Fatalf("the same secret is sealed as %s and %s", sealed, again)
+	}
+	if again, _ := SecretSeal(sealed); again != sealed {
+		t.Fatalf("the sealed secret is sealed again")
+	}
+
+	plain, err := SecretResolve(sealed)
+	if err != nil || plain != "p@ss, word" {
+		t.Fatalf("resolve %s: %q %v", sealed, plain, err)
+	}
+
+	// the other key can not open the sealed value
+	secretTestKey(t, "other key")
+	if _, err = SecretResolve(sealed); err == nil {
+		t.Fatalf("sealed secret is opened by the other key")
+	}
+	if _, err = SecretResolve(SECRET_SEALED + "!!"); err == nil {
+		t.Fatalf("broken sealed secret is resolved")
+	}
+}
+
+func TestSecretReference(t *testing.T) {
+	secretTestKey(t, "test key")
+
+	t.Setenv("OPCUA_GATEWAY_TEST_SECRET", "from env")
+	path := filepath.Join(t.TempDir(), "password.txt")
+	if err := os.WriteFile(path, []byte(" from file\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"${OPCUA_GATEWAY_TEST_SECRET}": "from env",
+		"${file:" + path + "}":         "from file",
+		"plain":                        "plain",
+		"":                             "",
+	}
+	for value, want := range cases {
+		sealed, err := SecretSeal(value)
+		if err != nil || (value != "plain" && sealed != value) {
+			t.Errorf("seal %q: %q %v", value, sealed, err)
+		}
+		plain, err := SecretResolve(sealed)
+		if err != nil || plain != want {
+			t.Errorf("resolve %q: %q %v, want %q", sealed, plain, err, want)
+		}
+	}
+
+	if _, err := SecretResolve("${OPCUA_GATEWAY_TEST_MISSING}"); err == nil {
+		t.Errorf("missing environment variable is resolved")
+	}
+	if _, err := SecretResolve("${file:" + path + ".missing}"); err == nil {
+		t.Errorf("missing file is resolved")
+	}
+}
+
+func TestSecretMask(t *testing.T) {
+	SecretRegister("abc")
+	SecretRegister("")
+	if text := SecretMask("user root password abc"); text != "user root password "+SECRET_MASK {
+		t.Fatalf("mask: %q", text)
+	}
+	if SecretRedact("${OPCUA_GATEWAY_TEST_SECRET}") != "${OPCUA_GATEWAY_TEST_SECRET}" || SecretRedact("abc") != SECRET_MASK {
+		t.Fatalf("redact keeps the secret or masks the reference")
+	}
+}
+
+func TestConfigSecretSeal(t *testing.T) {
+	secretTestKey(t, "test key")
+
+	config := Config{Datastore: DataStoreConfig{PassWord: "db secret"}}
+	config.Server.Security.Users = []ServerUser{{UserName: "root", PassWord: "${OPCUA_GATEWAY_TEST_SECRET}"}}
+
+	sealed := config.Clone()
+	if err := sealed.SecretSeal(); err != nil {
+		t.Fatalf("seal failed, %s", err.Error())
+	}
+	if config.Datastore.PassWord != "db secret" {
+		t.Fatalf("the sealed copy changes the config")
+	}
+	if !strings.HasPrefix(sealed.Datastore.PassWord, SECRET_SEALED) {
+		t.Fatalf("datastore password %s is not sealed", sealed.Datastore.PassWord)
+	}
+	if sealed.Server.Security.Users[0].PassWord != "${OPCUA_GATEWAY_TEST_SECRET}" {
+		t.Fatalf("user password reference is changed to %s", sealed.Server.Security.Users[0].PassWord)
+	}
+	if plain, err := SecretResolve(sealed.Datastore.PassWord); err != nil || plain != "db secret" {
+		t.Fatalf("resolve: %q %v", plain, err)
+	}
+
+	redacted := sealed.Redacted()
+	if redacted.Datastore.PassWord != SECRET_MASK || redacted.Server.Security.Users[0].PassWord != "${OPCUA_GATEWAY_TEST_SECRET}" {
+		t.Fatalf("redacted: %+v", redacted.Datastore)
+	}
+}
@@ -0,0 +1,534 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+const SERVER_APPLICATION_URI = "urn:open62541.server.application"
+
+var securityPolicyNames = []string{"None", "Basic128Rsa15", "Basic256", "Basic256Sha256", "Aes128_Sha256_RsaOaep"}
+
+var securityPolicyMasks = []uint32{
+	SECURITY_POLICY_NONE,
+	SECURITY_POLICY_BASIC128RSA15,
+	SECURITY_POLICY_BASIC256,
+	SECURITY_POLICY_BASIC256SHA256,
+	SECURITY_POLICY_AES128SHA256RSAOAEP,
+}
+
+func SecurityPolicyNames() []string {
+	return securityPolicyNames
+}
+
+func SecurityPolicyMask(name string) (uint32, error) {
+	for i, item := range securityPolicyNames {
+		if strings.EqualFold(item, name) {
+			return securityPolicyMasks[i], nil
+		}
+	}
+	return 0, fmt.Errorf("security policy %s not support", name)
+}
+
+func (c *SecurityConfig) Default() bool {
+	if len(c.Users) > 0 || c.DisableAnonymous {
+		return false
+	}
+	for _, policy := range c.Policies {
+		if !strings.EqualFold(policy, "None") {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *SecurityConfig) Enable(policy string) bool {
+	for _, item := range c.Policies {
+		if strings.EqualFold(item, policy) {
+			return true
+		}
+	}
+	return false
+}
+
+func certificateDER(body []byte, kind string) []byte {
+	for {
+		block, rest := pem.Decode(body)
+		if block == nil {
+			return body
+		}
+		if strings.Contains(block.Type, kind) {
+			return block.Bytes
+		}
+		body = rest
+	}
+}
+
+func certificateFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files := make([]string, 0)
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if !entry.IsDir() && (ext == ".der" || ext == ".pem" || ext == ".crt" || ext == ".cer") {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// Load reads the certificate files of the config, returns nil if the server keeps the minimal config
+func (c *SecurityConfig) Load() (*ServerSecurity, error) {
+	if c.Default() {
+		return nil, nil
+	}
+
+	security := &ServerSecurity{
+		Sign:           c.Sign,
+		SignAndEncrypt: c.SignAndEncrypt,
+		Anonymous:      !c.DisableAnonymous,
+		TrustList:      make([][]byte, 0),
+		Users:          make(map[string]string),
+	}
+
+	for _, policy := range c.Policies {
+		mask, err := SecurityPolicyMask(policy)
+		if err != nil {
+			return nil, err
+		}
+		security.Policies |= mask
+	}
+	if security.Policies == 0 {
+		security.Policies = SECURITY_POLICY_NONE
+	}
+
+	if security.Policies&^SECURITY_POLICY_NONE != 0 {
+		if !EncryptionSupported() {
+			return nil, errors.New("security policy Sign/SignAndEncrypt requires encryption support, which is not built into this open62541 library")
+		}
+		if !c.Sign && !c.SignAndEncrypt {
+			return nil, errors.New("security mode Sign or SignAndEncrypt must be selected")
+		}
+		if c.Certificate == "" || c.PrivateKey == "" {
+			return nil, errors.New("server certificate and private key are required by the security policy")
+		}
+	}
+
+	if c.Certificate != "" {
+		body, err := os.ReadFile(c.Certificate)
+		if err != nil {
+			return nil, fmt.Errorf("read server certificate failed, %s", err.Error())
+		}
+		security.Certificate = certificateDER(body, "CERTIFICATE")
+	}
+
+	if c.PrivateKey != "" {
+		body, err := os.ReadFile(c.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("read server private key failed, %s", err.Error())
+		}
+		security.PrivateKey = certificateDER(body, "PRIVATE KEY")
+	}
+
+	for _, path := range c.TrustList {
+		files, err := certificateFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf("read trust list %s failed, %s", path, err.Error())
+		}
+		for _, file := range files {
+			body, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("read trust certificate %s failed, %s", file, err.Error())
+			}
+			security.TrustList = append(security.TrustList, certificateDER(body, "CERTIFICATE"))
+		}
+	}
+
+	for _, user := range c.Users {
+		if user.UserName == "" {
+			return nil, errors.New("server user name cannot be empty")
+		}
+		if _, ok := security.Users[user.UserName]; ok {
+			return nil, fmt.Errorf("server user %s already exist", user.UserName)
+		}
+		password, err := SecretResolve(user.PassWord)
+		if err != nil {
+			return nil, fmt.Errorf("server user %s password, %s", user.UserName, err.Error())
+		}
+		security.Users[user.UserName] = password
+	}
+
+	// the user token policy None sends the password in clear text
+	if len(security.Users) > 0 && !EncryptionSupported() {
+		return nil, errors.New("server users require encryption support, the password of the security policy None is sent in clear text")
+	}
+
+	if !security.Anonymous && len(security.Users) == 0 {
+		return nil, errors.New("anonymous access is disabled but no user is configured")
+	}
+
+	logs.Info("opcua server security policies 0x%x, trust %d certificates, %d users, anonymous %v",
+		security.Policies, len(security.TrustList), len(security.Users), security.Anonymous)
+
+	return security, nil
+}
+
+// Endpoints returns the endpoint list of the config before the server startup
+func (c *SecurityConfig) Endpoints(endpoint string, port int) []EndpointInfo {
+	address := fmt.Sprintf("opc.tcp://%s:%d", endpoint, port)
+
+	tokens := make([]string, 0)
+	if !c.DisableAnonymous {
+		tokens = append(tokens, "Anonymous")
+	}
+	if len(c.Users) > 0 {
+		tokens = append(tokens, "UserName")
+	}
+
+	output := make([]EndpointInfo, 0)
+	if len(c.Policies) == 0 || c.Enable("None") {
+		output = append(output, EndpointInfo{Url: address, Policy: "None", Mode: "None", UserTokens: tokens})
+	}
+	for _, policy := range securityPolicyNames[1:] {
+		if !c.Enable(policy) {
+			continue
+		}
+		if c.Sign {
+			output = append(output, EndpointInfo{Url: address, Policy: policy, Mode: "Sign", UserTokens: tokens})
+		}
+		if c.SignAndEncrypt {
+			output = append(output, EndpointInfo{Url: address, Policy: policy, Mode: "SignAndEncrypt", UserTokens: tokens})
+		}
+	}
+	return output
+}
+
+func EndpointInfoStrings(endpoints []EndpointInfo) []string {
+	output := make([]string, 0)
+	for _, item := range endpoints {
+		output = append(output, fmt.Sprintf("%s  [%s, %s]  %s",
+			item.Url, item.Policy, item.Mode, strings.Join(item.UserTokens, "/")))
+	}
+	return output
+}
+
+// CertificateCreate generates a self-signed server certificate (DER) and RSA private key (PEM)
+func CertificateCreate(dir string, name string, hosts []string) (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+
+	uri, err := url.Parse(SERVER_APPLICATION_URI)
+	if err != nil {
+		return "", "", err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   name,
+			Organization: []string{"OpcuaGatewayWindows"},
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment | x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		URIs:                  []*url.URL{uri},
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			if !ip.IsUnspecified() {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			}
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	certPath := filepath.Join(dir, name+"_cert.der")
+	keyPath := filepath.Join(dir, name+"_key.pem")
+
+	err = os.WriteFile(certPath, der, 0664)
+	if err != nil {
+		return "", "", err
+	}
+
+	keyBody := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	err = os.WriteFile(keyPath, keyBody, 0600)
+	if err != nil {
+		return "", "", err
+	}
+
+	logs.Info("create self-signed certificate %s and private key %s success", certPath, keyPath)
+	return certPath, keyPath, nil
+}
+
+func usersToText(users []ServerUser) string {
+	lines := make([]string, 0)
+	for _, user := range users {
+		lines = append(lines, user.UserName+":"+user.PassWord)
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+func usersFromText(text string) ([]ServerUser, error) {
+	users := make([]ServerUser, 0)
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("line %d: the format is username:password", i+1)
+		}
+		users = append(users, ServerUser{UserName: parts[0], PassWord: parts[1]})
+	}
+	return users, nil
+}
+
+func linesFromText(text string) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func ServerSecurityDialog(from walk.Form, config *ServerConfig) {
+	var dlg *walk.Dialog
+	var policyCB [5]*walk.CheckBox
+	var signCB, encryptCB, anonymousCB *walk.CheckBox
+	var certLine, keyLine *walk.LineEdit
+	var trustEdit, usersEdit *walk.TextEdit
+	var acceptPB, cancelPB *walk.PushButton
+
+	security := config.Security
+
+	policyWidgets := []Widget{
+		Label{
+			Text: "Policies:",
+		},
+	}
+	for i, name := range securityPolicyNames {
+		policyWidgets = append(policyWidgets, CheckBox{
+			AssignTo: &policyCB[i],
+			Text:     name,
+			Checked:  security.Enable(name) || (i == 0 && len(security.Policies) == 0),
+		})
+	}
+
+	supported := "This build supports security policy None only, Sign, SignAndEncrypt and users require an open62541 library with encryption."
+	if EncryptionSupported() {
+		supported = "Encryption is supported by this build."
+	}
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         "Server Security Settings",
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 600, Height: 450},
+		Size:          Size{Width: 600, Height: 450},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Label{
+				Text: supported,
+			},
+			Composite{
+				Layout:   HBox{MarginsZero: true},
+				Children: policyWidgets,
+			},
+			Composite{
+				Layout: HBox{MarginsZero: true},
+				Children: []Widget{
+					Label{
+						Text: "Modes:",
+					},
+					CheckBox{
+						AssignTo: &signCB,
+						Text:     "Sign",
+						Checked:  security.Sign,
+					},
+					CheckBox{
+						AssignTo: &encryptCB,
+						Text:     "SignAndEncrypt",
+						Checked:  security.SignAndEncrypt,
+					},
+					HSpacer{},
+					CheckBox{
+						AssignTo: &anonymousCB,
+						Text:     "Allow Anonymous",
+						Checked:  !security.DisableAnonymous,
+					},
+				},
+			},
+			Composite{
+				Layout: Grid{Columns: 3, MarginsZero: true},
+				Children: []Widget{
+					Label{
+						Text: "Certificate:",
+					},
+					LineEdit{
+						AssignTo: &certLine,
+						Text:     security.Certificate,
+					},
+					PushButton{
+						Text: "Generate Self-Signed",
+						OnClicked: func() {
+							hosts := []string{config.Endpoint}
+							if hostname, err := os.Hostname(); err == nil {
+								hosts = append(hosts, hostname)
+							}
+							certPath, keyPath, err := CertificateCreate(CertDirGet(), config.Name, hosts)
+							if err != nil {
+								ErrorBoxAction(dlg, "Generate certificate failed: "+err.Error())
+								return
+							}
+							certLine.SetText(certPath)
+							keyLine.SetText(keyPath)
+						},
+					},
+					Label{
+						Text: "Private Key:",
+					},
+					LineEdit{
+						AssignTo:   &keyLine,
+						Text:       security.PrivateKey,
+						ColumnSpan: 2,
+					},
+				},
+			},
+			Label{
+				Text: "Trust List (certificate file or directory, one per line):",
+			},
+			TextEdit{
+				AssignTo: &trustEdit,
+				Text:     strings.Join(security.TrustList, "\r\n"),
+				VScroll:  true,
+			},
+			Label{
+				Text: "Users (username:password, one per line):",
+			},
+			TextEdit{
+				AssignTo: &usersEdit,
+				Text:     usersToText(security.Users),
+				VScroll:  true,
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							users, err := usersFromText(usersEdit.Text())
+							if err != nil {
+								ErrorBoxAction(dlg, "Users is invalid, "+err.Error())
+								return
+							}
+
+							roles := make(map[string]string)
+							for _, user := range config.Security.Users {
+								roles[user.UserName] = user.Role
+							}
+							for i := range users {
+								users[i].Role = roles[users[i].UserName]
+							}
+
+							output := SecurityConfig{
+								Policies:         make([]string, 0),
+								Sign:             signCB.Checked(),
+								SignAndEncrypt:   encryptCB.Checked(),
+								Certificate:      certLine.Text(),
+								PrivateKey:       keyLine.Text(),
+								TrustList:        linesFromText(trustEdit.Text()),
+								DisableAnonymous: !anonymousCB.Checked(),
+								AnonymousRole:    config.Security.AnonymousRole,
+								DefaultRole:      config.Security.DefaultRole,
+								Users:            users,
+							}
+							for i, name := range securityPolicyNames {
+								if policyCB[i].Checked() {
+									output.Policies = append(output.Policies, name)
+								}
+							}
+							if len(output.Policies) == 0 {
+								ErrorBoxAction(dlg, "Please select at least one security policy!")
+								return
+							}
+
+							_, err = output.Load()
+							if err != nil {
+								ErrorBoxAction(dlg, "Security config is invalid, "+err.Error())
+								return
+							}
+
+							config.Security = output
+							dlg.Accept()
+							logs.Info("server security dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("server security dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("ServerSecurityDialog: %s", err.Error())
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"sort"
 	"sync"
 
@@ -99,6 +100,10 @@ type ServerNodeItem struct {
 	serverName string
 	serverNode NodeInfo
 
+	clientEndpoint string
+	metaOverride   bool
+	meta           NodeMeta
+
 	checked bool
 }
 
@@ -231,6 +236,10 @@ func serverNodeTableInit(server *ServerConfig) {
 			clientNode: node.ClientNode,
 			serverName: node.ServerName,
 			serverNode: node.ServerNode,
+
+			clientEndpoint: node.ClientEndpoint,
+			metaOverride:   node.MetaOverride,
+			meta:           node.Meta,
 		})
 	}
 
@@ -267,6 +276,7 @@ func ServerNodeTableUpdate(server *ServerConfig, node *ServerNodeItem) {
 	defer serverNodeTable.Unlock()
 
 	server.Update(node.serverName, node.serverNode)
+	server.UpdateMeta(node.serverName, node.metaOverride, node.meta)
 
 	serverNodeTableInit(server)
 }
@@ -300,13 +310,20 @@ func ServerNodeTableInit(server *ServerConfig) {
 }
 
 func ServerStartupTest(config ServerConfig) (*Server, error) {
-	server, err := NewServer(config.Endpoint, config.Port)
+	security, err := config.SecurityLoad()
+	if err != nil {
+		logs.Error("opcua server security load failed, %s", err.Error())
+		return nil, err
+	}
+
+	server, err := NewServer(config.Endpoint, config.Port, security)
 	if err != nil {
 		logs.Error("opcua server init failed, %s", err.Error())
 		return nil, err
 	}
 
 	clients := make(map[string]*Client)
+	builders := make(map[string]*HierarchyBuilder)
 
 	defer func() {
 		for _, client := range clients {
@@ -331,6 +348,15 @@ func ServerStartupTest(config ServerConfig) (*Server, error) {
 		}
 
 		cli := clients[node.ClientName]
+		if _, ok := builders[node.ClientName]; !ok && config.Hierarchy.Enable {
+			builder, err := NewHierarchyBuilder(server, cli, config.Hierarchy, NodeInfo{NsIndex: index, NodeID: node.ClientName})
+			if err != nil {
+				logs.Error("opcua server hierarchy %s init failed, %s", node.ClientName, err.Error())
+				return nil, err
+			}
+			builders[node.ClientName] = builder
+		}
+
 		value, err := cli.ReadNode(node.ClientNode)
 		if err != nil {
 			logs.Error("opcua client read node %s failed, %s", node.ClientNode.ToString(), err.Error())
@@ -340,7 +366,19 @@ func ServerStartupTest(config ServerConfig) (*Server, error) {
 		clientNode := NodeInfo{NsIndex: index, NodeID: node.ClientName}
 		serverNode := NodeInfo{NsIndex: index, NodeID: node.ServerName}
 
-		err = server.AddNode(clientNode, serverNode, node.ServerName, *value)
+		browseName := node.ServerName
+		if builder, ok := builders[node.ClientName]; ok {
+			clientNode, browseName, err = builder.Place(node)
+			if err != nil {
+				logs.Error("opcua server place node %s failed, %s", serverNode.ToString(), err.Error())
+				return nil, err
+			}
+			if browseName == "" {
+				continue
+			}
+		}
+
+		err = server.AddNodeMeta(clientNode, serverNode, browseName, *value, ServerNodeMeta(cli, node))
 		if err != nil {
 			logs.Error("opcua server add node %s failed, %s", serverNode.ToString(), err.Error())
 			return nil, err
@@ -354,21 +392,43 @@ func ServerStartupTest(config ServerConfig) (*Server, error) {
 
 func ServerNodeEditDialog(from walk.Form, config *ServerConfig, item *ServerNodeItem) {
 	var dlg *walk.Dialog
+	var displayLine, descLine, unitLine, trueLine, falseLine *walk.LineEdit
+	var typeBox *walk.ComboBox
+	var rangeLow, rangeHigh, unitID *walk.NumberEdit
+	var overrideCB, writeCB *walk.CheckBox
 	var acceptPB, cancelPB *walk.PushButton
 
+	meta := item.meta
+	if !item.metaOverride {
+		meta.AccessLevel = UA_ACCESSLEVEL_READ | UA_ACCESSLEVEL_WRITE
+	}
+
+	metaShow := func(m NodeMeta) {
+		typeBox.SetCurrentIndex(int(m.Type))
+		displayLine.SetText(m.DisplayName)
+		descLine.SetText(m.Description)
+		writeCB.SetChecked(m.AccessLevel&UA_ACCESSLEVEL_WRITE != 0)
+		rangeLow.SetValue(m.RangeLow)
+		rangeHigh.SetValue(m.RangeHigh)
+		unitLine.SetText(m.UnitName)
+		unitID.SetValue(float64(m.UnitID))
+		trueLine.SetText(m.TrueState)
+		falseLine.SetText(m.FalseState)
+	}
+
 	_, err := Dialog{
 		AssignTo:      &dlg,
 		Title:         "Edit Node Dialog",
 		Icon:          walk.IconInformation(),
-		MinSize:       Size{Width: 200, Height: 250},
-		Size:          Size{Width: 200, Height: 250},
+		MinSize:       Size{Width: 500, Height: 350},
+		Size:          Size{Width: 500, Height: 350},
 		Font:          DefaultFont(),
 		DefaultButton: &acceptPB,
 		CancelButton:  &cancelPB,
 		Layout:        VBox{},
 		Children: []Widget{
 			Composite{
-				Layout: Grid{Columns: 2},
+				Layout: Grid{Columns: 4},
 				Children: []Widget{
 					Label{
 						Text: "Node Name:",
@@ -377,6 +437,7 @@ func ServerNodeEditDialog(from walk.Form, config *ServerConfig, item *ServerNode
 						Text:        item.serverName,
 						ToolTipText: item.serverName,
 						Enabled:     false,
+						ColumnSpan:  3,
 					},
 					Label{
 						Text: "Node Tag:",
@@ -385,9 +446,118 @@ func ServerNodeEditDialog(from walk.Form, config *ServerConfig, item *ServerNode
 						Text:        item.serverNode.ToString(),
 						ToolTipText: item.serverNode.ToString(),
 						Enabled:     false,
+						ColumnSpan:  3,
+					},
+					CheckBox{
+						AssignTo:    &overrideCB,
+						Text:        "Override Source Metadata",
+						Checked:     item.metaOverride,
+						ColumnSpan:  3,
+						ToolTipText: "When unchecked, the metadata is read from the source node on startup",
+					},
+					PushButton{
+						Text: "Read Source",
+						OnClicked: func() {
+							cli, err := NewClient(item.clientEndpoint)
+							if err != nil {
+								ErrorBoxAction(dlg, "Connect source failed: "+err.Error())
+								return
+							}
+							defer cli.Close()
+
+							m, err := cli.ReadNodeMeta(item.clientNode)
+							if err != nil {
+								ErrorBoxAction(dlg, "Read source metadata failed: "+err.Error())
+								return
+							}
+							metaShow(*m)
+						},
+					},
+					Label{
+						Text: "Variable Type:",
+					},
+					ComboBox{
+						AssignTo:     &typeBox,
+						CurrentIndex: int(meta.Type),
+						Model:        VariableTypeNames(),
+					},
+					HSpacer{},
+					CheckBox{
+						AssignTo: &writeCB,
+						Text:     "Writable",
+						Checked:  meta.AccessLevel&UA_ACCESSLEVEL_WRITE != 0,
+					},
+					Label{
+						Text: "Display Name:",
+					},
+					LineEdit{
+						AssignTo:   &displayLine,
+						Text:       meta.DisplayName,
+						ColumnSpan: 3,
+					},
+					Label{
+						Text: "Description:",
+					},
+					LineEdit{
+						AssignTo:   &descLine,
+						Text:       meta.Description,
+						ColumnSpan: 3,
+					},
+					Label{
+						Text: "Range Low:",
+					},
+					NumberEdit{
+						AssignTo: &rangeLow,
+						Value:    meta.RangeLow,
+						Decimals: 3,
+						MinValue: -1e15,
+						MaxValue: 1e15,
+					},
+					Label{
+						Text: "Range High:",
+					},
+					NumberEdit{
+						AssignTo: &rangeHigh,
+						Value:    meta.RangeHigh,
+						Decimals: 3,
+						MinValue: -1e15,
+						MaxValue: 1e15,
+					},
+					Label{
+						Text: "Unit:",
+					},
+					LineEdit{
+						AssignTo:    &unitLine,
+						Text:        meta.UnitName,
+						ToolTipText: "Engineering unit display name, e.g. °C",
+					},
+					Label{
+						Text: "Unit ID:",
+					},
+					NumberEdit{
+						AssignTo:    &unitID,
+						Value:       float64(meta.UnitID),
+						ToolTipText: "UNECE unit code id",
+						MinValue:    -1,
+						MaxValue:    0x7fffffff,
+					},
+					Label{
+						Text: "True State:",
+					},
+					LineEdit{
+						AssignTo: &trueLine,
+						Text:     meta.TrueState,
+					},
+					Label{
+						Text: "False State:",
+					},
+					LineEdit{
+						AssignTo: &falseLine,
+						Text:     meta.FalseState,
 					},
 				},
 			},
+			VSpacer{},
 			Composite{
 				Layout: HBox{},
 				Children: []Widget{
@@ -398,6 +568,36 @@ func ServerNodeEditDialog(from walk.Form, config *ServerConfig, item *ServerNode
 						OnClicked: func() {
 							logs.Info("server node single edit dialog accept")
 
+							item.metaOverride = overrideCB.Checked()
+							if item.metaOverride {
+								m := NodeMeta{
+									Type:        VariableType(typeBox.CurrentIndex()),
+									DisplayName: displayLine.Text(),
+									Description: descLine.Text(),
+									AccessLevel: UA_ACCESSLEVEL_READ,
+									TrueState:   trueLine.Text(),
+									FalseState:  falseLine.Text(),
+								}
+								if writeCB.Checked() {
+									m.AccessLevel |= UA_ACCESSLEVEL_WRITE
+								}
+								if m.Type == VARIABLE_ANALOG {
+									m.RangeLow = rangeLow.Value()
+									m.RangeHigh = rangeHigh.Value()
+									if m.RangeLow > m.RangeHigh {
+										ErrorBoxAction(dlg, "The range low cannot be greater than range high!")
+										return
+									}
+									m.UnitName = unitLine.Text()
+									m.UnitID = int32(unitID.Value())
+									if m.UnitName != "" {
+										m.HasUnits = true
+										m.UnitNamespace = UA_UNITS_NAMESPACE
+									}
+								}
+								item.meta = m
+							}
+
 							ServerNodeTableUpdate(config, item)
 							dlg.Accept()
 						},
@@ -430,7 +630,8 @@ func ServerEditDialog(from walk.Form, config *Config) {
 	var listenBox, clientBox *walk.ComboBox
 	var fromNodeTableView *walk.TableView
 	var toNodeTableView *walk.TableView
-	var enableCB, fromCheckBox, serverCheckBox *walk.CheckBox
+	var enableCB, historyCB, fromCheckBox, serverCheckBox *walk.CheckBox
+	var endpointBox *walk.ListBox
 	var server *Server
 
 	defer func() {
@@ -447,6 +648,10 @@ func ServerEditDialog(from walk.Form, config *Config) {
 
 	FromNodeTableInit("", config.Clients)
 
+	EndpointPreview := func() {
+		endpointBox.SetModel(EndpointInfoStrings(serverConfig.Security.Endpoints(serverConfig.Endpoint, serverConfig.Port)))
+	}
+
 	InterfaceGet := func() int {
 		for i, addr := range interfaces {
 			if addr == serverConfig.Endpoint {
@@ -518,9 +723,49 @@ func ServerEditDialog(from walk.Form, config *Config) {
 							serverConfig.Enable = enableCB.Checked()
 						},
 					},
+					CheckBox{
+						AssignTo:    &historyCB,
+						Text:        "History",
+						Checked:     serverConfig.History,
+						ToolTipText: "Serve history read from the datastore tables",
+						OnCheckedChanged: func() {
+							serverConfig.History = historyCB.Checked()
+						},
+					},
 					HSpacer{
 						MinSize: Size{Width: 50},
 					},
+					PushButton{
+						Text: "Security",
+						OnClicked: func() {
+							ServerSecurityDialog(dlg, &serverConfig)
+							EndpointPreview()
+						},
+					},
+					PushButton{
+						Text: "Roles",
+						OnClicked: func() {
+							ServerRoleDialog(dlg, &serverConfig)
+						},
+					},
+					PushButton{
+						Text: "Methods",
+						OnClicked: func() {
+							ServerMethodDialog(dlg, config.Clients, &serverConfig)
+						},
+					},
+					PushButton{
+						Text: "Queue",
+						OnClicked: func() {
+							QueueEditDialog(dlg, "Server Queue", &serverConfig.Queue)
+						},
+					},
+					PushButton{
+						Text: "Hierarchy",
+						OnClicked: func() {
+							HierarchyEditDialog(dlg, &serverConfig.Hierarchy)
+						},
+					},
 					PushButton{
 						AssignTo: &startTest,
 						Text:     "Start Testing",
@@ -531,6 +776,7 @@ func ServerEditDialog(from walk.Form, config *Config) {
 								ErrorBoxAction(dlg, "Service startup failed! Reasons are as follows:"+err.Error())
 								return
 							}
+							endpointBox.SetModel(EndpointInfoStrings(server.Endpoints()))
 							startTest.SetEnabled(false)
 							stopTest.SetEnabled(true)
 						},
@@ -544,6 +790,7 @@ func ServerEditDialog(from walk.Form, config *Config) {
 								server.Close()
 								server = nil
 							}
+							EndpointPreview()
 							startTest.SetEnabled(true)
 							stopTest.SetEnabled(false)
 						},
@@ -686,12 +933,58 @@ func ServerEditDialog(from walk.Form, config *Config) {
 											ServerNodeTableClean(&serverConfig)
 										},
 									},
+									PushButton{
+										Text: "Import",
+										OnClicked: func() {
+											path, err := TableFileDialogOpen(dlg, "Import the server node mapping")
+											if err != nil || path == "" {
+												return
+											}
+											nodes, report, err := ServerNodeImport(path, config.Clients, serverConfig)
+											if err != nil {
+												ErrorBoxAction(dlg, "Import server nodes failed: "+err.Error())
+												return
+											}
+											serverConfig.Import(nodes)
+											ServerNodeTableInit(&serverConfig)
+											ImportReportDialog(dlg, report)
+										},
+									},
+									PushButton{
+										Text: "Export",
+										OnClicked: func() {
+											path, err := TableFileDialogSave(dlg, "Export the server node mapping")
+											if err != nil || path == "" {
+												return
+											}
+											err = ServerNodeExport(path, serverConfig.NodeList)
+											if err != nil {
+												ErrorBoxAction(dlg, "Export server nodes failed: "+err.Error())
+												return
+											}
+											InfoBoxAction(dlg, fmt.Sprintf("Export %d server nodes to %s", len(serverConfig.NodeList), path))
+										},
+									},
 								},
 							},
 						},
 					},
 				},
 			},
+			Composite{
+				Layout:     HBox{},
+				Background: SolidColorBrush{Color: walk.RGB(220, 220, 220)},
+				Children: []Widget{
+					Label{
+						Text: "Endpoints:",
+					},
+					ListBox{
+						AssignTo: &endpointBox,
+						MaxSize:  Size{Height: 60},
+						Model:    EndpointInfoStrings(serverConfig.Security.Endpoints(serverConfig.Endpoint, serverConfig.Port)),
+					},
+				},
+			},
 			Composite{
 				Layout: HBox{MarginsZero: true},
 				Children: []Widget{
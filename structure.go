@@ -0,0 +1,1284 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const (
+	STRUCT_MAX_DEPTH    = 16
+	STRUCT_MAX_ELEMENTS = 100
+)
+
+const (
+	STRUCTURE_PLAIN    uint32 = 0
+	STRUCTURE_OPTIONAL uint32 = 1
+	STRUCTURE_UNION    uint32 = 2
+)
+
+// builtin type ids of the namespace zero
+const (
+	BUILTIN_BOOLEAN         uint32 = 1
+	BUILTIN_SBYTE           uint32 = 2
+	BUILTIN_BYTE            uint32 = 3
+	BUILTIN_INT16           uint32 = 4
+	BUILTIN_UINT16          uint32 = 5
+	BUILTIN_INT32           uint32 = 6
+	BUILTIN_UINT32          uint32 = 7
+	BUILTIN_INT64           uint32 = 8
+	BUILTIN_UINT64          uint32 = 9
+	BUILTIN_FLOAT           uint32 = 10
+	BUILTIN_DOUBLE          uint32 = 11
+	BUILTIN_STRING          uint32 = 12
+	BUILTIN_DATETIME        uint32 = 13
+	BUILTIN_GUID            uint32 = 14
+	BUILTIN_BYTESTRING      uint32 = 15
+	BUILTIN_XMLELEMENT      uint32 = 16
+	BUILTIN_NODEID          uint32 = 17
+	BUILTIN_EXPANDEDNODEID  uint32 = 18
+	BUILTIN_STATUSCODE      uint32 = 19
+	BUILTIN_QUALIFIEDNAME   uint32 = 20
+	BUILTIN_LOCALIZEDTEXT   uint32 = 21
+	BUILTIN_EXTENSIONOBJECT uint32 = 22
+	BUILTIN_DATAVALUE       uint32 = 23
+	BUILTIN_VARIANT         uint32 = 24
+	BUILTIN_DIAGNOSTICINFO  uint32 = 25
+)
+
+// the abstract and simple types of the namespace zero, mapped to the builtin encoding
+var builtinAliases = map[uint32]uint32{
+	26:  BUILTIN_VARIANT, // Number
+	27:  BUILTIN_VARIANT, // Integer
+	28:  BUILTIN_VARIANT, // UInteger
+	29:  BUILTIN_INT32,   // Enumeration
+	256: BUILTIN_INT32,   // IdType
+	257: BUILTIN_INT32,   // NodeClass
+	288: BUILTIN_UINT32,  // IntegerId
+	289: BUILTIN_UINT32,  // Counter
+	290: BUILTIN_DOUBLE,  // Duration
+	291: BUILTIN_STRING,  // NumericRange
+	292: BUILTIN_STRING,  // Time
+	293: BUILTIN_DATETIME,
+	294: BUILTIN_DATETIME, // UtcTime
+	295: BUILTIN_STRING,   // LocaleId
+	311: BUILTIN_BYTESTRING,
+	851: BUILTIN_INT32, // RedundancySupport
+	852: BUILTIN_INT32, // ServerState
+}
+
+// the type names of the opc binary schema (DataTypeDictionary)
+var dictionaryBuiltins = map[string]uint32{
+	"Boolean":         BUILTIN_BOOLEAN,
+	"SByte":           BUILTIN_SBYTE,
+	"Byte":            BUILTIN_BYTE,
+	"Int16":           BUILTIN_INT16,
+	"UInt16":          BUILTIN_UINT16,
+	"Int32":           BUILTIN_INT32,
+	"UInt32":          BUILTIN_UINT32,
+	"Int64":           BUILTIN_INT64,
+	"UInt64":          BUILTIN_UINT64,
+	"Float":           BUILTIN_FLOAT,
+	"Double":          BUILTIN_DOUBLE,
+	"String":          BUILTIN_STRING,
+	"CharArray":       BUILTIN_STRING,
+	"DateTime":        BUILTIN_DATETIME,
+	"Guid":            BUILTIN_GUID,
+	"ByteString":      BUILTIN_BYTESTRING,
+	"XmlElement":      BUILTIN_XMLELEMENT,
+	"NodeId":          BUILTIN_NODEID,
+	"ExpandedNodeId":  BUILTIN_EXPANDEDNODEID,
+	"StatusCode":      BUILTIN_STATUSCODE,
+	"QualifiedName":   BUILTIN_QUALIFIEDNAME,
+	"LocalizedText":   BUILTIN_LOCALIZEDTEXT,
+	"ExtensionObject": BUILTIN_EXTENSIONOBJECT,
+	"DataValue":       BUILTIN_DATAVALUE,
+	"Variant":         BUILTIN_VARIANT,
+	"DiagnosticInfo":  BUILTIN_DIAGNOSTICINFO,
+}
+
+type StructField struct {
+	Name      string
+	DataType  string
+	ValueRank int32
+	Optional  bool
+	Bit       int
+}
+
+type StructDefinition struct {
+	Name     string
+	DataType string
+	Kind     uint32
+	MaskBits int
+	Fields   []StructField
+}
+
+// TypeRegistry caches the structure definitions read from the server, the
+// data types are keyed by the node id text, the dictionary types by dictionary#name
+type TypeRegistry struct {
+	definitions map[string]*StructDefinition
+	encodings   map[string]string
+	aliases     map[string]uint32
+	failed      map[string]error
+}
+
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		definitions: make(map[string]*StructDefinition),
+		encodings:   make(map[string]string),
+		aliases:     make(map[string]uint32),
+		failed:      make(map[string]error),
+	}
+}
+
+func builtinTypeID(dataType string) (uint32, bool) {
+	if !strings.HasPrefix(dataType, "i=") {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(dataType[2:], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	if id >= uint64(BUILTIN_BOOLEAN) && id <= uint64(BUILTIN_DIAGNOSTICINFO) {
+		return uint32(id), true
+	}
+	alias, ok := builtinAliases[uint32(id)]
+	return alias, ok
+}
+
+// resolveType returns the builtin encoding or the structure definition of the data type
+func (c *Client) resolveType(dataType string, depth int) (uint32, *StructDefinition, error) {
+	if id, ok := builtinTypeID(dataType); ok {
+		return id, nil, nil
+	}
+	if id, ok := c.types.aliases[dataType]; ok {
+		return id, nil, nil
+	}
+	if def, ok := c.types.definitions[dataType]; ok {
+		return 0, def, nil
+	}
+	if err, ok := c.types.failed[dataType]; ok {
+		return 0, nil, err
+	}
+	if depth > STRUCT_MAX_DEPTH {
+		return 0, nil, fmt.Errorf("data type %s nested too deep", dataType)
+	}
+
+	id, def, err := c.loadType(dataType, depth)
+	if err != nil {
+		logs.Warning("opcua client load data type %s failed, %s", dataType, err.Error())
+		c.types.failed[dataType] = err
+		return 0, nil, err
+	}
+	if def != nil {
+		c.types.definitions[dataType] = def
+	} else {
+		c.types.aliases[dataType] = id
+	}
+	return id, def, nil
+}
+
+func (c *Client) loadType(dataType string, depth int) (uint32, *StructDefinition, error) {
+	value, err := c.readAttribute(dataType, UA_ATTRIBUTEID_DATATYPEDEFINITION)
+	if err == nil && value.Type == UA_EXTENSIONOBJECT && !value.Array {
+		object := value.Value.(ExtensionObject)
+		switch object.Type {
+		case "StructureDefinition":
+			return 0, c.structDefinition(dataType, object.Value), nil
+		case "EnumDefinition":
+			return BUILTIN_INT32, nil, nil
+		}
+	}
+
+	// the simple types have no definition, use the encoding of the super type
+	parent, err := c.browseFirst(dataType, UA_REFERENCE_HASSUBTYPE, true)
+	if err != nil {
+		return 0, nil, fmt.Errorf("no definition and super type, %s", err.Error())
+	}
+	if parent == "i=22" {
+		return 0, nil, fmt.Errorf("structure has no DataTypeDefinition")
+	}
+	return c.resolveType(parent, depth+1)
+}
+
+func (c *Client) structDefinition(dataType string, value interface{}) *StructDefinition {
+	def := &StructDefinition{DataType: dataType, Name: dataType}
+
+	name, err := c.readAttribute(dataType, UA_ATTRIBUTEID_BROWSENAME)
+	if err == nil && name.Type == UA_QUALIFIEDNAME && !name.Array {
+		def.Name = name.Value.(QualifiedName).Name
+	}
+
+	fields, _ := value.(map[string]interface{})
+	if kind, ok := fields["StructureType"].(int32); ok {
+		def.Kind = uint32(kind)
+	}
+	// the subtyped values are encoded as the plain structure and union
+	switch def.Kind {
+	case 3:
+		def.Kind = STRUCTURE_PLAIN
+	case 4:
+		def.Kind = STRUCTURE_UNION
+	}
+
+	items, _ := fields["Fields"].([]interface{})
+	for _, item := range items {
+		member, _ := item.(map[string]interface{})
+		field := StructField{ValueRank: -1, Bit: -1}
+		field.Name, _ = member["Name"].(string)
+		field.DataType, _ = member["DataType"].(string)
+		if rank, ok := member["ValueRank"].(int32); ok {
+			field.ValueRank = rank
+		}
+		field.Optional, _ = member["IsOptional"].(bool)
+		if field.Optional && def.Kind == STRUCTURE_OPTIONAL {
+			field.Bit = def.MaskBits
+			def.MaskBits++
+		}
+		def.Fields = append(def.Fields, field)
+	}
+	if def.Kind == STRUCTURE_OPTIONAL {
+		def.MaskBits = 32
+	}
+	return def
+}
+
+// encodingType returns the data type key of the binary encoding node
+func (c *Client) encodingType(encodingID string) (string, error) {
+	if dataType, ok := c.types.encodings[encodingID]; ok {
+		return dataType, nil
+	}
+	if err, ok := c.types.failed[encodingID]; ok {
+		return "", err
+	}
+
+	dataType, err := c.browseFirst(encodingID, UA_REFERENCE_HASENCODING, true)
+	if err == nil {
+		_, _, err = c.resolveType(dataType, 0)
+	}
+	if err != nil {
+		// the servers without DataTypeDefinition describe the structures in the dictionary
+		var dictErr error
+		dataType, dictErr = c.dictionaryType(encodingID)
+		if dictErr != nil {
+			err = fmt.Errorf("%s, dictionary: %s", err.Error(), dictErr.Error())
+			logs.Warning("opcua client load encoding %s failed, %s", encodingID, err.Error())
+			c.types.failed[encodingID] = err
+			return "", err
+		}
+	}
+
+	c.types.encodings[encodingID] = dataType
+	return dataType, nil
+}
+
+type dictionaryField struct {
+	Name        string `xml:"Name,attr"`
+	TypeName    string `xml:"TypeName,attr"`
+	Length      int    `xml:"Length,attr"`
+	LengthField string `xml:"LengthField,attr"`
+	SwitchField string `xml:"SwitchField,attr"`
+	SwitchValue string `xml:"SwitchValue,attr"`
+}
+
+type dictionaryStruct struct {
+	Name   string            `xml:"Name,attr"`
+	Fields []dictionaryField `xml:"Field"`
+}
+
+type dictionaryEnum struct {
+	Name         string `xml:"Name,attr"`
+	LengthInBits int    `xml:"LengthInBits,attr"`
+}
+
+type dictionarySchema struct {
+	Structs []dictionaryStruct `xml:"StructuredType"`
+	Enums   []dictionaryEnum   `xml:"EnumeratedType"`
+}
+
+// dictionaryType loads the opc binary schema of the encoding, and registers all the types of the dictionary
+func (c *Client) dictionaryType(encodingID string) (string, error) {
+	description, err := c.browseFirst(encodingID, UA_REFERENCE_HASDESCRIPTION, false)
+	if err != nil {
+		return "", err
+	}
+	name, err := c.readAttribute(description, UA_ATTRIBUTEID_VALUE)
+	if err != nil {
+		return "", err
+	}
+	if name.Type != UA_STRING || name.Array {
+		return "", fmt.Errorf("description %s value is not string", description)
+	}
+	dictionary, err := c.browseFirst(description, UA_REFERENCE_HASCOMPONENT, true)
+	if err != nil {
+		return "", err
+	}
+
+	dataType := dictionary + "#" + name.Value.(string)
+	if _, ok := c.types.definitions[dataType]; ok {
+		return dataType, nil
+	}
+
+	body, err := c.readAttribute(dictionary, UA_ATTRIBUTEID_VALUE)
+	if err != nil {
+		return "", err
+	}
+	if body.Type != UA_BYTESTRING || body.Array {
+		return "", fmt.Errorf("dictionary %s value is not bytestring", dictionary)
+	}
+
+	var schema dictionarySchema
+	err = xml.Unmarshal(body.Value.([]byte), &schema)
+	if err != nil {
+		return "", fmt.Errorf("dictionary %s parse failed, %s", dictionary, err.Error())
+	}
+
+	for _, enum := range schema.Enums {
+		id := BUILTIN_INT32
+		switch enum.LengthInBits {
+		case 8:
+			id = BUILTIN_BYTE
+		case 16:
+			id = BUILTIN_UINT16
+		}
+		c.types.aliases[dictionary+"#"+enum.Name] = id
+	}
+	for _, item := range schema.Structs {
+		def, err := dictionaryDefinition(dictionary, item)
+		if err != nil {
+			logs.Warning("opcua client dictionary %s type %s skipped, %s", dictionary, item.Name, err.Error())
+			c.types.failed[dictionary+"#"+item.Name] = err
+			continue
+		}
+		c.types.definitions[def.DataType] = def
+	}
+
+	if _, ok := c.types.definitions[dataType]; !ok {
+		if err, ok := c.types.failed[dataType]; ok {
+			return "", err
+		}
+		return "", fmt.Errorf("type %s not found in dictionary %s", name.Value.(string), dictionary)
+	}
+	return dataType, nil
+}
+
+func dictionaryTypeName(dictionary, typeName string) string {
+	prefix, name := "", typeName
+	if index := strings.Index(typeName, ":"); index >= 0 {
+		prefix, name = typeName[:index], typeName[index+1:]
+	}
+	if prefix == "opc" || prefix == "ua" {
+		if id, ok := dictionaryBuiltins[name]; ok {
+			return fmt.Sprintf("i=%d", id)
+		}
+	}
+	return dictionary + "#" + name
+}
+
+func dictionaryDefinition(dictionary string, item dictionaryStruct) (*StructDefinition, error) {
+	def := &StructDefinition{Name: item.Name, DataType: dictionary + "#" + item.Name, Kind: STRUCTURE_PLAIN}
+
+	lengthFields := make(map[string]bool)
+	for _, field := range item.Fields {
+		if field.LengthField != "" {
+			lengthFields[field.LengthField] = true
+		}
+	}
+
+	bits := make(map[string]int)
+	for _, field := range item.Fields {
+		if field.TypeName == "opc:Bit" {
+			length := field.Length
+			if length == 0 {
+				length = 1
+			}
+			bits[field.Name] = def.MaskBits
+			def.MaskBits += length
+			continue
+		}
+		// the length field and the array are encoded as the standard array
+		if lengthFields[field.Name] {
+			continue
+		}
+		if field.SwitchValue != "" {
+			return nil, fmt.Errorf("union is not supported")
+		}
+
+		member := StructField{
+			Name:      field.Name,
+			DataType:  dictionaryTypeName(dictionary, field.TypeName),
+			ValueRank: -1,
+			Bit:       -1,
+		}
+		if field.LengthField != "" {
+			member.ValueRank = 1
+		}
+		if field.SwitchField != "" {
+			bit, ok := bits[field.SwitchField]
+			if !ok {
+				return nil, fmt.Errorf("switch field %s is not a bit", field.SwitchField)
+			}
+			member.Optional = true
+			member.Bit = bit
+			def.Kind = STRUCTURE_OPTIONAL
+		}
+		def.Fields = append(def.Fields, member)
+	}
+	if def.Kind == STRUCTURE_PLAIN && def.MaskBits > 0 {
+		return nil, fmt.Errorf("bit fields without switch are not supported")
+	}
+	return def, nil
+}
+
+// decodeExtension decodes the body of the extension object with the server definitions
+func (c *Client) decodeExtension(object *ExtensionObject) error {
+	if object.Value != nil || len(object.Body) == 0 {
+		return nil
+	}
+	dataType, err := c.encodingType(object.TypeID)
+	if err != nil {
+		return err
+	}
+	_, def, err := c.resolveType(dataType, 0)
+	if err != nil {
+		return err
+	}
+	if def == nil {
+		return fmt.Errorf("data type %s is not a structure", dataType)
+	}
+
+	decoder := &structDecoder{c: c, buf: object.Body}
+	value, err := decoder.structure(def, 0)
+	if err != nil {
+		return fmt.Errorf("decode %s failed, %s", def.Name, err.Error())
+	}
+	object.Type = def.Name
+	object.Value = value
+	return nil
+}
+
+// structValue decodes the extension objects of the value, and picks the field of the node if any
+func (c *Client) structValue(node NodeInfo, value *NodeValue) (*NodeValue, error) {
+	if value.Type == UA_EXTENSIONOBJECT {
+		if value.Array {
+			objects := value.Value.([]ExtensionObject)
+			for i := range objects {
+				if err := c.decodeExtension(&objects[i]); err != nil {
+					logs.Debug("opcua client decode %s failed, %s", node.ToString(), err.Error())
+				}
+			}
+		} else {
+			object := value.Value.(ExtensionObject)
+			if err := c.decodeExtension(&object); err != nil {
+				logs.Debug("opcua client decode %s failed, %s", node.ToString(), err.Error())
+			}
+			value.Value = object
+		}
+	}
+
+	if node.Field == "" {
+		return value, nil
+	}
+	field, err := StructFieldGet(value.Value, node.Field)
+	if err != nil {
+		return nil, fmt.Errorf("ua client read %s failed, %s", node.ToString(), err.Error())
+	}
+	return StructNodeValue(field)
+}
+
+type structDecoder struct {
+	c   *Client
+	buf []byte
+	pos int
+}
+
+func (d *structDecoder) read(length int) ([]byte, error) {
+	if length < 0 || d.pos+length > len(d.buf) {
+		return nil, fmt.Errorf("body is too short at %d", d.pos)
+	}
+	data := d.buf[d.pos : d.pos+length]
+	d.pos += length
+	return data, nil
+}
+
+func (d *structDecoder) uint8() (uint8, error) {
+	data, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+func (d *structDecoder) uint16() (uint16, error) {
+	data, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(data), nil
+}
+
+func (d *structDecoder) uint32() (uint32, error) {
+	data, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+func (d *structDecoder) uint64() (uint64, error) {
+	data, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
+
+func (d *structDecoder) bytes() ([]byte, error) {
+	length, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if int32(length) < 0 {
+		return nil, nil
+	}
+	data, err := d.read(int(length))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{}, data...), nil
+}
+
+func (d *structDecoder) string() (string, error) {
+	data, err := d.bytes()
+	return string(data), err
+}
+
+// length reads the array length, the null array has no element
+func (d *structDecoder) length() (int, error) {
+	length, err := d.uint32()
+	if err != nil {
+		return 0, err
+	}
+	if int32(length) < 0 {
+		return 0, nil
+	}
+	if int(length) > len(d.buf)-d.pos {
+		return 0, fmt.Errorf("array length %d is out of body", length)
+	}
+	return int(length), nil
+}
+
+func (d *structDecoder) guid() (string, error) {
+	data, err := d.read(16)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(data[0:4]), binary.LittleEndian.Uint16(data[4:6]),
+		binary.LittleEndian.Uint16(data[6:8]), data[8:10], data[10:16]), nil
+}
+
+func nodeIdText(ns uint16, identifier string) string {
+	if ns == 0 {
+		return identifier
+	}
+	return fmt.Sprintf("ns=%d;%s", ns, identifier)
+}
+
+func (d *structDecoder) nodeID() (string, uint8, error) {
+	encoding, err := d.uint8()
+	if err != nil {
+		return "", 0, err
+	}
+
+	var ns uint16
+	var identifier string
+	switch encoding & 0x3f {
+	case 0x00:
+		id, err := d.uint8()
+		if err != nil {
+			return "", 0, err
+		}
+		identifier = fmt.Sprintf("i=%d", id)
+	case 0x01:
+		nsByte, err := d.uint8()
+		if err != nil {
+			return "", 0, err
+		}
+		id, err := d.uint16()
+		if err != nil {
+			return "", 0, err
+		}
+		ns, identifier = uint16(nsByte), fmt.Sprintf("i=%d", id)
+	case 0x02, 0x03, 0x04, 0x05:
+		ns, err = d.uint16()
+		if err != nil {
+			return "", 0, err
+		}
+		switch encoding & 0x3f {
+		case 0x02:
+			id, err := d.uint32()
+			if err != nil {
+				return "", 0, err
+			}
+			identifier = fmt.Sprintf("i=%d", id)
+		case 0x03:
+			id, err := d.string()
+			if err != nil {
+				return "", 0, err
+			}
+			identifier = "s=" + id
+		case 0x04:
+			id, err := d.guid()
+			if err != nil {
+				return "", 0, err
+			}
+			identifier = "g=" + id
+		case 0x05:
+			id, err := d.bytes()
+			if err != nil {
+				return "", 0, err
+			}
+			identifier = "b=" + base64.StdEncoding.EncodeToString(id)
+		}
+	default:
+		return "", 0, fmt.Errorf("node id encoding 0x%x is invalid", encoding)
+	}
+	return nodeIdText(ns, identifier), encoding, nil
+}
+
+func (d *structDecoder) expandedNodeID() (string, error) {
+	text, encoding, err := d.nodeID()
+	if err != nil {
+		return "", err
+	}
+	if encoding&0x80 != 0 {
+		uri, err := d.string()
+		if err != nil {
+			return "", err
+		}
+		text = "nsu=" + uri + ";" + text
+	}
+	if encoding&0x40 != 0 {
+		server, err := d.uint32()
+		if err != nil {
+			return "", err
+		}
+		text = fmt.Sprintf("svr=%d;%s", server, text)
+	}
+	return text, nil
+}
+
+func structDateTime(value uint64) time.Time {
+	if value == 0 || int64(value) < 0 {
+		return time.Time{}
+	}
+	return DatetimeToTime(value)
+}
+
+func (d *structDecoder) builtin(id uint32, depth int) (interface{}, error) {
+	switch id {
+	case BUILTIN_BOOLEAN:
+		value, err := d.uint8()
+		return value != 0, err
+	case BUILTIN_SBYTE:
+		value, err := d.uint8()
+		return int8(value), err
+	case BUILTIN_BYTE:
+		return d.uint8()
+	case BUILTIN_INT16:
+		value, err := d.uint16()
+		return int16(value), err
+	case BUILTIN_UINT16:
+		return d.uint16()
+	case BUILTIN_INT32:
+		value, err := d.uint32()
+		return int32(value), err
+	case BUILTIN_UINT32:
+		return d.uint32()
+	case BUILTIN_INT64:
+		value, err := d.uint64()
+		return int64(value), err
+	case BUILTIN_UINT64:
+		return d.uint64()
+	case BUILTIN_FLOAT:
+		value, err := d.uint32()
+		return math.Float32frombits(value), err
+	case BUILTIN_DOUBLE:
+		value, err := d.uint64()
+		return math.Float64frombits(value), err
+	case BUILTIN_STRING, BUILTIN_XMLELEMENT:
+		return d.string()
+	case BUILTIN_DATETIME:
+		value, err := d.uint64()
+		return structDateTime(value), err
+	case BUILTIN_GUID:
+		return d.guid()
+	case BUILTIN_BYTESTRING:
+		return d.bytes()
+	case BUILTIN_NODEID:
+		text, _, err := d.nodeID()
+		return text, err
+	case BUILTIN_EXPANDEDNODEID:
+		return d.expandedNodeID()
+	case BUILTIN_STATUSCODE:
+		return d.uint32()
+	case BUILTIN_QUALIFIEDNAME:
+		ns, err := d.uint16()
+		if err != nil {
+			return nil, err
+		}
+		name, err := d.string()
+		return QualifiedName{NamespaceIndex: ns, Name: name}, err
+	case BUILTIN_LOCALIZEDTEXT:
+		return d.localizedText()
+	case BUILTIN_EXTENSIONOBJECT:
+		return d.extensionObject(depth)
+	case BUILTIN_DATAVALUE:
+		return d.dataValue(depth)
+	case BUILTIN_VARIANT:
+		return d.variant(depth)
+	case BUILTIN_DIAGNOSTICINFO:
+		return d.diagnosticInfo(depth)
+	}
+	return nil, fmt.Errorf("builtin type %d is not supported", id)
+}
+
+func (d *structDecoder) localizedText() (LocalizedText, error) {
+	var text LocalizedText
+	mask, err := d.uint8()
+	if err != nil {
+		return text, err
+	}
+	if mask&0x01 != 0 {
+		if text.Locale, err = d.string(); err != nil {
+			return text, err
+		}
+	}
+	if mask&0x02 != 0 {
+		if text.Text, err = d.string(); err != nil {
+			return text, err
+		}
+	}
+	return text, nil
+}
+
+func (d *structDecoder) extensionObject(depth int) (ExtensionObject, error) {
+	var object ExtensionObject
+	typeID, _, err := d.nodeID()
+	if err != nil {
+		return object, err
+	}
+	object.TypeID = typeID
+
+	encoding, err := d.uint8()
+	if err != nil {
+		return object, err
+	}
+	if encoding == 0x00 {
+		return object, nil
+	}
+	object.Body, err = d.bytes()
+	if err != nil || encoding != 0x01 || depth > STRUCT_MAX_DEPTH {
+		return object, err
+	}
+
+	if err := d.c.decodeExtension(&object); err != nil {
+		logs.Debug("opcua client decode nested %s failed, %s", object.TypeID, err.Error())
+	}
+	return object, nil
+}
+
+func (d *structDecoder) dataValue(depth int) (map[string]interface{}, error) {
+	value := make(map[string]interface{})
+	mask, err := d.uint8()
+	if err != nil {
+		return nil, err
+	}
+	if mask&0x01 != 0 {
+		if value["Value"], err = d.variant(depth); err != nil {
+			return nil, err
+		}
+	}
+	if mask&0x02 != 0 {
+		if value["Status"], err = d.uint32(); err != nil {
+			return nil, err
+		}
+	}
+	if mask&0x04 != 0 {
+		if value["SourceTimestamp"], err = d.builtin(BUILTIN_DATETIME, depth); err != nil {
+			return nil, err
+		}
+	}
+	if mask&0x10 != 0 {
+		if value["SourcePicoseconds"], err = d.uint16(); err != nil {
+			return nil, err
+		}
+	}
+	if mask&0x08 != 0 {
+		if value["ServerTimestamp"], err = d.builtin(BUILTIN_DATETIME, depth); err != nil {
+			return nil, err
+		}
+	}
+	if mask&0x20 != 0 {
+		if value["ServerPicoseconds"], err = d.uint16(); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func (d *structDecoder) variant(depth int) (interface{}, error) {
+	mask, err := d.uint8()
+	if err != nil {
+		return nil, err
+	}
+	id := uint32(mask & 0x3f)
+	if id == 0 {
+		return nil, nil
+	}
+	if mask&0x80 == 0 {
+		return d.builtin(id, depth+1)
+	}
+
+	length, err := d.length()
+	if err != nil {
+		return nil, err
+	}
+	value, err := d.builtinArray(id, length, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if mask&0x40 != 0 {
+		// the dimensions of the multi dimension array, the values are kept flat
+		if _, err := d.builtinArray(BUILTIN_INT32, -1, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func (d *structDecoder) diagnosticInfo(depth int) (map[string]interface{}, error) {
+	if depth > STRUCT_MAX_DEPTH {
+		return nil, fmt.Errorf("diagnostic info nested too deep")
+	}
+	info := make(map[string]interface{})
+	mask, err := d.uint8()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{"SymbolicId", "NamespaceUri", "LocalizedText", "Locale"}
+	for i, name := range names {
+		if mask&(1<<uint(i)) != 0 {
+			value, err := d.uint32()
+			if err != nil {
+				return nil, err
+			}
+			info[name] = int32(value)
+		}
+	}
+	if mask&0x10 != 0 {
+		if info["AdditionalInfo"], err = d.string(); err != nil {
+			return nil, err
+		}
+	}
+	if mask&0x20 != 0 {
+		if info["InnerStatusCode"], err = d.uint32(); err != nil {
+			return nil, err
+		}
+	}
+	if mask&0x40 != 0 {
+		if info["InnerDiagnosticInfo"], err = d.diagnosticInfo(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+// builtinArray decodes the elements to the slice type of NodeValue, the length -1 reads it first
+func (d *structDecoder) builtinArray(id uint32, length int, depth int) (interface{}, error) {
+	if length < 0 {
+		var err error
+		if length, err = d.length(); err != nil {
+			return nil, err
+		}
+	}
+	items := make([]interface{}, length)
+	for i := range items {
+		item, err := d.builtin(id, depth)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+
+	switch id {
+	case BUILTIN_BOOLEAN:
+		return structSlice[bool](items), nil
+	case BUILTIN_SBYTE:
+		return structSlice[int8](items), nil
+	case BUILTIN_BYTE:
+		return structSlice[uint8](items), nil
+	case BUILTIN_INT16:
+		return structSlice[int16](items), nil
+	case BUILTIN_UINT16:
+		return structSlice[uint16](items), nil
+	case BUILTIN_INT32:
+		return structSlice[int32](items), nil
+	case BUILTIN_UINT32, BUILTIN_STATUSCODE:
+		return structSlice[uint32](items), nil
+	case BUILTIN_INT64:
+		return structSlice[int64](items), nil
+	case BUILTIN_UINT64:
+		return structSlice[uint64](items), nil
+	case BUILTIN_FLOAT:
+		return structSlice[float32](items), nil
+	case BUILTIN_DOUBLE:
+		return structSlice[float64](items), nil
+	case BUILTIN_STRING, BUILTIN_XMLELEMENT, BUILTIN_GUID, BUILTIN_NODEID, BUILTIN_EXPANDEDNODEID:
+		return structSlice[string](items), nil
+	case BUILTIN_DATETIME:
+		return structSlice[time.Time](items), nil
+	case BUILTIN_BYTESTRING:
+		return structSlice[[]byte](items), nil
+	case BUILTIN_QUALIFIEDNAME:
+		return structSlice[QualifiedName](items), nil
+	case BUILTIN_LOCALIZEDTEXT:
+		return structSlice[LocalizedText](items), nil
+	case BUILTIN_EXTENSIONOBJECT:
+		return structSlice[ExtensionObject](items), nil
+	}
+	return items, nil
+}
+
+func structSlice[T any](items []interface{}) []T {
+	values := make([]T, len(items))
+	for i, item := range items {
+		values[i] = item.(T)
+	}
+	return values
+}
+
+func (d *structDecoder) value(dataType string, depth int) (interface{}, error) {
+	id, def, err := d.c.resolveType(dataType, depth)
+	if err != nil {
+		return nil, err
+	}
+	if def != nil {
+		return d.structure(def, depth+1)
+	}
+	return d.builtin(id, depth)
+}
+
+func (d *structDecoder) field(field StructField, depth int) (interface{}, error) {
+	if field.ValueRank < 0 {
+		return d.value(field.DataType, depth)
+	}
+
+	length := 0
+	var err error
+	if field.ValueRank > 1 {
+		// the multi dimension array is the dimensions followed by the flat values
+		dims, err := d.builtinArray(BUILTIN_INT32, -1, depth)
+		if err != nil {
+			return nil, err
+		}
+		length = 1
+		for _, dim := range dims.([]int32) {
+			length *= int(dim)
+		}
+		if length < 0 || length > len(d.buf)-d.pos {
+			return nil, fmt.Errorf("array dimensions of %s are invalid", field.Name)
+		}
+	} else if length, err = d.length(); err != nil {
+		return nil, err
+	}
+
+	id, def, err := d.c.resolveType(field.DataType, depth)
+	if err != nil {
+		return nil, err
+	}
+	if def == nil {
+		return d.builtinArray(id, length, depth)
+	}
+	items := make([]interface{}, length)
+	for i := range items {
+		if items[i], err = d.structure(def, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (d *structDecoder) structure(def *StructDefinition, depth int) (map[string]interface{}, error) {
+	if depth > STRUCT_MAX_DEPTH {
+		return nil, fmt.Errorf("structure %s nested too deep", def.Name)
+	}
+	fields := make(map[string]interface{})
+
+	switch def.Kind {
+	case STRUCTURE_UNION:
+		selected, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		fields["SwitchField"] = selected
+		if selected == 0 {
+			return fields, nil
+		}
+		if int(selected) > len(def.Fields) {
+			return nil, fmt.Errorf("union %s switch field %d is invalid", def.Name, selected)
+		}
+		field := def.Fields[selected-1]
+		value, err := d.field(field, depth)
+		if err != nil {
+			return nil, err
+		}
+		fields[field.Name] = value
+		return fields, nil
+	case STRUCTURE_OPTIONAL, STRUCTURE_PLAIN:
+	default:
+		return nil, fmt.Errorf("structure %s type %d is not supported", def.Name, def.Kind)
+	}
+
+	var mask uint64
+	if def.Kind == STRUCTURE_OPTIONAL {
+		data, err := d.read((def.MaskBits + 7) / 8)
+		if err != nil {
+			return nil, err
+		}
+		for i, b := range data {
+			mask |= uint64(b) << (8 * uint(i))
+		}
+	}
+
+	for _, field := range def.Fields {
+		if field.Optional && field.Bit >= 0 && mask&(1<<uint(field.Bit)) == 0 {
+			continue
+		}
+		value, err := d.field(field, depth)
+		if err != nil {
+			return nil, fmt.Errorf("field %s, %s", field.Name, err.Error())
+		}
+		fields[field.Name] = value
+	}
+	return fields, nil
+}
+
+// structBuiltinValue keeps the NodeValue of the decoded structure members, the datetime becomes time
+func structBuiltinValue(value *NodeValue) interface{} {
+	if value.Type != UA_DATETIME {
+		return value.Value
+	}
+	if value.Array {
+		items := value.Value.([]uint64)
+		times := make([]time.Time, len(items))
+		for i, item := range items {
+			times[i] = structDateTime(item)
+		}
+		return times
+	}
+	return structDateTime(value.Value.(uint64))
+}
+
+func structPathSplit(path string) ([]interface{}, error) {
+	steps := make([]interface{}, 0)
+	for _, segment := range strings.Split(path, ".") {
+		name := segment
+		indexes := ""
+		if index := strings.Index(segment, "["); index >= 0 {
+			name, indexes = segment[:index], segment[index:]
+		}
+		if name != "" {
+			steps = append(steps, name)
+		}
+		for indexes != "" {
+			end := strings.Index(indexes, "]")
+			if !strings.HasPrefix(indexes, "[") || end < 0 {
+				return nil, fmt.Errorf("field path %s is invalid", path)
+			}
+			index, err := strconv.Atoi(indexes[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("field path %s index is invalid", path)
+			}
+			steps = append(steps, index)
+			indexes = indexes[end+1:]
+		}
+	}
+	return steps, nil
+}
+
+// StructFieldGet picks the value of the path like "Motor.Speeds[2].Value" from the decoded structure
+func StructFieldGet(value interface{}, path string) (interface{}, error) {
+	steps, err := structPathSplit(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		if object, ok := value.(ExtensionObject); ok {
+			if object.Value == nil {
+				return nil, fmt.Errorf("structure %s is not decoded", object.TypeID)
+			}
+			value = object.Value
+		}
+		switch step := step.(type) {
+		case string:
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("field %s is not in a structure", step)
+			}
+			value, ok = fields[step]
+			if !ok {
+				return nil, fmt.Errorf("field %s is not found", step)
+			}
+		case int:
+			items := reflect.ValueOf(value)
+			if items.Kind() != reflect.Slice || items.Type() == reflect.TypeOf([]byte{}) {
+				return nil, fmt.Errorf("index %d is not in an array", step)
+			}
+			if step >= items.Len() {
+				return nil, fmt.Errorf("index %d is out of array length %d", step, items.Len())
+			}
+			value = items.Index(step).Interface()
+		}
+	}
+	if object, ok := value.(ExtensionObject); ok && object.Value != nil {
+		value = object.Value
+	}
+	return value, nil
+}
+
+// StructFieldPaths lists the leaf fields of the decoded structure for the node selection
+func StructFieldPaths(value interface{}) []string {
+	paths := make([]string, 0)
+	structFieldWalk(value, "", &paths, 0)
+	return paths
+}
+
+func structFieldWalk(value interface{}, path string, paths *[]string, depth int) {
+	if object, ok := value.(ExtensionObject); ok && object.Value != nil {
+		value = object.Value
+	}
+	if depth > STRUCT_MAX_DEPTH {
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			child := name
+			if path != "" {
+				child = path + "." + name
+			}
+			structFieldWalk(value[name], child, paths, depth+1)
+		}
+	case []interface{}:
+		for i, item := range value {
+			if i >= STRUCT_MAX_ELEMENTS {
+				break
+			}
+			structFieldWalk(item, fmt.Sprintf("%s[%d]", path, i), paths, depth+1)
+		}
+	default:
+		if path != "" {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// StructNodeValue converts the decoded field to NodeValue, the nested structures become json string
+func StructNodeValue(value interface{}) (*NodeValue, error) {
+	switch value := value.(type) {
+	case bool:
+		return &NodeValue{Type: UA_BOOLEAN, Value: value}, nil
+	case int8:
+		return &NodeValue{Type: UA_INT8, Value: value}, nil
+	case uint8:
+		return &NodeValue{Type: UA_UINT8, Value: value}, nil
+	case int16:
+		return &NodeValue{Type: UA_INT16, Value: value}, nil
+	case uint16:
+		return &NodeValue{Type: UA_UINT16, Value: value}, nil
+	case int32:
+		return &NodeValue{Type: UA_INT32, Value: value}, nil
+	case uint32:
+		return &NodeValue{Type: UA_UINT32, Value: value}, nil
+	case int64:
+		return &NodeValue{Type: UA_INT64, Value: value}, nil
+	case uint64:
+		return &NodeValue{Type: UA_UINT64, Value: value}, nil
+	case float32:
+		return &NodeValue{Type: UA_FLOAT, Value: value}, nil
+	case float64:
+		return &NodeValue{Type: UA_DOUBLE, Value: value}, nil
+	case string:
+		return &NodeValue{Type: UA_STRING, Value: value}, nil
+	case time.Time:
+		return &NodeValue{Type: UA_DATETIME, Value: uint64(TimeToDatetime(value))}, nil
+	case []byte:
+		return &NodeValue{Type: UA_BYTESTRING, Value: value}, nil
+	case QualifiedName:
+		return &NodeValue{Type: UA_QUALIFIEDNAME, Value: value}, nil
+	case LocalizedText:
+		return &NodeValue{Type: UA_LOCALIZEDTEXT, Value: value}, nil
+	case ExtensionObject:
+		return &NodeValue{Type: UA_EXTENSIONOBJECT, Value: value}, nil
+	case []bool:
+		return &NodeValue{Type: UA_BOOLEAN, Array: true, Value: value}, nil
+	case []int8:
+		return &NodeValue{Type: UA_INT8, Array: true, Value: value}, nil
+	case []int16:
+		return &NodeValue{Type: UA_INT16, Array: true, Value: value}, nil
+	case []uint16:
+		return &NodeValue{Type: UA_UINT16, Array: true, Value: value}, nil
+	case []int32:
+		return &NodeValue{Type: UA_INT32, Array: true, Value: value}, nil
+	case []uint32:
+		return &NodeValue{Type: UA_UINT32, Array: true, Value: value}, nil
+	case []int64:
+		return &NodeValue{Type: UA_INT64, Array: true, Value: value}, nil
+	case []uint64:
+		return &NodeValue{Type: UA_UINT64, Array: true, Value: value}, nil
+	case []float32:
+		return &NodeValue{Type: UA_FLOAT, Array: true, Value: value}, nil
+	case []float64:
+		return &NodeValue{Type: UA_DOUBLE, Array: true, Value: value}, nil
+	case []string:
+		return &NodeValue{Type: UA_STRING, Array: true, Value: value}, nil
+	case []time.Time:
+		items := make([]uint64, len(value))
+		for i, item := range value {
+			items[i] = uint64(TimeToDatetime(item))
+		}
+		return &NodeValue{Type: UA_DATETIME, Array: true, Value: items}, nil
+	case [][]byte:
+		return &NodeValue{Type: UA_BYTESTRING, Array: true, Value: value}, nil
+	case []QualifiedName:
+		return &NodeValue{Type: UA_QUALIFIEDNAME, Array: true, Value: value}, nil
+	case []LocalizedText:
+		return &NodeValue{Type: UA_LOCALIZEDTEXT, Array: true, Value: value}, nil
+	case []ExtensionObject:
+		return &NodeValue{Type: UA_EXTENSIONOBJECT, Array: true, Value: value}, nil
+	}
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("structure field marshal failed, %s", err.Error())
+	}
+	return &NodeValue{Type: UA_STRING, Value: string(body)}, nil
+}
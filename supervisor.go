@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+// the queue full policy of the sink queues
+const (
+	QUEUE_BLOCK = "block" // the producer waits for the sink
+	QUEUE_DROP  = "drop"  // the oldest queued data is dropped
+)
+
+const (
+	QUEUE_DEFAULT_SIZE = 256
+	QUEUE_MAX_SIZE     = 100000
+)
+
+const (
+	WORKER_RESTART_DELAY = time.Second
+	WORKER_RESTART_MAX   = time.Minute
+)
+
+type QueueConfig struct {
+	Size   int    `json:"size"`
+	Policy string `json:"policy"`
+}
+
+func QueuePolicies() []string {
+	return []string{QUEUE_BLOCK, QUEUE_DROP}
+}
+
+// Default fills the zero values with the size and the policy
+func (c QueueConfig) Default(policy string) QueueConfig {
+	if c.Size <= 0 {
+		c.Size = QUEUE_DEFAULT_SIZE
+	}
+	if c.Policy != QUEUE_BLOCK && c.Policy != QUEUE_DROP {
+		c.Policy = policy
+	}
+	return c
+}
+
+// SinkQueue is the bounded queue of one producer to the sink
+type SinkQueue struct {
+	name    string
+	policy  string
+	ch      chan interface{}
+	notify  chan struct{}
+	dropped uint64
+}
+
+// Push queues the data by the policy, it returns false when the data or the oldest data is dropped
+func (q *SinkQueue) Push(ctx context.Context, data interface{}) bool {
+	if q.policy == QUEUE_BLOCK {
+		select {
+		case q.ch <- data:
+			q.signal()
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	full := false
+	for {
+		select {
+		case q.ch <- data:
+			q.signal()
+			return !full
+		default:
+		}
+		select {
+		case <-q.ch:
+			full = true
+			if dropped := atomic.AddUint64(&q.dropped, 1); dropped%1000 == 1 {
+				logs.Warning("sink queue %s is full, %d data dropped", q.name, dropped)
+			}
+		default:
+		}
+	}
+}
+
+func (q *SinkQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *SinkQueue) Dropped() uint64 {
+	return atomic.LoadUint64(&q.dropped)
+}
+
+// Sink takes the data from the producer queues by turns, so one busy producer does not starve the others
+type Sink struct {
+	sync.Mutex
+	name   string
+	notify chan struct{}
+	queues []*SinkQueue
+	next   int
+}
+
+func NewSink(name string) *Sink {
+	return &Sink{name: name, notify: make(chan struct{}, 1)}
+}
+
+// Queue returns the queue of the producer, the queue is created at the first call
+func (s *Sink) Queue(name string, cfg QueueConfig) *SinkQueue {
+	s.Lock()
+	defer s.Unlock()
+
+	name = fmt.Sprintf("%s.%s", s.name, name)
+	for _, queue := range s.queues {
+		if queue.name == name {
+			return queue
+		}
+	}
+	queue := &SinkQueue{
+		name:   name,
+		policy: cfg.Policy,
+		ch:     make(chan interface{}, cfg.Size),
+		notify: s.notify,
+	}
+	s.queues = append(s.queues, queue)
+	return queue
+}
+
+func (s *Sink) pop() (interface{}, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	for i := range s.queues {
+		queue := s.queues[(s.next+i)%len(s.queues)]
+		select {
+		case data := <-queue.ch:
+			s.next = (s.next + i + 1) % len(s.queues)
+			return data, true
+		default:
+		}
+	}
+	return nil, false
+}
+
+// Pop waits for the next data, after the cancel it returns the queued data until the queues are empty
+func (s *Sink) Pop(ctx context.Context) (interface{}, bool) {
+	for {
+		if data, ok := s.pop(); ok {
+			return data, true
+		}
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return s.pop()
+		}
+	}
+}
+
+type worker struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Supervisor runs the workers with their own cancel, the crashed worker is restarted alone
+type Supervisor struct {
+	sync.Mutex
+	sync.WaitGroup
+	name    string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers map[string]worker
+}
+
+func NewSupervisor(name string) *Supervisor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Supervisor{
+		name:    name,
+		ctx:     ctx,
+		cancel:  cancel,
+		workers: make(map[string]worker),
+	}
+}
+
+func (s *Supervisor) Context() context.Context {
+	return s.ctx
+}
+
+// Go starts the worker, the worker is restarted with backoff after a panic until it is stopped
+func (s *Supervisor) Go(name string, task func(ctx context.Context)) {
+	s.Stop(name)
+
+	s.Lock()
+	defer s.Unlock()
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	done := make(chan struct{})
+	s.workers[name] = worker{cancel: cancel, done: done}
+
+	s.Add(1)
+	go func() {
+		defer s.Done()
+		defer close(done)
+		defer cancel()
+
+		delay := WORKER_RESTART_DELAY
+		for !s.run(ctx, name, task) {
+			logs.Warning("%s worker %s restart after %s", s.name, name, delay)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			delay = min(delay*2, WORKER_RESTART_MAX)
+		}
+	}()
+}
+
+// run returns false when the task panics
+func (s *Supervisor) run(ctx context.Context, name string, task func(ctx context.Context)) (ok bool) {
+	defer func() {
+		if err := recover(); err != nil {
+			logs.Error("%s worker %s panic, %v\n%s", s.name, name, err, debug.Stack())
+			ok = false
+		}
+	}()
+	task(ctx)
+	return true
+}
+
+// Stop cancels the worker and waits for it to exit
+func (s *Supervisor) Stop(name string) {
+	s.Lock()
+	w, ok := s.workers[name]
+	delete(s.workers, name)
+	s.Unlock()
+
+	if ok {
+		w.cancel()
+		<-w.done
+	}
+}
+
+// Close cancels all workers and waits for them
+func (s *Supervisor) Close() {
+	s.cancel()
+	s.Wait()
+}
+
+func QueueEditDialog(from walk.Form, title string, queue *QueueConfig) {
+	var dlg *walk.Dialog
+	var sizeNum *walk.NumberEdit
+	var policyBox *walk.ComboBox
+	var acceptPB, cancelPB *walk.PushButton
+
+	current := 0
+	for i, policy := range QueuePolicies() {
+		if policy == queue.Policy {
+			current = i
+		}
+	}
+
+	_, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         title,
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 400, Height: 150},
+		Size:          Size{Width: 400, Height: 150},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		Layout:        VBox{},
+		Children: []Widget{
+			Composite{
+				Layout: Grid{Columns: 2},
+				Children: []Widget{
+					Label{
+						Text: "Queue Size (per client):",
+					},
+					NumberEdit{
+						AssignTo:    &sizeNum,
+						Value:       float64(queue.Size),
+						ToolTipText: fmt.Sprintf("1~%d, 0 is %d", QUEUE_MAX_SIZE, QUEUE_DEFAULT_SIZE),
+						MinValue:    0,
+						MaxValue:    QUEUE_MAX_SIZE,
+					},
+					Label{
+						Text: "Queue Full Policy:",
+					},
+					ComboBox{
+						AssignTo:     &policyBox,
+						CurrentIndex: current,
+						Model:        QueuePolicies(),
+						ToolTipText:  "block: the client waits for the sink, drop: the oldest data is dropped",
+					},
+				},
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Accept",
+						OnClicked: func() {
+							queue.Size = int(sizeNum.Value())
+							queue.Policy = policyBox.Text()
+							dlg.Accept()
+							logs.Info("queue edit dialog accept")
+						},
+					},
+					PushButton{
+						AssignTo: &cancelPB,
+						Text:     "Cancel",
+						OnClicked: func() {
+							dlg.Cancel()
+							logs.Info("queue edit dialog cancel")
+						},
+					},
+					HSpacer{},
+				},
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("QueueEditDialog: %s", err.Error())
+	}
+}
@@ -3,10 +3,13 @@ package main
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -178,6 +181,96 @@ func SmallLength(a, b int) int {
 	return b
 }
 
+// CheckIndexRange checks the numeric range of the opc ua index range, the empty range is valid
+func CheckIndexRange(text string) error {
+	if text == "" {
+		return nil
+	}
+	for _, dim := range strings.Split(text, ",") {
+		bounds := strings.Split(dim, ":")
+		if len(bounds) > 2 {
+			return fmt.Errorf("index range %s is invalid", text)
+		}
+		values := make([]uint64, 0)
+		for _, bound := range bounds {
+			value, err := strconv.ParseUint(bound, 10, 32)
+			if err != nil {
+				return fmt.Errorf("index range %s is invalid", text)
+			}
+			values = append(values, value)
+		}
+		if len(values) == 2 && values[0] >= values[1] {
+			return fmt.Errorf("index range %s lower bound must be less than upper bound", text)
+		}
+	}
+	return nil
+}
+
+func MatrixLength(dims []uint32) int {
+	length := 1
+	for _, dim := range dims {
+		length *= int(dim)
+	}
+	return length
+}
+
+// ParseMatrixString parses the nested json array to the flat element texts and the dimensions
+func ParseMatrixString(text string) ([]string, []uint32, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var matrix interface{}
+	err := decoder.Decode(&matrix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("matrix %s parse failed, %s", text, err.Error())
+	}
+
+	dims := make([]uint32, 0)
+	for item := matrix; ; {
+		list, ok := item.([]interface{})
+		if !ok {
+			break
+		}
+		dims = append(dims, uint32(len(list)))
+		if len(list) == 0 {
+			break
+		}
+		item = list[0]
+	}
+
+	items := make([]string, 0)
+	var flatten func(item interface{}, dim int) error
+	flatten = func(item interface{}, dim int) error {
+		if dim == len(dims) {
+			switch item := item.(type) {
+			case string:
+				items = append(items, item)
+			case json.Number:
+				items = append(items, item.String())
+			case bool:
+				items = append(items, BoolToString(item))
+			default:
+				return fmt.Errorf("matrix element %v is invalid", item)
+			}
+			return nil
+		}
+		list, ok := item.([]interface{})
+		if !ok || uint32(len(list)) != dims[dim] {
+			return fmt.Errorf("matrix %s is not rectangular", text)
+		}
+		for _, child := range list {
+			if err := flatten(child, dim+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := flatten(matrix, 0); err != nil {
+		return nil, nil, err
+	}
+	return items, dims, nil
+}
+
 func BoolListToString(values []bool) string {
 	var buffer bytes.Buffer
 	for i, v := range values {
@@ -592,3 +685,103 @@ func ByteClone(a []byte) []byte {
 	copy(b, a)
 	return b
 }
+
+func StatusCodeListToString(values []uint32) string {
+	var buffer bytes.Buffer
+	for i, v := range values {
+		buffer.WriteString(StatusCodeString(v))
+		if i+1 != len(values) {
+			buffer.WriteString(",")
+		}
+	}
+	return buffer.String()
+}
+
+func QualifiedNameListToString(values []QualifiedName) string {
+	var buffer bytes.Buffer
+	for i, v := range values {
+		buffer.WriteString(v.String())
+		if i+1 != len(values) {
+			buffer.WriteString(",")
+		}
+	}
+	return buffer.String()
+}
+
+func LocalizedTextListToString(values []LocalizedText) string {
+	var buffer bytes.Buffer
+	for i, v := range values {
+		buffer.WriteString(v.String())
+		if i+1 != len(values) {
+			buffer.WriteString(",")
+		}
+	}
+	return buffer.String()
+}
+
+func ExtensionObjectListToString(values []ExtensionObject) string {
+	var buffer bytes.Buffer
+	for i, v := range values {
+		buffer.WriteString(v.String())
+		if i+1 != len(values) {
+			buffer.WriteString(",")
+		}
+	}
+	return buffer.String()
+}
+
+func QualifiedNameListCompare(a, b []QualifiedName) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func LocalizedTextListCompare(a, b []LocalizedText) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func ExtensionObjectListCompare(a, b []ExtensionObject) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func QualifiedNameListClone(a []QualifiedName) []QualifiedName {
+	b := make([]QualifiedName, len(a))
+	copy(b, a)
+	return b
+}
+
+func LocalizedTextListClone(a []LocalizedText) []LocalizedText {
+	b := make([]LocalizedText, len(a))
+	copy(b, a)
+	return b
+}
+
+func ExtensionObjectListClone(a []ExtensionObject) []ExtensionObject {
+	b := make([]ExtensionObject, len(a))
+	for i := range a {
+		b[i] = a[i].Clone()
+	}
+	return b
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+const (
+	CLIENT_TIMEOUT_MIN = 100
+	CLIENT_TIMEOUT_MAX = 10000
+)
+
+var ErrConfigInvalid = errors.New("the configuration is invalid")
+
+// ConfigIssue is a problem of the config, the path is the json path like clients[0].endpoint
+type ConfigIssue struct {
+	Path     string
+	Severity IssueSeverity
+	Message  string
+}
+
+func (i ConfigIssue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("[%s] %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", i.Severity, i.Path, i.Message)
+}
+
+type ConfigReport struct {
+	Issues []ConfigIssue
+}
+
+func (r *ConfigReport) add(path string, severity IssueSeverity, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ConfigIssue{
+		Path:     path,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *ConfigReport) Count(severity IssueSeverity) int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *ConfigReport) Summary() string {
+	return fmt.Sprintf("%d errors, %d warnings", r.Count(SEVERITY_ERROR), r.Count(SEVERITY_WARNING))
+}
+
+// Err returns ErrConfigInvalid with the errors of the report, nil without errors
+func (r *ConfigReport) Err() error {
+	lines := make([]string, 0)
+	for _, issue := range r.Issues {
+		if issue.Severity == SEVERITY_ERROR {
+			lines = append(lines, issue.String())
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w, %s\n%s", ErrConfigInvalid, r.Summary(), strings.Join(lines, "\n"))
+}
+
+func (r *ConfigReport) Log() {
+	for _, issue := range r.Issues {
+		if issue.Severity == SEVERITY_ERROR {
+			logs.Error("config validate %s", issue.String())
+		} else {
+			logs.Warning("config validate %s", issue.String())
+		}
+	}
+}
+
+// ConfigValidate returns all problems of the config, the listen port is tested only with ports
+func ConfigValidate(config Config, ports bool) *ConfigReport {
+	report := &ConfigReport{}
+
+	validateClients(report, config)
+	validateServer(report, config, ports)
+	validateDatastore(report, config.Datastore)
+	validateSecrets(report, config)
+
+	return report
+}
+
+func validateClients(report *ConfigReport, config Config) {
+	if len(config.Clients) == 0 {
+		report.add("clients", SEVERITY_WARNING, "no opcua client is configured")
+	}
+
+	names := make(map[string]int)
+	for i, client := range config.Clients {
+		path := fmt.Sprintf("clients[%d]", i)
+
+		if strings.TrimSpace(client.Name) == "" {
+			report.add(path+".name", SEVERITY_ERROR, "client name is empty")
+		} else if prev, ok := names[client.Name]; ok {
+			report.add(path+".name", SEVERITY_ERROR, "client name %s duplicates clients[%d]", client.Name, prev)
+		} else {
+			names[client.Name] = i
+		}
+
+		if client.Endpoint == "" {
+			report.add(path+".endpoint", SEVERITY_ERROR, "endpoint is empty")
+		} else if !strings.HasPrefix(client.Endpoint, "opc.tcp://") {
+			report.add(path+".endpoint", SEVERITY_ERROR, "endpoint %s is not an opc.tcp:// url", client.Endpoint)
+		}
+
+		if client.Timeout < CLIENT_TIMEOUT_MIN || client.Timeout > CLIENT_TIMEOUT_MAX {
+			report.add(path+".timeout", SEVERITY_ERROR, "timeout %d is out of the range %d~%d ms",
+				client.Timeout, CLIENT_TIMEOUT_MIN, CLIENT_TIMEOUT_MAX)
+		}
+		if client.Backfill < 0 || client.Backfill > BACKFILL_MAX_HOURS {
+			report.add(path+".backfill", SEVERITY_ERROR, "backfill %d is out of the range 0~%d hours", client.Backfill, BACKFILL_MAX_HOURS)
+		}
+		if client.Pipeline < 0 || client.Pipeline > CLIENT_PIPELINE_MAX {
+			report.add(path+".pipeline", SEVERITY_ERROR, "pipeline %d is out of the range 0~%d", client.Pipeline, CLIENT_PIPELINE_MAX)
+		}
+
+		if client.Enable && len(client.NodeList) == 0 && !client.Events.Enable {
+			report.add(path+".nodes", SEVERITY_WARNING, "the enabled client has no nodes")
+		}
+		for j, node := range client.NodeList {
+			validateNode(report, fmt.Sprintf("%s.nodes[%d]", path, j), client.NodeList[:j], node)
+		}
+
+		alarms := make(map[string]int)
+		for j, alarm := range client.Alarms {
+			alarmPath := fmt.Sprintf("%s.alarms[%d]", path, j)
+			if alarm.Name == "" {
+				report.add(alarmPath+".name", SEVERITY_ERROR, "alarm name is empty")
+			} else if prev, ok := alarms[alarm.Name]; ok {
+				report.add(alarmPath+".name", SEVERITY_ERROR, "alarm name %s duplicates %s.alarms[%d]", alarm.Name, path, prev)
+			} else {
+				alarms[alarm.Name] = j
+			}
+			if !validateListed(client.NodeList, alarm.Node) {
+				report.add(alarmPath+".node", SEVERITY_WARNING, "alarm node %s is not in the client node list", alarm.Node.ToString())
+			}
+		}
+
+		if client.Events.Enable {
+			if _, err := ParseEventWhere(client.Events.Where); err != nil {
+				report.add(path+".events.where", SEVERITY_ERROR, "%s", err.Error())
+			}
+		}
+	}
+}
+
+func validateNode(report *ConfigReport, path string, prev []NodeInfo, node NodeInfo) {
+	if node.NodeID == "" {
+		report.add(path+".NodeID", SEVERITY_ERROR, "node id is empty")
+		return
+	}
+	if err := CheckIndexRange(node.Range); err != nil {
+		report.add(path+".Range", SEVERITY_ERROR, "%s", err.Error())
+	}
+	if err := AliasCheck(node.Alias); err != nil {
+		report.add(path+".Alias", SEVERITY_ERROR, "%s", err.Error())
+	}
+	if node.Sampling < 0 {
+		report.add(path+".Sampling", SEVERITY_ERROR, "sampling %d is negative", node.Sampling)
+	}
+	for _, item := range prev {
+		if item.Compare(node) {
+			report.add(path, SEVERITY_ERROR, "node %s is duplicated", node.ToString())
+			return
+		}
+	}
+	if item, ok := AliasConflict(prev, node); ok {
+		report.add(path+".Alias", SEVERITY_ERROR, "name %s is used by the node %s", node.Name(), item.ToString())
+	}
+}
+
+func validateListed(nodes []NodeInfo, node NodeInfo) bool {
+	for _, item := range nodes {
+		if item.Compare(node) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateServer(report *ConfigReport, config Config, ports bool) {
+	server := config.Server
+	if !server.Enable {
+		return
+	}
+
+	if server.Port <= 0 || server.Port > 65535 {
+		report.add("server.port", SEVERITY_ERROR, "port %d is out of the range 1~65535", server.Port)
+	} else if ports {
+		if err := ListenTest(server.Endpoint, server.Port); err != nil {
+			report.add("server.port", SEVERITY_ERROR, "port %d is not available, %s", server.Port, err.Error())
+		}
+	}
+
+	clients := make(map[string]ClientConfig)
+	for _, client := range config.Clients {
+		clients[client.Name] = client
+	}
+
+	// the proxy nodes and methods are browsed under the client folder
+	names := make(map[string]string)
+	for i, node := range server.NodeList {
+		path := fmt.Sprintf("server.nodes[%d]", i)
+
+		client, ok := clients[node.ClientName]
+		if !ok {
+			report.add(path+".clientName", SEVERITY_ERROR, "client %s does not exist", node.ClientName)
+		} else if !client.Enable {
+			report.add(path+".clientName", SEVERITY_WARNING, "client %s is disabled, the node is not served", node.ClientName)
+		} else if !validateListed(client.NodeList, node.ClientNode) {
+			report.add(path+".clientNode", SEVERITY_WARNING, "node %s is not in the client %s node list", node.ClientNode.ToString(), node.ClientName)
+		}
+
+		if node.ServerName == "" {
+			report.add(path+".serverName", SEVERITY_ERROR, "server name is empty")
+		} else if prev, ok := names[historyKey(node.ClientName, node.ServerName)]; ok {
+			report.add(path+".serverName", SEVERITY_ERROR, "server name %s duplicates %s", node.ServerName, prev)
+		} else {
+			names[historyKey(node.ClientName, node.ServerName)] = path
+		}
+	}
+
+	for i, method := range server.Methods {
+		path := fmt.Sprintf("server.methods[%d]", i)
+
+		if _, ok := clients[method.ClientName]; !ok {
+			report.add(path+".clientName", SEVERITY_ERROR, "client %s does not exist", method.ClientName)
+		}
+		if method.ServerName == "" {
+			report.add(path+".serverName", SEVERITY_ERROR, "server name is empty")
+		} else if prev, ok := names[historyKey(method.ClientName, method.ServerName)]; ok {
+			report.add(path+".serverName", SEVERITY_ERROR, "server name %s duplicates %s", method.ServerName, prev)
+		} else {
+			names[historyKey(method.ClientName, method.ServerName)] = path
+		}
+	}
+
+	for i, mapping := range server.Hierarchy.Mapping {
+		if _, err := regexp.Compile(mapping.Pattern); err != nil {
+			report.add(fmt.Sprintf("server.hierarchy.mapping[%d].pattern", i), SEVERITY_ERROR, "pattern invalid, %s", err.Error())
+		}
+	}
+
+	roles := make(map[string]bool)
+	for _, role := range server.Roles {
+		roles[role.Name] = true
+	}
+	if role := server.Security.AnonymousRole; role != "" && !roles[role] {
+		report.add("server.security.anonymousRole", SEVERITY_WARNING, "role %s does not exist", role)
+	}
+	if role := server.Security.DefaultRole; role != "" && !roles[role] {
+		report.add("server.security.defaultRole", SEVERITY_WARNING, "role %s does not exist", role)
+	}
+	if len(server.Security.Users) > 0 && !EncryptionSupported() {
+		report.add("server.security.users", SEVERITY_ERROR, "users require encryption support, the password of the security policy None is sent in clear text")
+	}
+	for i, user := range server.Security.Users {
+		if user.Role != "" && !roles[user.Role] {
+			report.add(fmt.Sprintf("server.security.users[%d].role", i), SEVERITY_WARNING, "role %s does not exist", user.Role)
+		}
+	}
+
+	validateQueue(report, "server.queue", server.Queue)
+}
+
+func validateDatastore(report *ConfigReport, datastore DataStoreConfig) {
+	if !datastore.Enable {
+		return
+	}
+	if datastore.Address == "" {
+		report.add("datastore.address", SEVERITY_ERROR, "address is empty")
+	}
+	if datastore.Port <= 0 || datastore.Port > 65535 {
+		report.add("datastore.port", SEVERITY_ERROR, "port %d is out of the range 1~65535", datastore.Port)
+	}
+	if datastore.DataBase == "" {
+		report.add("datastore.database", SEVERITY_ERROR, "database name is empty")
+	} else if EscapeString(datastore.DataBase) != datastore.DataBase {
+		report.add("datastore.database", SEVERITY_ERROR, "database name %s has illegal characters", datastore.DataBase)
+	}
+	if datastore.Expired < 1 || datastore.Expired > 365 {
+		report.add("datastore.expired", SEVERITY_ERROR, "expired %d is out of the range 1~365 days", datastore.Expired)
+	}
+	validateQueue(report, "datastore.queue", datastore.Queue)
+}
+
+func validateSecrets(report *ConfigReport, config Config) {
+	for path, field := range config.secretFields() {
+		if _, err := SecretResolve(*field); err != nil {
+			report.add(path, SEVERITY_ERROR, "%s", err.Error())
+		}
+	}
+}
+
+func validateQueue(report *ConfigReport, path string, queue QueueConfig) {
+	if queue.Size < 0 || queue.Size > QUEUE_MAX_SIZE {
+		report.add(path+".size", SEVERITY_ERROR, "queue size %d is out of the range 0~%d", queue.Size, QUEUE_MAX_SIZE)
+	}
+	if queue.Policy != "" && queue.Policy != QUEUE_BLOCK && queue.Policy != QUEUE_DROP {
+		report.add(path+".policy", SEVERITY_WARNING, "queue policy %s is unknown, the default is used", queue.Policy)
+	}
+}
+
+// consoleAttach writes the output of the windows gui program to the console of the parent process
+func consoleAttach() {
+	attach := syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+	if ret, _, _ := attach.Call(uintptr(^uint32(0))); ret == 0 {
+		return
+	}
+	if out, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+		os.Stdout = out
+		os.Stderr = out
+	}
+}
+
+// ValidateCommand is the headless "validate [-ports] <config.json>" command, the exit code is
+// 0 for a valid config, 1 for the config errors and 2 for the unreadable config
+func ValidateCommand(args []string) int {
+	consoleAttach()
+
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	ports := flags.Bool("ports", false, "test the server listen port")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: validate [-ports] <config.json>")
+		return 2
+	}
+
+	body, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	var config Config
+	if err = json.Unmarshal(body, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "config %s is not valid json, %s\n", flags.Arg(0), err.Error())
+		return 2
+	}
+
+	report := ConfigValidate(config, *ports)
+	for _, issue := range report.Issues {
+		fmt.Println(issue.String())
+	}
+	fmt.Printf("%s: %s\n", flags.Arg(0), report.Summary())
+
+	if report.Count(SEVERITY_ERROR) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// ConfigReportDialog shows the report, with the confirm text it returns whether the user confirms
+func ConfigReportDialog(from walk.Form, title string, report *ConfigReport, confirm string) bool {
+	var dlg *walk.Dialog
+	var acceptPB, cancelPB *walk.PushButton
+
+	issues := make([]string, 0)
+	for _, issue := range report.Issues {
+		issues = append(issues, issue.String())
+	}
+	if len(issues) == 0 {
+		issues = append(issues, "no problems found")
+	}
+
+	buttons := []Widget{
+		HSpacer{},
+		PushButton{
+			AssignTo: &acceptPB,
+			Text:     "OK",
+			OnClicked: func() {
+				dlg.Accept()
+			},
+		},
+	}
+	if confirm != "" {
+		buttons = []Widget{
+			HSpacer{},
+			PushButton{
+				AssignTo: &acceptPB,
+				Text:     confirm,
+				OnClicked: func() {
+					dlg.Accept()
+				},
+			},
+			PushButton{
+				AssignTo: &cancelPB,
+				Text:     "Cancel",
+				OnClicked: func() {
+					dlg.Cancel()
+				},
+			},
+		}
+	}
+	buttons = append(buttons, HSpacer{})
+
+	cancel := &acceptPB
+	if confirm != "" {
+		cancel = &cancelPB
+	}
+
+	result, err := Dialog{
+		AssignTo:      &dlg,
+		Title:         title,
+		Icon:          walk.IconInformation(),
+		MinSize:       Size{Width: 700, Height: 400},
+		Size:          Size{Width: 700, Height: 400},
+		Font:          DefaultFont(),
+		DefaultButton: &acceptPB,
+		CancelButton:  cancel,
+		Layout:        VBox{},
+		Children: []Widget{
+			Label{
+				Text: report.Summary(),
+			},
+			ListBox{
+				Model: issues,
+			},
+			Composite{
+				Layout:   HBox{},
+				Children: buttons,
+			},
+		},
+	}.Run(from)
+
+	if err != nil {
+		logs.Error("ConfigReportDialog: %s", err.Error())
+		return false
+	}
+	return result == walk.DlgCmdOK
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
@@ -102,9 +103,10 @@ const (
 )
 
 var mainWindow *walk.MainWindow
-var saveAction, clientEditAction, serverEditAction, mysqlEditAction *walk.Action
-var statTableView *walk.TableView
-var startPB, stopPB *walk.PushButton
+var saveAction, clientEditAction, serverEditAction, mysqlEditAction, alarmEditAction, validateAction *walk.Action
+var saveCommentAction, historyAction *walk.Action
+var statTableView, alarmTableView *walk.TableView
+var startPB, stopPB, reloadPB *walk.PushButton
 var globalConfig *Config
 var timestampView *walk.Label
 var startupBox *walk.CheckBox
@@ -156,6 +158,12 @@ func StatUpdateTask() {
 		globalStat.Sort(globalStat.sortColumn, globalStat.sortOrder)
 		globalStat.Unlock()
 
+		if instance != nil {
+			ActiveAlarmTableUpdate(instance.alarms)
+		} else {
+			ActiveAlarmTableUpdate(nil)
+		}
+
 		if timestampView != nil && timestampView.Visible() {
 			timestampView.SetText(TimeStampGet())
 		}
@@ -176,7 +184,11 @@ func ActionReady() bool {
 	if saveAction != nil &&
 		clientEditAction != nil &&
 		serverEditAction != nil &&
-		mysqlEditAction != nil {
+		mysqlEditAction != nil &&
+		alarmEditAction != nil &&
+		validateAction != nil &&
+		saveCommentAction != nil &&
+		historyAction != nil {
 		return true
 	}
 	return false
@@ -187,6 +199,10 @@ func ActionEnable() {
 	clientEditAction.SetEnabled(true)
 	serverEditAction.SetEnabled(true)
 	mysqlEditAction.SetEnabled(true)
+	alarmEditAction.SetEnabled(true)
+	validateAction.SetEnabled(true)
+	saveCommentAction.SetEnabled(true)
+	historyAction.SetEnabled(true)
 }
 
 func MenuBarInit() []MenuItem {
@@ -239,6 +255,11 @@ func MenuBarInit() []MenuItem {
 							defaultApplicationConfig.UpdateLastPath(globalConfig.Filepath)
 
 							ActionEnable()
+
+							report := ConfigValidate(*config, false)
+							if len(report.Issues) > 0 {
+								ConfigReportDialog(mainWindow, "Configuration Problems", report, "")
+							}
 						}
 					},
 				},
@@ -248,6 +269,12 @@ func MenuBarInit() []MenuItem {
 					Enabled:  false,
 					Shortcut: Shortcut{Modifiers: walk.ModControl, Key: walk.KeyS},
 					OnTriggered: func() {
+						report := ConfigValidate(*globalConfig, false)
+						if report.Count(SEVERITY_ERROR) > 0 &&
+							!ConfigReportDialog(mainWindow, "Configuration Problems", report, "Save Anyway") {
+							return
+						}
+
 						err := globalConfig.Save()
 						if err != nil {
 							ErrorBoxAction(mainWindow, "Configuration file saving fails for the following reason:"+err.Error())
@@ -258,6 +285,48 @@ func MenuBarInit() []MenuItem {
 						}
 					},
 				},
+				Action{
+					Text:     "Save Configuration with Comment",
+					AssignTo: &saveCommentAction,
+					Enabled:  false,
+					OnTriggered: func() {
+						report := ConfigValidate(*globalConfig, false)
+						if report.Count(SEVERITY_ERROR) > 0 &&
+							!ConfigReportDialog(mainWindow, "Configuration Problems", report, "Save Anyway") {
+							return
+						}
+
+						comment, ok := ConfigCommentDialog(mainWindow)
+						if !ok {
+							return
+						}
+						err := globalConfig.SaveComment(comment)
+						if err != nil {
+							ErrorBoxAction(mainWindow, "Configuration file saving fails for the following reason:"+err.Error())
+						} else {
+							InfoBoxAction(mainWindow, "Configuration file saved successfully")
+
+							defaultApplicationConfig.UpdateLastPath(globalConfig.Filepath)
+						}
+					},
+				},
+				Action{
+					Text:     "Configuration History",
+					AssignTo: &historyAction,
+					Enabled:  false,
+					OnTriggered: func() {
+						config := ConfigHistoryDialog(mainWindow, globalConfig)
+						if config == nil {
+							return
+						}
+						globalConfig = config
+						if ServerRunning() {
+							InfoBoxAction(mainWindow, "Configuration rollback successfully, reload the service to apply it")
+						} else {
+							InfoBoxAction(mainWindow, "Configuration rollback successfully")
+						}
+					},
+				},
 				Separator{},
 				Action{
 					Text: "Exit",
@@ -294,6 +363,23 @@ func MenuBarInit() []MenuItem {
 						DataStoreDialog(mainWindow, globalConfig)
 					},
 				},
+				Action{
+					AssignTo: &alarmEditAction,
+					Text:     "Alarm Publish Settings",
+					Enabled:  false,
+					OnTriggered: func() {
+						AlarmSinkDialog(mainWindow, globalConfig)
+					},
+				},
+				Separator{},
+				Action{
+					AssignTo: &validateAction,
+					Text:     "Validate Configuration",
+					Enabled:  false,
+					OnTriggered: func() {
+						ConfigReportDialog(mainWindow, "Configuration Validate", ConfigValidate(*globalConfig, !ServerRunning()), "")
+					},
+				},
 			},
 		},
 		Action{
@@ -330,7 +416,12 @@ func ServerStart() error {
 		return fmt.Errorf("No OPCUA client is configured")
 	}
 
-	var err error
+	err := ConfigValidate(*globalConfig, true).Err()
+	if err != nil {
+		logs.Error("server start failed, %s", err.Error())
+		return fmt.Errorf("Starting the service fails for the following reason: %s", err.Error())
+	}
+
 	instance, err = NewOpcuaServer(*globalConfig, globalStat.items)
 	if err != nil {
 		logs.Error("server start failed, %s", err.Error())
@@ -349,6 +440,42 @@ func ServerShutdown() error {
 func ServerStatus(flag bool) {
 	startPB.SetEnabled(!flag)
 	stopPB.SetEnabled(flag)
+	reloadPB.SetEnabled(flag)
+}
+
+// ServerReload applies the config changes to the running service, the changes which can not be
+// reloaded restart the service after the confirmation
+func ServerReload() {
+	if !ServerRunning() {
+		return
+	}
+	reloadPB.SetEnabled(false)
+	defer func() {
+		ServerStatus(ServerRunning())
+	}()
+
+	err := ConfigValidate(*globalConfig, false).Err()
+	if err != nil {
+		ErrorBoxAction(mainWindow, err.Error())
+		return
+	}
+
+	err = instance.Reload(*globalConfig)
+	if errors.Is(err, ErrReloadRestart) {
+		ConfirmBoxAction(mainWindow, err.Error()+", do you want to restart the service?", func() {
+			ServerShutdown()
+			if err := ServerStart(); err != nil {
+				ErrorBoxAction(mainWindow, err.Error())
+			}
+			ServerStatus(ServerRunning())
+		})
+		return
+	}
+	if err != nil {
+		ErrorBoxAction(mainWindow, err.Error())
+		return
+	}
+	InfoBoxAction(mainWindow, "The configuration changes are applied to the running service")
 }
 
 func ServerSwitch() {
@@ -419,6 +546,60 @@ func ConsoleWidget() []Widget {
 			},
 			Model: globalStat,
 		},
+		Composite{
+			Layout: HBox{MarginsZero: true},
+			Children: []Widget{
+				Label{
+					Text: "Active Alarms:",
+				},
+				HSpacer{},
+				PushButton{
+					ToolTipText: "Acknowledge the selected alarms",
+					Text:        "Acknowledge",
+					OnClicked: func() {
+						if instance != nil {
+							instance.alarms.Acknowledge(ActiveAlarmTableSelect()...)
+						}
+					},
+				},
+				PushButton{
+					ToolTipText: "Acknowledge all alarms",
+					Text:        "Acknowledge All",
+					OnClicked: func() {
+						if instance != nil {
+							instance.alarms.AcknowledgeAll()
+						}
+					},
+				},
+			},
+		},
+		TableView{
+			AssignTo:         &alarmTableView,
+			ToolTipText:      "Active and unacknowledged alarms",
+			AlternatingRowBG: true,
+			ColumnsOrderable: true,
+			CheckBoxes:       true,
+			Columns: []TableViewColumn{
+				{Title: "Time", Width: 130},
+				{Title: "Client", Width: 100},
+				{Title: "Alarm", Width: 100},
+				{Title: "Type", Width: 50},
+				{Title: "State", Width: 100},
+				{Title: "Value", Width: 80},
+				{Title: "Message", Width: 200},
+			},
+			StyleCell: func(style *walk.CellStyle) {
+				if style.Row() >= len(activeAlarmTable.items) {
+					return
+				}
+				if activeAlarmTable.items[style.Row()].state.Active {
+					style.BackgroundColor = walk.RGB(255, 200, 200)
+				} else {
+					style.BackgroundColor = walk.RGB(255, 255, 200)
+				}
+			},
+			Model: activeAlarmTable,
+		},
 		Composite{
 			Layout: HBox{MarginsZero: true},
 			Children: []Widget{
@@ -444,6 +625,16 @@ func ConsoleWidget() []Widget {
 						ServerSwitch()
 					},
 				},
+				PushButton{
+					AssignTo:    &reloadPB,
+					Text:        "Reload",
+					Enabled:     false,
+					ToolTipText: "Apply the configuration changes without stopping the other clients",
+					MinSize:     Size{Height: 64},
+					OnClicked: func() {
+						ServerReload()
+					},
+				},
 			},
 		},
 	}
@@ -460,8 +651,8 @@ func CreateWindow() {
 		Title:          AppNameGet(),
 		Icon:           ICON_Main,
 		AssignTo:       &mainWindow,
-		MinSize:        Size{Width: 600, Height: 300},
-		Size:           Size{Width: 650, Height: 350},
+		MinSize:        Size{Width: 800, Height: 500},
+		Size:           Size{Width: 800, Height: 550},
 		Layout:         VBox{},
 		Font:           DefaultFont(),
 		MenuItems:      MenuBarInit(),
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the minimal spreadsheet parts, the cells are written as inline strings
+var xlsxParts = map[string]string{
+	"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`,
+	"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`,
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var text strings.Builder
+	for _, run := range t.Runs {
+		text.WriteString(run.T)
+	}
+	return text.String()
+}
+
+type xlsxCell struct {
+	R  string    `xml:"r,attr"`
+	T  string    `xml:"t,attr"`
+	V  string    `xml:"v"`
+	Is *xlsxText `xml:"is"`
+}
+
+type xlsxRow struct {
+	R     int        `xml:"r,attr"`
+	Cells []xlsxCell `xml:"c"`
+}
+
+type xlsxSheet struct {
+	Rows []xlsxRow `xml:"sheetData>row"`
+}
+
+type xlsxShared struct {
+	Items []xlsxText `xml:"si"`
+}
+
+func xlsxColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxColumnIndex returns the zero based column of the cell reference like "AB12"
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		index = index*26 + int(c-'A') + 1
+	}
+	return index - 1
+}
+
+func XlsxWrite(path string, rows [][]string) error {
+	var sheet bytes.Buffer
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			fmt.Fprintf(&sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, xlsxColumnName(j), i+1)
+			xml.EscapeText(&sheet, []byte(cell))
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var body bytes.Buffer
+	writer := zip.NewWriter(&body)
+
+	names := make([]string, 0, len(xlsxParts))
+	for name := range xlsxParts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		part, err := writer.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err = part.Write([]byte(xlsxParts[name])); err != nil {
+			return err
+		}
+	}
+	part, err := writer.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if _, err = part.Write(sheet.Bytes()); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, body.Bytes(), 0644)
+}
+
+func xlsxPart(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(body, v)
+}
+
+// XlsxRead reads the cells of the first worksheet as strings
+func XlsxRead(path string) ([][]string, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var shared xlsxShared
+	sheets := make([]*zip.File, 0)
+	for _, file := range reader.File {
+		switch {
+		case file.Name == "xl/sharedStrings.xml":
+			if err = xlsxPart(file, &shared); err != nil {
+				return nil, fmt.Errorf("xlsx shared strings invalid, %s", err.Error())
+			}
+		case strings.HasPrefix(file.Name, "xl/worksheets/") && strings.HasSuffix(file.Name, ".xml"):
+			sheets = append(sheets, file)
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("xlsx file %s has no worksheet", filepath.Base(path))
+	}
+	sort.Slice(sheets, func(i, j int) bool { return sheets[i].Name < sheets[j].Name })
+
+	var sheet xlsxSheet
+	if err = xlsxPart(sheets[0], &sheet); err != nil {
+		return nil, fmt.Errorf("xlsx worksheet invalid, %s", err.Error())
+	}
+
+	rows := make([][]string, 0)
+	for _, row := range sheet.Rows {
+		index := row.R - 1
+		if index < len(rows) {
+			index = len(rows)
+		}
+		for len(rows) <= index {
+			rows = append(rows, []string{})
+		}
+
+		cells := rows[index]
+		for i, cell := range row.Cells {
+			column := i
+			if cell.R != "" {
+				column = xlsxColumnIndex(cell.R)
+			}
+			if column < 0 {
+				continue
+			}
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+
+			switch cell.T {
+			case "s":
+				item, err := strconv.Atoi(cell.V)
+				if err == nil && item >= 0 && item < len(shared.Items) {
+					cells[column] = shared.Items[item].String()
+				}
+			case "inlineStr":
+				if cell.Is != nil {
+					cells[column] = cell.Is.String()
+				}
+			case "b":
+				cells[column] = map[string]string{"1": "true", "0": "false"}[cell.V]
+			default:
+				cells[column] = cell.V
+			}
+		}
+		rows[index] = cells
+	}
+	return rows, nil
+}
+
+// TableFileRead reads the rows of the csv or xlsx file by the file extension
+func TableFileRead(path string) ([][]string, error) {
+	if strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		return XlsxRead(path)
+	}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return reader.ReadAll()
+}
+
+func TableFileWrite(path string, rows [][]string) error {
+	if strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		return XlsxWrite(path, rows)
+	}
+
+	var body bytes.Buffer
+	// the byte order mark lets excel open the utf-8 csv
+	body.WriteString("\xef\xbb\xbf")
+	writer := csv.NewWriter(&body)
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return os.WriteFile(path, body.Bytes(), 0644)
+}